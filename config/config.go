@@ -20,6 +20,14 @@ func InitSetting(file string) (*AppConfig, error) {
 	config.EmailConfig = NewEmailConfig(cfg)
 	config.COSConfig = NewCOSConfig(cfg)
 	config.FilePathConfig = NewFilePathConfig(cfg)
+	config.TraceConfig = NewTraceConfig(cfg)
+	config.VisualConfig = NewVisualConfig(cfg)
+	config.DebugConfig = NewDebugConfig(cfg)
+	config.ReviewConfig = NewReviewConfig(cfg)
+	config.DraftConfig = NewDraftConfig(cfg)
+	config.RateLimitConfig = NewRateLimitConfig(cfg)
+	config.MistakeConfig = NewMistakeConfig(cfg)
+	config.ArchiveConfig = NewArchiveConfig(cfg)
 	return config, nil
 }
 
@@ -36,6 +44,14 @@ type AppConfig struct {
 	*ReleasePathConfig
 	*COSConfig
 	*FilePathConfig
+	*TraceConfig
+	*VisualConfig
+	*DebugConfig
+	*ReviewConfig
+	*DraftConfig
+	*RateLimitConfig
+	*MistakeConfig
+	*ArchiveConfig
 }
 
 type ReleasePathConfig struct {
@@ -112,10 +128,236 @@ type COSConfig struct {
 	SecretKey         string `ini:"secretKey"`
 	ProblemBucketName string `ini:"problemBucketName"`
 	ImageBucketName   string `ini:"imageBucketName"`
+	ArchiveBucketName string `ini:"archiveBucketName"`
 }
 
 func NewCOSConfig(cfg *ini.File) *COSConfig {
 	cosConfig := &COSConfig{}
 	cfg.Section("cos").MapTo(cosConfig)
+	// 没有单独配置时，归档的提交和题目放在同一个存储桶
+	if cosConfig.ArchiveBucketName == "" {
+		cosConfig.ArchiveBucketName = cosConfig.ProblemBucketName
+	}
 	return cosConfig
 }
+
+// TraceConfig
+// @Description: 执行轨迹相关配置
+type TraceConfig struct {
+	MaxSteps       int `ini:"maxSteps"`       //最多记录的步数
+	MaxBytes       int `ini:"maxBytes"`       //轨迹序列化后的最大字节数
+	MaxHeapObjects int `ini:"maxHeapObjects"` //每一步最多读取的堆对象数
+	Timeout        int `ini:"timeout"`        //一次记录的最长时间，秒
+	MaxRunning     int `ini:"maxRunning"`     //同时进行的最大记录数
+}
+
+func NewTraceConfig(cfg *ini.File) *TraceConfig {
+	traceConfig := &TraceConfig{}
+	cfg.Section("trace").MapTo(traceConfig)
+	if traceConfig.MaxSteps <= 0 {
+		traceConfig.MaxSteps = 1000
+	}
+	if traceConfig.MaxBytes <= 0 {
+		traceConfig.MaxBytes = 2 << 20
+	}
+	if traceConfig.MaxHeapObjects <= 0 {
+		traceConfig.MaxHeapObjects = 50
+	}
+	if traceConfig.Timeout <= 0 {
+		traceConfig.Timeout = 30
+	}
+	if traceConfig.MaxRunning <= 0 {
+		traceConfig.MaxRunning = 10
+	}
+	return traceConfig
+}
+
+// VisualConfig
+// @Description: 可视化指令相关配置
+type VisualConfig struct {
+	MaxCommands     int `ini:"maxCommands"`     //最多保留的指令数
+	MaxCommandBytes int `ini:"maxCommandBytes"` //所有指令的最大字节数
+	MaxArrayLength  int `ini:"maxArrayLength"`  //数组的最大长度
+}
+
+func NewVisualConfig(cfg *ini.File) *VisualConfig {
+	visualConfig := &VisualConfig{}
+	cfg.Section("visual").MapTo(visualConfig)
+	if visualConfig.MaxCommands <= 0 {
+		visualConfig.MaxCommands = 5000
+	}
+	if visualConfig.MaxCommandBytes <= 0 {
+		visualConfig.MaxCommandBytes = 1 << 20
+	}
+	if visualConfig.MaxArrayLength <= 0 {
+		visualConfig.MaxArrayLength = 1000
+	}
+	return visualConfig
+}
+
+// DebugConfig
+// @Description: 在线调试相关配置
+type DebugConfig struct {
+	SandboxCommand string `ini:"sandboxCommand"` //沙箱命令前缀，编译和调试器都通过它启动，为空时不允许调试
+	GccCommand     string `ini:"gccCommand"`     //gcc命令
+	GdbCommand     string `ini:"gdbCommand"`     //gdb命令
+	DelveCommand   string `ini:"delveCommand"`   //delve命令
+	Timeout        int    `ini:"timeout"`        //一次调试的最长时间，秒
+	IdleTimeout    int    `ini:"idleTimeout"`    //没有收到消息的最长时间，秒
+	CommandTimeout int    `ini:"commandTimeout"` //单条命令的最长执行时间，秒
+	MaxSessions    int    `ini:"maxSessions"`    //同时进行的最大调试数
+}
+
+func NewDebugConfig(cfg *ini.File) *DebugConfig {
+	debugConfig := &DebugConfig{}
+	cfg.Section("debug").MapTo(debugConfig)
+	if debugConfig.GccCommand == "" {
+		debugConfig.GccCommand = "gcc"
+	}
+	if debugConfig.GdbCommand == "" {
+		debugConfig.GdbCommand = "gdb"
+	}
+	if debugConfig.DelveCommand == "" {
+		debugConfig.DelveCommand = "dlv"
+	}
+	if debugConfig.Timeout <= 0 {
+		debugConfig.Timeout = 300
+	}
+	if debugConfig.IdleTimeout <= 0 {
+		debugConfig.IdleTimeout = 60
+	}
+	if debugConfig.CommandTimeout <= 0 {
+		debugConfig.CommandTimeout = 10
+	}
+	if debugConfig.MaxSessions <= 0 {
+		debugConfig.MaxSessions = 20
+	}
+	return debugConfig
+}
+
+// ReviewConfig
+// @Description: 题目审核发布相关配置
+type ReviewConfig struct {
+	PublishInterval int `ini:"publishInterval"` //检查定时发布题目的间隔，秒
+}
+
+func NewReviewConfig(cfg *ini.File) *ReviewConfig {
+	reviewConfig := &ReviewConfig{}
+	cfg.Section("review").MapTo(reviewConfig)
+	if reviewConfig.PublishInterval <= 0 {
+		reviewConfig.PublishInterval = 60
+	}
+	return reviewConfig
+}
+
+// DraftConfig
+// @Description: 代码草稿相关配置
+type DraftConfig struct {
+	FlushInterval int `ini:"flushInterval"` //草稿从redis保存到mysql的间隔，秒
+	HistorySize   int `ini:"historySize"`   //每个草稿保留的历史版本数
+	MaxCodeSize   int `ini:"maxCodeSize"`   //草稿的最大长度，字节
+	CacheExpire   int `ini:"cacheExpire"`   //草稿在redis中的过期时间，秒
+}
+
+func NewDraftConfig(cfg *ini.File) *DraftConfig {
+	draftConfig := &DraftConfig{}
+	cfg.Section("draft").MapTo(draftConfig)
+	if draftConfig.FlushInterval <= 0 {
+		draftConfig.FlushInterval = 60
+	}
+	if draftConfig.HistorySize <= 0 {
+		draftConfig.HistorySize = 20
+	}
+	if draftConfig.MaxCodeSize <= 0 {
+		draftConfig.MaxCodeSize = 64 * 1024
+	}
+	if draftConfig.CacheExpire <= 0 {
+		draftConfig.CacheExpire = 7 * 24 * 3600
+	}
+	return draftConfig
+}
+
+// RateLimitConfig
+// @Description: 运行和提交的限流配置，按令牌桶计算，桶的容量和每分钟补充的令牌数相同
+type RateLimitConfig struct {
+	UserRunPerMinute       int `ini:"userRunPerMinute"`       //每个用户每分钟运行次数
+	UserSubmitPerMinute    int `ini:"userSubmitPerMinute"`    //每个用户每分钟提交次数
+	ProblemRunPerMinute    int `ini:"problemRunPerMinute"`    //每个用户在一道题目中每分钟运行次数
+	ProblemSubmitPerMinute int `ini:"problemSubmitPerMinute"` //每个用户在一道题目中每分钟提交次数
+	DuplicateWindow        int `ini:"duplicateWindow"`        //相同代码重复提交时直接返回上次结果的时间范围，秒
+}
+
+func NewRateLimitConfig(cfg *ini.File) *RateLimitConfig {
+	rateLimitConfig := &RateLimitConfig{}
+	cfg.Section("rateLimit").MapTo(rateLimitConfig)
+	if rateLimitConfig.UserRunPerMinute <= 0 {
+		rateLimitConfig.UserRunPerMinute = 30
+	}
+	if rateLimitConfig.UserSubmitPerMinute <= 0 {
+		rateLimitConfig.UserSubmitPerMinute = 10
+	}
+	if rateLimitConfig.ProblemRunPerMinute <= 0 {
+		rateLimitConfig.ProblemRunPerMinute = 10
+	}
+	if rateLimitConfig.ProblemSubmitPerMinute <= 0 {
+		rateLimitConfig.ProblemSubmitPerMinute = 5
+	}
+	if rateLimitConfig.DuplicateWindow <= 0 {
+		rateLimitConfig.DuplicateWindow = 60
+	}
+	return rateLimitConfig
+}
+
+// MistakeConfig
+// @Description: 错题本相关配置
+type MistakeConfig struct {
+	MinErrCount      int `ini:"minErrCount"`      //一道题目错误多少次后加入错题本
+	DigestMaxProblem int `ini:"digestMaxProblem"` //提醒邮件中最多列出的题目数
+	CollectInterval  int `ini:"collectInterval"`  //收集错题的间隔，秒
+	DigestHour       int `ini:"digestHour"`       //每天几点发送提醒邮件，1到23
+}
+
+func NewMistakeConfig(cfg *ini.File) *MistakeConfig {
+	mistakeConfig := &MistakeConfig{}
+	cfg.Section("mistake").MapTo(mistakeConfig)
+	if mistakeConfig.MinErrCount <= 0 {
+		mistakeConfig.MinErrCount = 3
+	}
+	if mistakeConfig.DigestMaxProblem <= 0 {
+		mistakeConfig.DigestMaxProblem = 10
+	}
+	if mistakeConfig.CollectInterval <= 0 {
+		mistakeConfig.CollectInterval = 3600
+	}
+	if mistakeConfig.DigestHour <= 0 || mistakeConfig.DigestHour > 23 {
+		mistakeConfig.DigestHour = 8
+	}
+	return mistakeConfig
+}
+
+// ArchiveConfig
+// @Description: 提交归档相关配置
+type ArchiveConfig struct {
+	RetentionDays int `ini:"retentionDays"` //提交保留在mysql中的天数，超过后代码和用例数据归档到对象存储
+	BatchSize     int `ini:"batchSize"`     //每批归档的提交数
+	MaxPerRun     int `ini:"maxPerRun"`     //定时任务每次最多归档的提交数
+	Interval      int `ini:"interval"`      //归档任务的执行间隔，秒
+}
+
+func NewArchiveConfig(cfg *ini.File) *ArchiveConfig {
+	archiveConfig := &ArchiveConfig{}
+	cfg.Section("archive").MapTo(archiveConfig)
+	if archiveConfig.RetentionDays <= 0 {
+		archiveConfig.RetentionDays = 180
+	}
+	if archiveConfig.BatchSize <= 0 {
+		archiveConfig.BatchSize = 100
+	}
+	if archiveConfig.MaxPerRun <= 0 {
+		archiveConfig.MaxPerRun = 10000
+	}
+	if archiveConfig.Interval <= 0 {
+		archiveConfig.Interval = 3600
+	}
+	return archiveConfig
+}
@@ -6,3 +6,15 @@ const (
 	TouristID = uint(2)
 	UserID    = uint(3)
 )
+
+// 题目发布流程相关的权限代码
+const (
+	// PermissionProblemSubmitReview 提交题目审核
+	PermissionProblemSubmitReview = "problem:review:submit"
+	// PermissionProblemReview 审核题目，通过或者驳回
+	PermissionProblemReview = "problem:review:audit"
+	// PermissionProblemPublish 发布题目
+	PermissionProblemPublish = "problem:publish"
+	// PermissionProblemArchive 归档题目
+	PermissionProblemArchive = "problem:archive"
+)
@@ -0,0 +1,22 @@
+package consts
+
+// 提交的代码来源
+const (
+	// SourceTypeCode 直接编写的代码
+	SourceTypeCode = iota
+	// SourceTypeBlocklyXml Blockly积木程序，xml格式
+	SourceTypeBlocklyXml
+	// SourceTypeBlocklyJson Blockly积木程序，json格式
+	SourceTypeBlocklyJson
+)
+
+const (
+	// BlockTargetLanguage 积木程序翻译成的语言
+	BlockTargetLanguage = ProgramC
+	// BlockMaxWorkspaceSize 积木程序的最大字节数
+	BlockMaxWorkspaceSize = 512 * 1024
+	// BlockMaxCount 积木程序中积木的最大数量
+	BlockMaxCount = 2000
+	// BlockMaxDepth 积木的最大嵌套层数
+	BlockMaxDepth = 100
+)
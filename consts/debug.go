@@ -0,0 +1,57 @@
+package consts
+
+// DebugLanguages 支持调试的语言，c使用gdb，go使用delve
+var DebugLanguages = []string{ProgramC, ProgramGo}
+
+// IsDebugLanguageSupported 检验语言是否支持调试
+func IsDebugLanguageSupported(language string) bool {
+	for _, l := range DebugLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// DebugMaxCodeSize 调试代码的最大字节数
+const DebugMaxCodeSize = 64 * 1024
+
+// 客户端发送的调试消息类型
+const (
+	// DebugMsgStart 开始调试，必须是第一条消息
+	DebugMsgStart = "start"
+	// DebugMsgBreak 设置断点
+	DebugMsgBreak = "break"
+	// DebugMsgClear 删除断点
+	DebugMsgClear = "clear"
+	// DebugMsgContinue 继续运行到下一个断点
+	DebugMsgContinue = "continue"
+	// DebugMsgNext 单步执行，不进入函数
+	DebugMsgNext = "next"
+	// DebugMsgStep 单步执行，进入函数
+	DebugMsgStep = "step"
+	// DebugMsgStepOut 执行到当前函数返回
+	DebugMsgStepOut = "stepOut"
+	// DebugMsgStack 查看调用栈
+	DebugMsgStack = "stack"
+	// DebugMsgLocals 查看栈帧中的变量
+	DebugMsgLocals = "locals"
+	// DebugMsgStop 结束调试
+	DebugMsgStop = "stop"
+)
+
+// 服务端返回的调试消息类型
+const (
+	// DebugMsgReady 程序已启动，停在main函数入口
+	DebugMsgReady = "ready"
+	// DebugMsgStopped 程序暂停
+	DebugMsgStopped = "stopped"
+	// DebugMsgExited 程序已结束
+	DebugMsgExited = "exited"
+	// DebugMsgOutput 程序输出或编译信息
+	DebugMsgOutput = "output"
+	// DebugMsgError 命令执行失败
+	DebugMsgError = "error"
+	// DebugMsgTimeout 调试时间用完，会话结束
+	DebugMsgTimeout = "timeout"
+)
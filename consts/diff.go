@@ -0,0 +1,14 @@
+package consts
+
+// 代码对比中每一行的类型
+const (
+	// DiffEqual 两边相同
+	DiffEqual = "equal"
+	// DiffInsert 只在新代码中
+	DiffInsert = "insert"
+	// DiffDelete 只在旧代码中
+	DiffDelete = "delete"
+)
+
+// DiffMaxEdits 对比时最多计算的修改行数，超过时整体视为删除后插入
+const DiffMaxEdits = 1000
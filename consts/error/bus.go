@@ -39,33 +39,47 @@ var (
 
 /************problem相关错误**************/
 const (
-	CodeProblemCodeIsExist           = 11500 + iota //题目编号已存在
-	CodeProblemCodeCheckFailed                      // 题目编号检测失败
-	CodeProblemGetFailed                            // 获取题目失败
-	CodeProblemInsertFailed                         // 添加题目失败
-	CodeProblemUpdateFailed                         // 题目更新失败
-	CodeProblemDeleteFailed                         // 题目删除失败
-	CodeProblemListFailed                           // 获取题目列表失败
-	CodeProblemNotExist                             // 题目不存在
-	CodeProblemFileUploadFailed                     // 题目文件更新失败
-	CodeProblemFileNotExist                         // 题目文件不存在
-	CodeProblemZipFileDownloadFailed                // 题目压缩包文件下载失败
-	CodeProblemFilePathNotExist                     // 题目文件路径不存在
+	CodeProblemCodeIsExist             = 11500 + iota //题目编号已存在
+	CodeProblemCodeCheckFailed                        // 题目编号检测失败
+	CodeProblemGetFailed                              // 获取题目失败
+	CodeProblemInsertFailed                           // 添加题目失败
+	CodeProblemUpdateFailed                           // 题目更新失败
+	CodeProblemDeleteFailed                           // 题目删除失败
+	CodeProblemListFailed                             // 获取题目列表失败
+	CodeProblemNotExist                               // 题目不存在
+	CodeProblemFileUploadFailed                       // 题目文件更新失败
+	CodeProblemFileNotExist                           // 题目文件不存在
+	CodeProblemZipFileDownloadFailed                  // 题目压缩包文件下载失败
+	CodeProblemFilePathNotExist                       // 题目文件路径不存在
+	CodeProblemCloneFailed                            // 题目复制失败
+	CodeProblemStatusTransitionInvalid                // 题目状态变更不合法
+	CodeProblemChecklistNotPassed                     // 题目检查清单未通过
+	CodeProblemNotPublished                           // 题目未发布
+	CodeProblemPublishTimeInvalid                     // 定时发布时间不合法
+	CodeProblemTemplateNotExist                       // 题目模板不存在
+	CodeProblemLanguageInvalid                        // 题目语言设置不合法
 )
 
 var (
-	ErrProblemCodeIsExist           = NewError(CodeProblemCodeIsExist, "problem code is exist", ErrTypeBus)
-	ErrProblemCodeCheckFailed       = NewError(CodeProblemCodeCheckFailed, "The problem code check failed", ErrTypeServer)
-	ErrProblemGetFailed             = NewError(CodeProblemGetFailed, "The problem get failed", ErrTypeServer)
-	ErrProblemInsertFailed          = NewError(CodeProblemInsertFailed, "The problem insert failed", ErrTypeServer)
-	ErrProblemUpdateFailed          = NewError(CodeProblemUpdateFailed, "The problem update failed", ErrTypeServer)
-	ErrProblemDeleteFailed          = NewError(CodeProblemDeleteFailed, "The problem delete failed", ErrTypeServer)
-	ErrProblemListFailed            = NewError(CodeProblemListFailed, "Failed to get the problem list", ErrTypeServer)
-	ErrProblemFileUploadFailed      = NewError(CodeProblemFileUploadFailed, "The problem file storage failed", ErrTypeServer)
-	ErrProblemNotExist              = NewError(CodeProblemNotExist, "The problem does not exist", ErrTypeBus)
-	ErrProblemFileNotExist          = NewError(CodeProblemFileNotExist, "The problem file is not exist", ErrTypeBus)
-	ErrProblemZipFileDownloadFailed = NewError(CodeProblemZipFileDownloadFailed, "The problem zipfile download failed", ErrTypeServer)
-	ErrProblemFilePathNotExist      = NewError(CodeProblemFilePathNotExist, "题目编程文件不存在，需要上传编程文件", ErrTypeBus)
+	ErrProblemCodeIsExist             = NewError(CodeProblemCodeIsExist, "problem code is exist", ErrTypeBus)
+	ErrProblemCodeCheckFailed         = NewError(CodeProblemCodeCheckFailed, "The problem code check failed", ErrTypeServer)
+	ErrProblemGetFailed               = NewError(CodeProblemGetFailed, "The problem get failed", ErrTypeServer)
+	ErrProblemInsertFailed            = NewError(CodeProblemInsertFailed, "The problem insert failed", ErrTypeServer)
+	ErrProblemUpdateFailed            = NewError(CodeProblemUpdateFailed, "The problem update failed", ErrTypeServer)
+	ErrProblemDeleteFailed            = NewError(CodeProblemDeleteFailed, "The problem delete failed", ErrTypeServer)
+	ErrProblemListFailed              = NewError(CodeProblemListFailed, "Failed to get the problem list", ErrTypeServer)
+	ErrProblemFileUploadFailed        = NewError(CodeProblemFileUploadFailed, "The problem file storage failed", ErrTypeServer)
+	ErrProblemNotExist                = NewError(CodeProblemNotExist, "The problem does not exist", ErrTypeBus)
+	ErrProblemFileNotExist            = NewError(CodeProblemFileNotExist, "The problem file is not exist", ErrTypeBus)
+	ErrProblemZipFileDownloadFailed   = NewError(CodeProblemZipFileDownloadFailed, "The problem zipfile download failed", ErrTypeServer)
+	ErrProblemFilePathNotExist        = NewError(CodeProblemFilePathNotExist, "题目编程文件不存在，需要上传编程文件", ErrTypeBus)
+	ErrProblemCloneFailed             = NewError(CodeProblemCloneFailed, "The problem clone failed", ErrTypeServer)
+	ErrProblemStatusTransitionInvalid = NewError(CodeProblemStatusTransitionInvalid, "题目当前状态不允许该操作", ErrTypeBus)
+	ErrProblemChecklistNotPassed      = NewError(CodeProblemChecklistNotPassed, "题目检查未通过，请完善用例、参考代码和题目描述", ErrTypeBus)
+	ErrProblemNotPublished            = NewError(CodeProblemNotPublished, "题目未发布", ErrTypeBus)
+	ErrProblemPublishTimeInvalid      = NewError(CodeProblemPublishTimeInvalid, "定时发布时间不合法", ErrTypeBadReq)
+	ErrProblemTemplateNotExist        = NewError(CodeProblemTemplateNotExist, "The problem template does not exist", ErrTypeBus)
+	ErrProblemLanguageInvalid         = NewError(CodeProblemLanguageInvalid, "题目语言设置不合法", ErrTypeBadReq)
 )
 
 /************judge相关错误**************/
@@ -74,13 +88,35 @@ const (
 	CodeExecuteFailed
 	CodeCompileFailed
 	CodeLanguageNotSupported
+	CodeTraceNotSupported
+	CodeTraceRunning
+	CodeBlockWorkspaceInvalid
+	CodeSubmissionNotExist
+	CodeDebugNotSupported
+	CodeDebugNotAvailable
+	CodeDebugSessionLimit
+	CodeProblemAttemptRepairRunning
+	CodeSubmissionDiffInvalid
+	CodeExportFormatInvalid
+	CodeSubmitTooFrequent
 )
 
 var (
-	ErrSubmitFailed         = NewError(CodeSubmitFailed, "Submit error", ErrTypeBus)
-	ErrExecuteFailed        = NewError(CodeExecuteFailed, "Execute error", ErrTypeBus)
-	ErrCompileFailed        = NewError(CodeCompileFailed, "Compilation error", ErrTypeBus)
-	ErrLanguageNotSupported = NewError(CodeLanguageNotSupported, "This language is not supported", ErrTypeBus)
+	ErrSubmitFailed                = NewError(CodeSubmitFailed, "Submit error", ErrTypeBus)
+	ErrExecuteFailed               = NewError(CodeExecuteFailed, "Execute error", ErrTypeBus)
+	ErrCompileFailed               = NewError(CodeCompileFailed, "Compilation error", ErrTypeBus)
+	ErrLanguageNotSupported        = NewError(CodeLanguageNotSupported, "This language is not supported", ErrTypeBus)
+	ErrTraceNotSupported           = NewError(CodeTraceNotSupported, "该语言不支持可视化执行", ErrTypeBus)
+	ErrTraceRunning                = NewError(CodeTraceRunning, "已有正在进行的可视化执行，请稍后再试", ErrTypeBus)
+	ErrBlockWorkspaceInvalid       = NewError(CodeBlockWorkspaceInvalid, "积木程序不合法", ErrTypeBadReq)
+	ErrSubmissionNotExist          = NewError(CodeSubmissionNotExist, "提交记录不存在", ErrTypeBus)
+	ErrDebugNotSupported           = NewError(CodeDebugNotSupported, "该语言不支持调试", ErrTypeBus)
+	ErrDebugNotAvailable           = NewError(CodeDebugNotAvailable, "调试功能未开启", ErrTypeBus)
+	ErrDebugSessionLimit           = NewError(CodeDebugSessionLimit, "调试人数过多，请稍后再试", ErrTypeBus)
+	ErrProblemAttemptRepairRunning = NewError(CodeProblemAttemptRepairRunning, "做题情况正在修复中", ErrTypeBus)
+	ErrSubmissionDiffInvalid       = NewError(CodeSubmissionDiffInvalid, "只能对比同一道题目的两次不同提交", ErrTypeBadReq)
+	ErrExportFormatInvalid         = NewError(CodeExportFormatInvalid, "不支持的导出格式", ErrTypeBadReq)
+	ErrSubmitTooFrequent           = NewError(CodeSubmitTooFrequent, "操作过于频繁，请稍后再试", ErrTypeBus)
 )
 
 /************permission相关错误**************/
@@ -124,3 +160,94 @@ var (
 	ErrHashTypeNotSupport = NewError(CodeHashTypeNotSupportError, "hash type not support", ErrTypeBadReq)
 	ErrHashMissMatch      = NewError(CodeHashMissMatchError, "hash miss match", ErrTypeBus)
 )
+
+/*************题解管理*****************/
+const (
+	CodeProblemSolutionNotExist = 15500 + iota
+	CodeProblemSolutionLocked
+	CodeProblemSolutionVisibleTypeWrong
+)
+
+var (
+	ErrProblemSolutionNotExist         = NewError(CodeProblemSolutionNotExist, "The problem solution does not exist", ErrTypeBus)
+	ErrProblemSolutionLocked           = NewError(CodeProblemSolutionLocked, "题解暂未公开", ErrTypeBus)
+	ErrProblemSolutionVisibleTypeWrong = NewError(CodeProblemSolutionVisibleTypeWrong, "题解可见规则错误", ErrTypeBadReq)
+)
+
+/*************讨论区*****************/
+const (
+	CodeDiscussionNotExist = 16000 + iota
+	CodeDiscussionCommentNotExist
+	CodeDiscussionContentEmpty
+	CodeDiscussionSpoiler
+)
+
+var (
+	ErrDiscussionNotExist        = NewError(CodeDiscussionNotExist, "The discussion does not exist", ErrTypeBus)
+	ErrDiscussionCommentNotExist = NewError(CodeDiscussionCommentNotExist, "The comment does not exist", ErrTypeBus)
+	ErrDiscussionContentEmpty    = NewError(CodeDiscussionContentEmpty, "内容不能为空", ErrTypeBadReq)
+	ErrDiscussionSpoiler         = NewError(CodeDiscussionSpoiler, "通过题目后才能查看该帖子", ErrTypeBus)
+)
+
+/*************题单*****************/
+const (
+	CodeProblemMenuNotExist = 16500 + iota
+	CodeProblemMenuSectionNotExist
+	CodeProblemMenuOrderInvalid
+	CodeProblemMenuForbidden
+	CodeProblemMenuVisibilityWrong
+)
+
+var (
+	ErrProblemMenuNotExist        = NewError(CodeProblemMenuNotExist, "The problem menu does not exist", ErrTypeBus)
+	ErrProblemMenuSectionNotExist = NewError(CodeProblemMenuSectionNotExist, "The problem menu section does not exist", ErrTypeBus)
+	ErrProblemMenuOrderInvalid    = NewError(CodeProblemMenuOrderInvalid, "题单排序数据不合法", ErrTypeBadReq)
+	ErrProblemMenuForbidden       = NewError(CodeProblemMenuForbidden, "没有权限访问该题单", ErrTypeBus)
+	ErrProblemMenuVisibilityWrong = NewError(CodeProblemMenuVisibilityWrong, "题单可见性设置错误", ErrTypeBadReq)
+)
+
+/*************题目提示*****************/
+const (
+	CodeProblemHintNotExist = 17000 + iota
+	CodeProblemHintAllUnlocked
+)
+
+var (
+	ErrProblemHintNotExist    = NewError(CodeProblemHintNotExist, "The problem hint does not exist", ErrTypeBus)
+	ErrProblemHintAllUnlocked = NewError(CodeProblemHintAllUnlocked, "所有提示都已解锁", ErrTypeBus)
+)
+
+/*************代码草稿*****************/
+const (
+	CodeCodeDraftTooLarge = 17500 + iota
+	CodeCodeDraftSnapshotNotExist
+)
+
+var (
+	ErrCodeDraftTooLarge         = NewError(CodeCodeDraftTooLarge, "代码长度超过限制", ErrTypeBadReq)
+	ErrCodeDraftSnapshotNotExist = NewError(CodeCodeDraftSnapshotNotExist, "草稿的历史版本不存在", ErrTypeBus)
+)
+
+/*************错题本*****************/
+const (
+	CodeMistakeNoteNotExist = 18000 + iota
+	CodeMistakeNoteExist
+	CodeMistakeQualityInvalid
+)
+
+var (
+	ErrMistakeNoteNotExist   = NewError(CodeMistakeNoteNotExist, "错题本中没有这道题目", ErrTypeBus)
+	ErrMistakeNoteExist      = NewError(CodeMistakeNoteExist, "题目已在错题本中", ErrTypeBus)
+	ErrMistakeQualityInvalid = NewError(CodeMistakeQualityInvalid, "掌握程度应为0到5", ErrTypeBadReq)
+)
+
+/*************用户笔记*****************/
+const (
+	CodeUserNoteNotExist = 18500 + iota
+	CodeUserNoteTooLarge
+)
+
+var (
+	ErrUserNoteNotExist = NewError(CodeUserNoteNotExist, "笔记不存在", ErrTypeBus)
+	ErrUserNoteTooLarge = NewError(CodeUserNoteTooLarge, "笔记长度超过限制", ErrTypeBadReq)
+)
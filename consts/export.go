@@ -0,0 +1,19 @@
+package consts
+
+// 导出文件的格式
+const (
+	ExportFormatCsv  = "csv"
+	ExportFormatJson = "json"
+)
+
+// SubmissionSortProperties 管理员查询提交时允许排序的字段
+var SubmissionSortProperties = map[string]bool{
+	"created_at":  true,
+	"time_used":   true,
+	"memory_used": true,
+}
+
+// IsExportFormatSupported 检验导出格式是否支持
+func IsExportFormatSupported(format string) bool {
+	return format == ExportFormatCsv || format == ExportFormatJson
+}
@@ -0,0 +1,12 @@
+package consts
+
+// 错题本复习使用SM-2间隔重复算法
+const (
+	// MistakeQualityMax 复习时自评的掌握程度为0到5，小于MistakeQualityPass时重新开始复习
+	MistakeQualityMax  = 5
+	MistakeQualityPass = 3
+	// MistakeDefaultEase 初始的难度系数
+	MistakeDefaultEase = 2.5
+	// MistakeMinEase 难度系数的最小值
+	MistakeMinEase = 1.3
+)
@@ -13,13 +13,126 @@ const (
 	RuntimeError
 )
 
+// FinalVerdicts 提交的最终判题结果
+var FinalVerdicts = []int{Accepted, WrongAnswer, CompileError, RuntimeError}
+
+// IsFinalVerdict 检验是否为提交的最终判题结果，运行代码的结果不是
+func IsFinalVerdict(status int) bool {
+	switch status {
+	case Accepted, WrongAnswer, CompileError, RuntimeError:
+		return true
+	}
+	return false
+}
+
 const (
 	ProgramC    = "c"
 	ProgramJava = "java"
 	ProgramGo   = "go"
 )
 
+// ProgramLanguages 支持的所有编程语言
+var ProgramLanguages = []string{ProgramC, ProgramJava, ProgramGo}
+
+// defaultLanguageMultipliers 各个语言默认的时间、内存限制倍率，未列出的语言为1
+var defaultLanguageMultipliers = map[string][2]float64{
+	// jvm启动耗时且占用内存较多
+	ProgramJava: {2, 2},
+}
+
+// GetDefaultLanguageMultiplier 获取语言默认的时间、内存限制倍率
+func GetDefaultLanguageMultiplier(language string) (float64, float64) {
+	if multiplier, ok := defaultLanguageMultipliers[language]; ok {
+		return multiplier[0], multiplier[1]
+	}
+	return 1, 1
+}
+
+// IsProgramLanguageSupported 检验编程语言是否支持
+func IsProgramLanguageSupported(language string) bool {
+	for _, l := range ProgramLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
 const (
 	CodeTypeAcm  = "acm"
 	CodeTypeCore = "core_code"
 )
+
+// 题目分布统计的类别
+const (
+	StatisticCategoryStatus   = "status"
+	StatisticCategoryLanguage = "language"
+	// StatisticCategoryTime 通过提交的耗时分桶，统计项为分桶下界，毫秒
+	StatisticCategoryTime = "time"
+	// StatisticCategoryMemory 通过提交的内存分桶，统计项为分桶下界，字节
+	StatisticCategoryMemory = "memory"
+)
+
+// 题解的可见规则
+const (
+	// SolutionVisibleAlways 总是可见
+	SolutionVisibleAlways = 1 + iota
+	// SolutionVisibleAfterAccepted 用户通过题目以后可见
+	SolutionVisibleAfterAccepted
+	// SolutionVisibleAfterDate 指定时间以后可见
+	SolutionVisibleAfterDate
+)
+
+// 用户做题状态，对应 ProblemAttempt.Status
+const (
+	// AttemptStatusNotStarted 未开始
+	AttemptStatusNotStarted = iota
+	// AttemptStatusTrying 进行中
+	AttemptStatusTrying
+	// AttemptStatusAccepted 提交成功
+	AttemptStatusAccepted
+)
+
+// 点赞对象类型
+const (
+	UpvoteTargetDiscussion = "discussion"
+	UpvoteTargetComment    = "comment"
+)
+
+// 题目发布流程的状态，对应 Problem.Status
+const (
+	// ProblemStatusDraft 草稿
+	ProblemStatusDraft = 1 + iota
+	// ProblemStatusInReview 审核中
+	ProblemStatusInReview
+	// ProblemStatusApproved 审核通过，待发布
+	ProblemStatusApproved
+	// ProblemStatusPublished 已发布
+	ProblemStatusPublished
+	// ProblemStatusArchived 已归档
+	ProblemStatusArchived
+)
+
+// 题单类型
+const (
+	// ProblemMenuTypeOfficial 管理员创建的题单
+	ProblemMenuTypeOfficial = iota
+	// ProblemMenuTypeUser 用户创建的题单
+	ProblemMenuTypeUser
+)
+
+// 题单可见性
+const (
+	// ProblemMenuVisibilityPublic 公开，所有人可见，可以被复制
+	ProblemMenuVisibilityPublic = iota
+	// ProblemMenuVisibilityPrivate 仅创建者可见
+	ProblemMenuVisibilityPrivate
+	// ProblemMenuVisibilityShared 持有分享链接的用户可见
+	ProblemMenuVisibilityShared
+)
+
+// ProblemMaxDifficulty 题目难度为1到5
+const ProblemMaxDifficulty = 5
+
+// AccountRecentVerdictDays 个人统计中展示最近多少天的判题结果
+const AccountRecentVerdictDays = 30
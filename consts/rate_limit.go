@@ -0,0 +1,7 @@
+package consts
+
+// 限流区分的操作，运行代码和提交分别计数
+const (
+	SubmitActionRun    = "run"
+	SubmitActionSubmit = "submit"
+)
@@ -0,0 +1,36 @@
+package consts
+
+// 执行轨迹中的事件类型
+const (
+	// TraceEventStepLine 执行到新的一行
+	TraceEventStepLine = "step_line"
+	// TraceEventCall 进入函数
+	TraceEventCall = "call"
+	// TraceEventReturn 函数返回
+	TraceEventReturn = "return"
+	// TraceEventException 程序异常
+	TraceEventException = "exception"
+)
+
+// TraceLanguages 支持记录执行轨迹的语言，通过调试器单步执行记录，c使用gdb，go使用delve
+var TraceLanguages = []string{ProgramC, ProgramGo}
+
+// IsTraceLanguageSupported 检验语言是否支持记录执行轨迹
+func IsTraceLanguageSupported(language string) bool {
+	for _, l := range TraceLanguages {
+		if l == language {
+			return true
+		}
+	}
+	return false
+}
+
+// 执行轨迹被截断的原因
+const (
+	// TraceTruncatedSteps 步数超过限制
+	TraceTruncatedSteps = "steps"
+	// TraceTruncatedSize 大小超过限制
+	TraceTruncatedSize = "size"
+	// TraceTruncatedTime 执行时间超过限制
+	TraceTruncatedTime = "time"
+)
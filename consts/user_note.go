@@ -0,0 +1,4 @@
+package consts
+
+// UserNoteMaxSize 笔记的最大长度，字节
+const UserNoteMaxSize = 64 * 1024
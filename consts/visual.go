@@ -0,0 +1,30 @@
+package consts
+
+// VisualStdoutMarker 标准输出中以该标记开头的行为可视化指令，判题只返回标准输出，因此不支持单独的文件描述符
+const VisualStdoutMarker = "@@VIZ "
+
+// 可视化指令
+const (
+	// VisualOpArrayInit 初始化数组
+	VisualOpArrayInit = "array.init"
+	// VisualOpArraySet 修改数组元素
+	VisualOpArraySet = "array.set"
+	// VisualOpArraySwap 交换数组元素
+	VisualOpArraySwap = "array.swap"
+	// VisualOpArrayHighlight 高亮数组元素
+	VisualOpArrayHighlight = "array.highlight"
+	// VisualOpGraphNode 添加或修改图的节点
+	VisualOpGraphNode = "graph.node"
+	// VisualOpGraphEdge 添加或修改图的边
+	VisualOpGraphEdge = "graph.edge"
+	// VisualOpPointerMove 移动指向数组下标的指针
+	VisualOpPointerMove = "pointer.move"
+)
+
+// 可视化时间线被截断的原因
+const (
+	// VisualTruncatedCount 指令数量超过限制
+	VisualTruncatedCount = "count"
+	// VisualTruncatedSize 指令大小超过限制
+	VisualTruncatedSize = "size"
+)
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AccountController struct {
+	accountService services.AccountService
+}
+
+func NewAccountController(accountService services.AccountService) *AccountController {
+	return &AccountController{
+		accountService: accountService,
+	}
+}
+
+// GetAccountInfo 获取当前用户的账号信息和做题统计
+func (ctl *AccountController) GetAccountInfo(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	accountInfo, err := ctl.accountService.GetAccountInfo(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(accountInfo)
+}
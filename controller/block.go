@@ -0,0 +1,47 @@
+package controller
+
+import (
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type BlockController struct {
+	blockService services.BlockService
+}
+
+func NewBlockController(blockService services.BlockService) *BlockController {
+	return &BlockController{
+		blockService: blockService,
+	}
+}
+
+// TranslateBlockProgram 预览积木程序翻译后的代码
+func (ctl *BlockController) TranslateBlockProgram(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	program := &request.BlockProgram{}
+	if err := ctx.BindJSON(program); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	answer, err := ctl.blockService.TranslateBlockProgram(program)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(answer)
+}
+
+func (ctl *BlockController) GetSubmissionWorkspace(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	submissionID := utils.GetIntParamOrDefault(ctx, "id", 0)
+	answer, err := ctl.blockService.GetSubmissionWorkspace(ctx, uint(submissionID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(answer)
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type CodeDraftController struct {
+	codeDraftService services.CodeDraftService
+}
+
+func NewCodeDraftController(codeDraftService services.CodeDraftService) *CodeDraftController {
+	return &CodeDraftController{
+		codeDraftService: codeDraftService,
+	}
+}
+
+// SaveCodeDraft 编辑器自动保存草稿
+func (ctl *CodeDraftController) SaveCodeDraft(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)
+	err := ctl.codeDraftService.SaveCodeDraft(ctx, uint(problemID), ctx.PostForm("language"), ctx.PostForm("code"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("保存成功")
+}
+
+func (ctl *CodeDraftController) GetCodeDraftHistory(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.GetIntQueryOrDefault(ctx, "problemID", 0)
+	snapshots, err := ctl.codeDraftService.GetCodeDraftHistory(ctx, uint(problemID), ctx.Query("language"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(snapshots)
+}
+
+func (ctl *CodeDraftController) RestoreCodeDraft(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)
+	version := utils.AtoiOrDefault(ctx.PostForm("version"), 0)
+	code, err := ctl.codeDraftService.RestoreCodeDraft(ctx, uint(problemID), ctx.PostForm("language"), version)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("恢复成功", code)
+}
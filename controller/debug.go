@@ -0,0 +1,66 @@
+package controller
+
+import (
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"log"
+	"net/http"
+	"strings"
+)
+
+// debugUpgrader 通过token认证，不依赖cookie，因此允许跨域连接
+var debugUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+	CheckOrigin: func(r *http.Request) bool {
+		return true
+	},
+}
+
+type DebugController struct {
+	debugService services.DebugService
+}
+
+func NewDebugController(debugService services.DebugService) *DebugController {
+	return &DebugController{
+		debugService: debugService,
+	}
+}
+
+// Debug 建立调试的WebSocket连接，浏览器不能设置请求头，token可以通过query参数传递
+func (ctl *DebugController) Debug(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	token := ctx.Query("token")
+	if token == "" {
+		token = strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	}
+	if token == "" {
+		result.Error(e.ErrSessionInvalid)
+		return
+	}
+	claims, err := utils.ParseToken(token)
+	if err != nil || claims == nil {
+		result.Error(e.ErrSessionExpire)
+		return
+	}
+	conn, err := debugUpgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	ctl.debugService.ServeDebugSession(conn, &dto.UserInfo{
+		ID:          claims.ID,
+		Avatar:      claims.Avatar,
+		LoginName:   claims.LoginName,
+		UserName:    claims.UserName,
+		Email:       claims.Email,
+		Phone:       claims.Phone,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+	})
+}
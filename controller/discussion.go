@@ -0,0 +1,174 @@
+package controller
+
+import (
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/model/repository"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type DiscussionController struct {
+	discussionService services.DiscussionService
+}
+
+func NewDiscussionController(discussionService services.DiscussionService) *DiscussionController {
+	return &DiscussionController{
+		discussionService: discussionService,
+	}
+}
+
+func (ctl *DiscussionController) GetDiscussionList(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	pageQuery.Query = &request.DiscussionForList{
+		ProblemID: uint(utils.GetIntQueryOrDefault(ctx, "problemID", 0)),
+		UserID:    uint(utils.GetIntQueryOrDefault(ctx, "userID", 0)),
+		Title:     ctx.Query("title"),
+	}
+	pageInfo, err := ctl.discussionService.GetDiscussionList(ctx, pageQuery)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
+
+func (ctl *DiscussionController) GetDiscussionByID(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	discussion, err := ctl.discussionService.GetDiscussionByID(ctx, uint(id))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(discussion)
+}
+
+func (ctl *DiscussionController) InsertDiscussion(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	discussion := &repository.Discussion{
+		ProblemID: uint(utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)),
+		Title:     ctx.PostForm("title"),
+		Content:   ctx.PostForm("content"),
+		Spoiler:   ctx.PostForm("spoiler") == "true",
+	}
+	id, err := ctl.discussionService.InsertDiscussion(ctx, discussion)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("发布成功", id)
+}
+
+func (ctl *DiscussionController) UpdateDiscussion(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	discussion := &repository.Discussion{
+		Title:   ctx.PostForm("title"),
+		Content: ctx.PostForm("content"),
+		Spoiler: ctx.PostForm("spoiler") == "true",
+	}
+	discussion.ID = uint(utils.AtoiOrDefault(ctx.PostForm("id"), 0))
+	if err := ctl.discussionService.UpdateDiscussion(ctx, discussion); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("更新成功")
+}
+
+func (ctl *DiscussionController) DeleteDiscussion(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	if err := ctl.discussionService.DeleteDiscussion(ctx, uint(id)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("删除成功")
+}
+
+func (ctl *DiscussionController) PinDiscussion(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.AtoiOrDefault(ctx.PostForm("id"), 0)
+	pinned := utils.Atob(ctx.PostForm("pinned"))
+	if id == 0 {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	if err := ctl.discussionService.PinDiscussion(ctx, uint(id), pinned); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("设置成功")
+}
+
+func (ctl *DiscussionController) UpvoteDiscussion(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	upvoted, err := ctl.discussionService.UpvoteDiscussion(ctx, uint(id))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(upvoted)
+}
+
+func (ctl *DiscussionController) GetDiscussionCommentList(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	pageQuery.Query = &request.DiscussionCommentForList{
+		DiscussionID: uint(utils.GetIntQueryOrDefault(ctx, "discussionID", 0)),
+	}
+	pageInfo, err := ctl.discussionService.GetDiscussionCommentList(ctx, pageQuery)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
+
+func (ctl *DiscussionController) InsertDiscussionComment(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	comment := &repository.DiscussionComment{
+		DiscussionID: uint(utils.AtoiOrDefault(ctx.PostForm("discussionID"), 0)),
+		ParentID:     uint(utils.AtoiOrDefault(ctx.PostForm("parentID"), 0)),
+		Content:      ctx.PostForm("content"),
+		Spoiler:      ctx.PostForm("spoiler") == "true",
+	}
+	id, err := ctl.discussionService.InsertDiscussionComment(ctx, comment)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("回复成功", id)
+}
+
+func (ctl *DiscussionController) DeleteDiscussionComment(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	if err := ctl.discussionService.DeleteDiscussionComment(ctx, uint(id)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("删除成功")
+}
+
+func (ctl *DiscussionController) UpvoteDiscussionComment(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	upvoted, err := ctl.discussionService.UpvoteDiscussionComment(ctx, uint(id))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(upvoted)
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"fmt"
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/form/response"
+	"github.com/gin-gonic/gin"
+	"log"
+	"time"
+)
+
+// setExportHeader 设置下载文件的响应头，文件名为name加上当前时间
+func setExportHeader(ctx *gin.Context, name string, format string) {
+	if format == consts.ExportFormatCsv {
+		ctx.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		ctx.Header("Content-Type", "application/json; charset=utf-8")
+	}
+	fileName := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102150405"), format)
+	ctx.Header("Content-Disposition", "attachment; filename="+fileName)
+}
+
+// handleExportError 已经开始写入文件时无法再返回错误信息，只记录日志
+func handleExportError(ctx *gin.Context, result *response.Result, err *e.Error) {
+	if ctx.Writer.Written() {
+		log.Println(err.Message)
+		return
+	}
+	ctx.Header("Content-Type", "application/json; charset=utf-8")
+	ctx.Header("Content-Disposition", "")
+	result.Error(err)
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type MistakeNoteController struct {
+	mistakeNoteService services.MistakeNoteService
+}
+
+func NewMistakeNoteController(mistakeNoteService services.MistakeNoteService) *MistakeNoteController {
+	return &MistakeNoteController{
+		mistakeNoteService: mistakeNoteService,
+	}
+}
+
+// GetMistakeNoteList 获取错题本，dueToday为true时只获取今天需要复习的题目
+func (ctl *MistakeNoteController) GetMistakeNoteList(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	pageInfo, err := ctl.mistakeNoteService.GetMistakeNoteList(ctx, pageQuery, utils.GetBoolQuery(ctx, "dueToday"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
+
+func (ctl *MistakeNoteController) AddMistakeNote(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)
+	if err := ctl.mistakeNoteService.AddMistakeNote(ctx, uint(problemID)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("已加入错题本")
+}
+
+func (ctl *MistakeNoteController) UpdateMistakeNoteContent(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)
+	if err := ctl.mistakeNoteService.UpdateMistakeNoteContent(ctx, uint(problemID), ctx.PostForm("note")); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("保存成功")
+}
+
+// ReviewMistakeNote 完成一次复习，quality为0到5的掌握程度
+func (ctl *MistakeNoteController) ReviewMistakeNote(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)
+	quality := utils.AtoiOrDefault(ctx.PostForm("quality"), -1)
+	note, err := ctl.mistakeNoteService.ReviewMistakeNote(ctx, uint(problemID), quality)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(note)
+}
+
+func (ctl *MistakeNoteController) DeleteMistakeNote(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.GetIntQueryOrDefault(ctx, "problemID", 0)
+	if err := ctl.mistakeNoteService.DeleteMistakeNote(ctx, uint(problemID)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("已移出错题本")
+}
+
+// SetMistakeNoteDigest 开启或关闭复习提醒邮件
+func (ctl *MistakeNoteController) SetMistakeNoteDigest(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	enabled := utils.Atob(ctx.PostForm("enabled"))
+	if err := ctl.mistakeNoteService.SetMistakeNoteDigest(ctx, enabled); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("设置成功")
+}
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ProblemAttemptController struct {
+	problemAttemptService services.ProblemAttemptService
+}
+
+func NewProblemAttemptController(problemAttemptService services.ProblemAttemptService) *ProblemAttemptController {
+	return &ProblemAttemptController{
+		problemAttemptService: problemAttemptService,
+	}
+}
+
+// RepairProblemAttempts 管理员根据提交记录重新计算所有用户的做题情况
+func (ctl *ProblemAttemptController) RepairProblemAttempts(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	answer, err := ctl.problemAttemptService.RepairProblemAttempts()
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(answer)
+}
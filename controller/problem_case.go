@@ -0,0 +1,30 @@
+package controller
+
+import (
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ProblemCaseController struct {
+	problemCaseService services.ProblemCaseService
+}
+
+func NewProblemCaseController(problemCaseService services.ProblemCaseService) *ProblemCaseController {
+	return &ProblemCaseController{
+		problemCaseService: problemCaseService,
+	}
+}
+
+// UpdateProblemCaseSample 设置用例是否为样例，sample为true或false
+func (ctl *ProblemCaseController) UpdateProblemCaseSample(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	sample := utils.GetBoolQuery(ctx, "sample")
+	if err := ctl.problemCaseService.UpdateProblemCaseSample(uint(id), sample); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("设置成功")
+}
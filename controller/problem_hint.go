@@ -0,0 +1,112 @@
+package controller
+
+import (
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/model/repository"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ProblemHintController struct {
+	problemHintService services.ProblemHintService
+}
+
+func NewProblemHintController(problemHintService services.ProblemHintService) *ProblemHintController {
+	return &ProblemHintController{
+		problemHintService: problemHintService,
+	}
+}
+
+func (ctl *ProblemHintController) InsertProblemHint(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	hint := &repository.ProblemHint{}
+	if err := ctx.BindJSON(hint); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	id, err := ctl.problemHintService.InsertProblemHint(hint)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("添加成功", id)
+}
+
+func (ctl *ProblemHintController) UpdateProblemHint(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	hint := &repository.ProblemHint{}
+	if err := ctx.BindJSON(hint); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	if err := ctl.problemHintService.UpdateProblemHint(hint); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("更新成功")
+}
+
+func (ctl *ProblemHintController) DeleteProblemHint(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	if err := ctl.problemHintService.DeleteProblemHint(uint(id)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("删除成功")
+}
+
+func (ctl *ProblemHintController) GetProblemHints(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.GetIntQueryOrDefault(ctx, "problemID", 0)
+	hints, err := ctl.problemHintService.GetProblemHints(uint(problemID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(hints)
+}
+
+func (ctl *ProblemHintController) GetProblemHintUnlockList(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	pageQuery.Query = &request.ProblemHintUnlockForList{
+		ProblemID: uint(utils.GetIntQueryOrDefault(ctx, "problemID", 0)),
+		UserID:    uint(utils.GetIntQueryOrDefault(ctx, "userID", 0)),
+	}
+	pageInfo, err := ctl.problemHintService.GetProblemHintUnlockList(pageQuery)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
+
+func (ctl *ProblemHintController) GetUserProblemHints(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.GetIntQueryOrDefault(ctx, "problemID", 0)
+	hints, err := ctl.problemHintService.GetUserProblemHints(ctx, uint(problemID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(hints)
+}
+
+func (ctl *ProblemHintController) UnlockProblemHint(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)
+	hint, err := ctl.problemHintService.UnlockProblemHint(ctx, uint(problemID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(hint)
+}
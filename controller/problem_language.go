@@ -0,0 +1,47 @@
+package controller
+
+import (
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/form/response"
+	"funoj-backend/model/repository"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ProblemLanguageController struct {
+	problemService services.ProblemService
+}
+
+func NewProblemLanguageController(problemService services.ProblemService) *ProblemLanguageController {
+	return &ProblemLanguageController{
+		problemService: problemService,
+	}
+}
+
+func (ctl *ProblemLanguageController) GetProblemLanguages(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.GetIntQueryOrDefault(ctx, "problemID", 0)
+	languages, err := ctl.problemService.GetProblemLanguages(uint(problemID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(languages)
+}
+
+// UpdateProblemLanguages 设置题目允许的语言及倍率，请求体为语言设置数组
+func (ctl *ProblemLanguageController) UpdateProblemLanguages(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.GetIntQueryOrDefault(ctx, "problemID", 0)
+	var languages []*repository.ProblemLanguage
+	if err := ctx.BindJSON(&languages); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	if err := ctl.problemService.UpdateProblemLanguages(uint(problemID), languages); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("更新成功")
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/model/repository"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ProblemMenuController struct {
+	problemMenuService services.ProblemMenuService
+}
+
+func NewProblemMenuController(problemMenuService services.ProblemMenuService) *ProblemMenuController {
+	return &ProblemMenuController{
+		problemMenuService: problemMenuService,
+	}
+}
+
+func (ctl *ProblemMenuController) GetProblemMenuSections(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	menuID := utils.GetIntQueryOrDefault(ctx, "menuID", 0)
+	sections, err := ctl.problemMenuService.GetProblemMenuSections(uint(menuID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(sections)
+}
+
+func (ctl *ProblemMenuController) InsertProblemMenuSection(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	section := &repository.ProblemMenuSection{}
+	if err := ctx.BindJSON(section); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	id, err := ctl.problemMenuService.InsertProblemMenuSection(section)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("添加成功", id)
+}
+
+func (ctl *ProblemMenuController) UpdateProblemMenuSection(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	section := &repository.ProblemMenuSection{}
+	if err := ctx.BindJSON(section); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	if err := ctl.problemMenuService.UpdateProblemMenuSection(section); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("更新成功")
+}
+
+func (ctl *ProblemMenuController) DeleteProblemMenuSection(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntQueryOrDefault(ctx, "id", 0)
+	if err := ctl.problemMenuService.DeleteProblemMenuSection(uint(id)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("删除成功")
+}
+
+// ReorderProblemMenu 调整题单顺序，请求体为按顺序排列的分组，每个分组包含按顺序排列的题目id
+func (ctl *ProblemMenuController) ReorderProblemMenu(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	menuID := utils.GetIntQueryOrDefault(ctx, "menuID", 0)
+	var orders []*request.ProblemMenuSectionOrder
+	if err := ctx.BindJSON(&orders); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	if err := ctl.problemMenuService.ReorderProblemMenu(uint(menuID), orders); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("排序成功")
+}
+
+func (ctl *ProblemMenuController) GetUserProblemMenuProgress(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	menuID := utils.GetIntQueryOrDefault(ctx, "menuID", 0)
+	progress, err := ctl.problemMenuService.GetUserProblemMenuProgress(ctx, uint(menuID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(progress)
+}
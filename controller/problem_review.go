@@ -0,0 +1,63 @@
+package controller
+
+import (
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+type ProblemReviewController struct {
+	problemReviewService services.ProblemReviewService
+}
+
+func NewProblemReviewController(reviewService services.ProblemReviewService) *ProblemReviewController {
+	return &ProblemReviewController{
+		problemReviewService: reviewService,
+	}
+}
+
+func (ctl *ProblemReviewController) GetProblemChecklist(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.GetIntParamOrDefault(ctx, "problemID", 0)
+	checklist, err := ctl.problemReviewService.GetProblemChecklist(uint(problemID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(checklist)
+}
+
+func (ctl *ProblemReviewController) TransitProblemStatus(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)
+	status := utils.AtoiOrDefault(ctx.PostForm("status"), 0)
+	var publishAt *time.Time
+	if publishAtStr := ctx.PostForm("publishAt"); publishAtStr != "" {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", publishAtStr, time.Local)
+		if err != nil {
+			result.Error(e.ErrProblemPublishTimeInvalid)
+			return
+		}
+		publishAt = &t
+	}
+	err := ctl.problemReviewService.TransitProblemStatus(ctx, uint(problemID), status, ctx.PostForm("comment"), publishAt)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("操作成功")
+}
+
+func (ctl *ProblemReviewController) GetProblemReviewList(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.GetIntParamOrDefault(ctx, "problemID", 0)
+	reviews, err := ctl.problemReviewService.GetProblemReviewList(uint(problemID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(reviews)
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ProblemSolutionController struct {
+	problemSolutionService services.ProblemSolutionService
+}
+
+func NewProblemSolutionController(solutionService services.ProblemSolutionService) *ProblemSolutionController {
+	return &ProblemSolutionController{
+		problemSolutionService: solutionService,
+	}
+}
+
+func (ctl *ProblemSolutionController) InsertProblemSolution(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	solution := &request.ProblemSolution{}
+	if err := ctx.BindJSON(solution); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	id, err := ctl.problemSolutionService.InsertProblemSolution(ctx, solution)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("添加成功", id)
+}
+
+func (ctl *ProblemSolutionController) UpdateProblemSolution(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	solution := &request.ProblemSolution{}
+	if err := ctx.BindJSON(solution); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	if err := ctl.problemSolutionService.UpdateProblemSolution(solution); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("更新成功")
+}
+
+func (ctl *ProblemSolutionController) DeleteProblemSolution(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	if err := ctl.problemSolutionService.DeleteProblemSolution(uint(id)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("删除成功")
+}
+
+func (ctl *ProblemSolutionController) GetProblemSolutionByID(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	solution, err := ctl.problemSolutionService.GetProblemSolutionByID(uint(id))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(solution)
+}
+
+func (ctl *ProblemSolutionController) GetProblemSolutionList(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	pageQuery.Query = &request.ProblemSolutionForList{
+		ProblemID: uint(utils.GetIntQueryOrDefault(ctx, "problemID", 0)),
+		Title:     ctx.Query("title"),
+	}
+	pageInfo, err := ctl.problemSolutionService.GetProblemSolutionList(pageQuery)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
+
+func (ctl *ProblemSolutionController) GetUserProblemSolutions(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.GetIntParamOrDefault(ctx, "problemID", 0)
+	solutions, err := ctl.problemSolutionService.GetUserProblemSolutions(ctx, uint(problemID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(solutions)
+}
+
+func (ctl *ProblemSolutionController) GetUserProblemSolution(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	solution, err := ctl.problemSolutionService.GetUserProblemSolution(ctx, uint(id))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(solution)
+}
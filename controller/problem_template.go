@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type ProblemTemplateController struct {
+	problemTemplateService services.ProblemTemplateService
+}
+
+func NewProblemTemplateController(templateService services.ProblemTemplateService) *ProblemTemplateController {
+	return &ProblemTemplateController{
+		problemTemplateService: templateService,
+	}
+}
+
+// SaveProblemTemplate 保存题目模板，problemID为0时保存全局默认模板
+func (ctl *ProblemTemplateController) SaveProblemTemplate(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)
+	version, err := ctl.problemTemplateService.SaveProblemTemplate(ctx, uint(problemID),
+		ctx.PostForm("language"), ctx.PostForm("code"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("保存成功", version)
+}
+
+func (ctl *ProblemTemplateController) RestoreProblemTemplate(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)
+	version := utils.AtoiOrDefault(ctx.PostForm("version"), 0)
+	newVersion, err := ctl.problemTemplateService.RestoreProblemTemplate(ctx, uint(problemID),
+		ctx.PostForm("language"), version)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("恢复成功", newVersion)
+}
+
+func (ctl *ProblemTemplateController) DeleteProblemTemplate(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.GetIntQueryOrDefault(ctx, "problemID", 0)
+	if err := ctl.problemTemplateService.DeleteProblemTemplate(uint(problemID), ctx.Query("language")); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("删除成功")
+}
+
+func (ctl *ProblemTemplateController) GetProblemTemplateHistory(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.GetIntQueryOrDefault(ctx, "problemID", 0)
+	templates, err := ctl.problemTemplateService.GetProblemTemplateHistory(uint(problemID), ctx.Query("language"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(templates)
+}
+
+func (ctl *ProblemTemplateController) GetDefaultTemplates(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	templates, err := ctl.problemTemplateService.GetDefaultTemplates()
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(templates)
+}
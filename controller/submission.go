@@ -0,0 +1,164 @@
+package controller
+
+import (
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type SubmissionController struct {
+	submissionService services.SubmissionService
+}
+
+func NewSubmissionController(submissionService services.SubmissionService) *SubmissionController {
+	return &SubmissionController{
+		submissionService: submissionService,
+	}
+}
+
+func (ctl *SubmissionController) GetSubmission(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	submission, err := ctl.submissionService.GetSubmission(ctx, uint(id))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(submission)
+}
+
+// DiffSubmissions 对比两次提交，oldID和newID为提交id
+func (ctl *SubmissionController) DiffSubmissions(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	oldID := utils.GetIntQueryOrDefault(ctx, "oldID", 0)
+	newID := utils.GetIntQueryOrDefault(ctx, "newID", 0)
+	diff, err := ctl.submissionService.DiffSubmissions(ctx, uint(oldID), uint(newID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(diff)
+}
+
+func (ctl *SubmissionController) ShareSubmission(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	shareCode, err := ctl.submissionService.ShareSubmission(ctx, uint(id))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("分享成功", shareCode)
+}
+
+func (ctl *SubmissionController) CancelShareSubmission(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	if err := ctl.submissionService.CancelShareSubmission(ctx, uint(id)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("已取消分享")
+}
+
+// GetSharedSubmission 通过分享链接查看提交，不需要登录
+func (ctl *SubmissionController) GetSharedSubmission(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	submission, err := ctl.submissionService.GetSharedSubmission(ctx.Param("shareCode"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(submission)
+}
+
+// GetSubmissionListForAdmin 管理员查询所有提交
+func (ctl *SubmissionController) GetSubmissionListForAdmin(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	if pageQuery.Query, err = getSubmissionQuery(ctx); err != nil {
+		result.Error(err)
+		return
+	}
+	pageInfo, err := ctl.submissionService.GetSubmissionListForAdmin(pageQuery)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
+
+// ExportSubmissions 按查询条件导出提交，format为csv或json
+func (ctl *SubmissionController) ExportSubmissions(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	format := ctx.DefaultQuery("format", consts.ExportFormatCsv)
+	if !consts.IsExportFormatSupported(format) {
+		result.Error(e.ErrExportFormatInvalid)
+		return
+	}
+	query, err := getSubmissionQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	setExportHeader(ctx, "submissions", format)
+	err = ctl.submissionService.ExportSubmissions(query, ctx.Query("sortProperty"), ctx.Query("sortRule"), format, ctx.Writer)
+	if err != nil {
+		handleExportError(ctx, result, err)
+	}
+}
+
+// getSubmissionQuery 读取提交的查询条件，时间格式为2006-01-02 15:04:05
+func getSubmissionQuery(ctx *gin.Context) (*request.SubmissionForList, *e.Error) {
+	query := &request.SubmissionForList{
+		UserID:        uint(utils.GetIntQueryOrDefault(ctx, "userID", 0)),
+		ProblemID:     uint(utils.GetIntQueryOrDefault(ctx, "problemID", 0)),
+		Status:        utils.GetIntQueryOrDefault(ctx, "status", 0),
+		Language:      ctx.Query("language"),
+		UserName:      ctx.Query("userName"),
+		ProblemNumber: ctx.Query("problemNumber"),
+		MenuID:        uint(utils.GetIntQueryOrDefault(ctx, "menuID", 0)),
+	}
+	var err error
+	if startTime := ctx.Query("startTime"); startTime != "" {
+		if query.StartTime, err = utils.ParseTime(startTime); err != nil {
+			return nil, e.ErrBadRequest
+		}
+	}
+	if endTime := ctx.Query("endTime"); endTime != "" {
+		if query.EndTime, err = utils.ParseTime(endTime); err != nil {
+			return nil, e.ErrBadRequest
+		}
+	}
+	return query, nil
+}
+
+// GetActivityMap 获取活动图，year为0时获取截至今天的一年，timezone为IANA时区名称，如Asia/Shanghai
+func (ctl *SubmissionController) GetActivityMap(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	year := utils.GetIntQueryOrDefault(ctx, "year", 0)
+	activity, err := ctl.submissionService.GetActivityMap(ctx, year, ctx.Query("timezone"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(activity)
+}
+
+func (ctl *SubmissionController) GetActivityYear(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	years, err := ctl.submissionService.GetActivityYear(ctx, ctx.Query("timezone"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(years)
+}
@@ -0,0 +1,35 @@
+package controller
+
+import (
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"github.com/gin-gonic/gin"
+)
+
+type TraceController struct {
+	traceService services.TraceService
+}
+
+func NewTraceController(traceService services.TraceService) *TraceController {
+	return &TraceController{
+		traceService: traceService,
+	}
+}
+
+// RunExecutionTrace 可视化执行，返回逐行的执行轨迹
+func (ctl *TraceController) RunExecutionTrace(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	traceRun := &request.TraceRun{}
+	if err := ctx.BindJSON(traceRun); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	trace, err := ctl.traceService.RunExecutionTrace(ctx, traceRun)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(trace)
+}
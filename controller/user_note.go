@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type UserNoteController struct {
+	userNoteService services.UserNoteService
+}
+
+func NewUserNoteController(userNoteService services.UserNoteService) *UserNoteController {
+	return &UserNoteController{
+		userNoteService: userNoteService,
+	}
+}
+
+// SaveProblemNote 保存对题目的笔记
+func (ctl *UserNoteController) SaveProblemNote(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)
+	note, err := ctl.userNoteService.SaveProblemNote(ctx, uint(problemID), ctx.PostForm("content"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("保存成功", note)
+}
+
+// SaveSubmissionNote 保存对提交的笔记
+func (ctl *UserNoteController) SaveSubmissionNote(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	submissionID := utils.AtoiOrDefault(ctx.PostForm("submissionID"), 0)
+	note, err := ctl.userNoteService.SaveSubmissionNote(ctx, uint(submissionID), ctx.PostForm("content"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("保存成功", note)
+}
+
+func (ctl *UserNoteController) DeleteUserNote(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	if err := ctl.userNoteService.DeleteUserNote(ctx, uint(id)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("删除成功")
+}
+
+// SearchUserNotes 搜索笔记，problemID和keyword可选
+func (ctl *UserNoteController) SearchUserNotes(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	pageQuery.Query = &request.UserNoteForList{
+		ProblemID: uint(utils.GetIntQueryOrDefault(ctx, "problemID", 0)),
+		Keyword:   ctx.Query("keyword"),
+	}
+	pageInfo, err := ctl.userNoteService.SearchUserNotes(ctx, pageQuery)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
+
+// ExportUserNotes 导出所有笔记，format为csv或json
+func (ctl *UserNoteController) ExportUserNotes(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	format := ctx.DefaultQuery("format", consts.ExportFormatCsv)
+	if !consts.IsExportFormatSupported(format) {
+		result.Error(e.ErrExportFormatInvalid)
+		return
+	}
+	setExportHeader(ctx, "notes", format)
+	if err := ctl.userNoteService.ExportUserNotes(ctx, format, ctx.Writer); err != nil {
+		handleExportError(ctx, result, err)
+	}
+}
@@ -0,0 +1,203 @@
+package controller
+
+import (
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/services"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+type UserProblemMenuController struct {
+	userProblemMenuService services.UserProblemMenuService
+}
+
+func NewUserProblemMenuController(userProblemMenuService services.UserProblemMenuService) *UserProblemMenuController {
+	return &UserProblemMenuController{
+		userProblemMenuService: userProblemMenuService,
+	}
+}
+
+func (ctl *UserProblemMenuController) InsertUserProblemMenu(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	menu := &request.UserProblemMenu{}
+	if err := ctx.BindJSON(menu); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	id, err := ctl.userProblemMenuService.InsertUserProblemMenu(ctx, menu)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("添加成功", id)
+}
+
+func (ctl *UserProblemMenuController) UpdateUserProblemMenu(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	menu := &request.UserProblemMenu{}
+	if err := ctx.BindJSON(menu); err != nil {
+		result.Error(e.ErrBadRequest)
+		return
+	}
+	if err := ctl.userProblemMenuService.UpdateUserProblemMenu(ctx, menu); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("更新成功")
+}
+
+func (ctl *UserProblemMenuController) DeleteUserProblemMenu(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	id := utils.GetIntParamOrDefault(ctx, "id", 0)
+	if err := ctl.userProblemMenuService.DeleteUserProblemMenu(ctx, uint(id)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("删除成功")
+}
+
+func (ctl *UserProblemMenuController) AddUserProblemMenuProblem(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	menuID := utils.AtoiOrDefault(ctx.PostForm("menuID"), 0)
+	problemID := utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)
+	if err := ctl.userProblemMenuService.AddUserProblemMenuProblem(ctx, uint(menuID), uint(problemID)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("添加成功")
+}
+
+func (ctl *UserProblemMenuController) RemoveUserProblemMenuProblem(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	menuID := utils.GetIntQueryOrDefault(ctx, "menuID", 0)
+	problemID := utils.GetIntQueryOrDefault(ctx, "problemID", 0)
+	if err := ctl.userProblemMenuService.RemoveUserProblemMenuProblem(ctx, uint(menuID), uint(problemID)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("移除成功")
+}
+
+func (ctl *UserProblemMenuController) GetUserProblemMenuList(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	pageQuery.Query = &request.ProblemMenuForList{
+		Name: ctx.Query("name"),
+	}
+	pageInfo, err := ctl.userProblemMenuService.GetUserProblemMenuList(ctx, pageQuery)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
+
+func (ctl *UserProblemMenuController) GetPublicProblemMenuList(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	pageQuery.Query = &request.ProblemMenuForList{
+		Name: ctx.Query("name"),
+	}
+	pageInfo, err := ctl.userProblemMenuService.GetPublicProblemMenuList(pageQuery)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
+
+func (ctl *UserProblemMenuController) GetSharedProblemMenu(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	detail, err := ctl.userProblemMenuService.GetSharedProblemMenu(ctx, ctx.Param("shareCode"))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(detail)
+}
+
+func (ctl *UserProblemMenuController) CloneProblemMenu(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	menuID := utils.AtoiOrDefault(ctx.PostForm("menuID"), 0)
+	id, err := ctl.userProblemMenuService.CloneProblemMenu(ctx, uint(menuID))
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.Success("复制成功", id)
+}
+
+func (ctl *UserProblemMenuController) FollowProblemMenu(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	menuID := utils.AtoiOrDefault(ctx.PostForm("menuID"), 0)
+	if err := ctl.userProblemMenuService.FollowProblemMenu(ctx, uint(menuID), ctx.PostForm("shareCode")); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("关注成功")
+}
+
+func (ctl *UserProblemMenuController) UnfollowProblemMenu(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	menuID := utils.GetIntQueryOrDefault(ctx, "menuID", 0)
+	if err := ctl.userProblemMenuService.UnfollowProblemMenu(ctx, uint(menuID)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("取消关注成功")
+}
+
+func (ctl *UserProblemMenuController) GetFollowedProblemMenus(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	menus, err := ctl.userProblemMenuService.GetFollowedProblemMenus(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(menus)
+}
+
+func (ctl *UserProblemMenuController) BookmarkProblem(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.AtoiOrDefault(ctx.PostForm("problemID"), 0)
+	if err := ctl.userProblemMenuService.BookmarkProblem(ctx, uint(problemID)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("收藏成功")
+}
+
+func (ctl *UserProblemMenuController) UnbookmarkProblem(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	problemID := utils.GetIntQueryOrDefault(ctx, "problemID", 0)
+	if err := ctl.userProblemMenuService.UnbookmarkProblem(ctx, uint(problemID)); err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessMessage("取消收藏成功")
+}
+
+func (ctl *UserProblemMenuController) GetBookmarkedProblems(ctx *gin.Context) {
+	result := response.NewResult(ctx)
+	pageQuery, err := utils.GetPageQueryByQuery(ctx)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	pageInfo, err := ctl.userProblemMenuService.GetBookmarkedProblems(ctx, pageQuery)
+	if err != nil {
+		result.Error(err)
+		return
+	}
+	result.SuccessData(pageInfo)
+}
@@ -0,0 +1,75 @@
+package dao
+
+import (
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CodeDraftDao interface {
+	// GetCodeDraft 获取用户在题目某个语言中的草稿
+	GetCodeDraft(db *gorm.DB, userID uint, problemID uint, language string) (*repository.CodeDraft, error)
+	// SaveCodeDraft 保存草稿，已存在时更新代码
+	SaveCodeDraft(db *gorm.DB, draft *repository.CodeDraft) error
+	// GetLatestCodeDraftSnapshot 获取草稿最新的历史版本
+	GetLatestCodeDraftSnapshot(db *gorm.DB, userID uint, problemID uint, language string) (*repository.CodeDraftSnapshot, error)
+	// GetCodeDraftSnapshot 获取草稿指定的历史版本
+	GetCodeDraftSnapshot(db *gorm.DB, userID uint, problemID uint, language string, version int) (*repository.CodeDraftSnapshot, error)
+	// GetCodeDraftSnapshots 获取草稿所有的历史版本，新版本在前
+	GetCodeDraftSnapshots(db *gorm.DB, userID uint, problemID uint, language string) ([]*repository.CodeDraftSnapshot, error)
+	// InsertCodeDraftSnapshot 添加一个历史版本
+	InsertCodeDraftSnapshot(db *gorm.DB, snapshot *repository.CodeDraftSnapshot) error
+	// DeleteCodeDraftSnapshotsBefore 删除版本号小于version的历史版本
+	DeleteCodeDraftSnapshotsBefore(db *gorm.DB, userID uint, problemID uint, language string, version int) error
+}
+
+type CodeDraftDaoImpl struct {
+}
+
+func NewCodeDraftDao() CodeDraftDao {
+	return &CodeDraftDaoImpl{}
+}
+
+func (dao *CodeDraftDaoImpl) GetCodeDraft(db *gorm.DB, userID uint, problemID uint, language string) (*repository.CodeDraft, error) {
+	draft := &repository.CodeDraft{}
+	err := db.Where("user_id = ? and problem_id = ? and language = ?", userID, problemID, language).
+		First(draft).Error
+	return draft, err
+}
+
+func (dao *CodeDraftDaoImpl) SaveCodeDraft(db *gorm.DB, draft *repository.CodeDraft) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "problem_id"}, {Name: "language"}},
+		DoUpdates: clause.AssignmentColumns([]string{"code", "updated_at", "deleted_at"}),
+	}).Create(draft).Error
+}
+
+func (dao *CodeDraftDaoImpl) GetLatestCodeDraftSnapshot(db *gorm.DB, userID uint, problemID uint, language string) (*repository.CodeDraftSnapshot, error) {
+	snapshot := &repository.CodeDraftSnapshot{}
+	err := db.Where("user_id = ? and problem_id = ? and language = ?", userID, problemID, language).
+		Order("version desc").First(snapshot).Error
+	return snapshot, err
+}
+
+func (dao *CodeDraftDaoImpl) GetCodeDraftSnapshot(db *gorm.DB, userID uint, problemID uint, language string, version int) (*repository.CodeDraftSnapshot, error) {
+	snapshot := &repository.CodeDraftSnapshot{}
+	err := db.Where("user_id = ? and problem_id = ? and language = ? and version = ?", userID, problemID, language, version).
+		First(snapshot).Error
+	return snapshot, err
+}
+
+func (dao *CodeDraftDaoImpl) GetCodeDraftSnapshots(db *gorm.DB, userID uint, problemID uint, language string) ([]*repository.CodeDraftSnapshot, error) {
+	var snapshots []*repository.CodeDraftSnapshot
+	err := db.Where("user_id = ? and problem_id = ? and language = ?", userID, problemID, language).
+		Order("version desc").Find(&snapshots).Error
+	return snapshots, err
+}
+
+func (dao *CodeDraftDaoImpl) InsertCodeDraftSnapshot(db *gorm.DB, snapshot *repository.CodeDraftSnapshot) error {
+	return db.Create(snapshot).Error
+}
+
+func (dao *CodeDraftDaoImpl) DeleteCodeDraftSnapshotsBefore(db *gorm.DB, userID uint, problemID uint, language string, version int) error {
+	return db.Unscoped().Where("user_id = ? and problem_id = ? and language = ? and version < ?", userID, problemID, language, version).
+		Delete(&repository.CodeDraftSnapshot{}).Error
+}
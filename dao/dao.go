@@ -1,14 +1,39 @@
 package dao
 
-import "github.com/google/wire"
+import (
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/google/wire"
+)
+
+// mysqlErrDuplicateEntry 唯一索引冲突的错误码
+const mysqlErrDuplicateEntry = 1062
 
 var ProviderSet = wire.NewSet(
+	NewCodeDraftDao,
+	NewDiscussionDao,
+	NewDiscussionCommentDao,
+	NewMistakeNoteDao,
 	NewProblemAttemptDao,
+	NewProblemBookmarkDao,
 	NewProblemMenuDao,
 	NewProblemDao,
 	NewProblemCaseDao,
+	NewProblemHintDao,
+	NewProblemLanguageDao,
+	NewProblemStatisticDao,
+	NewProblemReviewDao,
+	NewProblemSolutionDao,
+	NewProblemTemplateDao,
 	NewSubmissionDao,
 	NewSysPermissionDao,
 	NewSysRoleDao,
 	NewSysUserDao,
+	NewUserNoteDao,
 )
+
+// IsDuplicateKeyError 检测错误是否为唯一索引冲突
+func IsDuplicateKeyError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlErrDuplicateEntry
+}
@@ -0,0 +1,131 @@
+package dao
+
+import (
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+)
+
+type DiscussionDao interface {
+	// InsertDiscussion 添加讨论帖
+	InsertDiscussion(db *gorm.DB, discussion *repository.Discussion) error
+	// UpdateDiscussion 更新讨论帖的标题、内容和剧透标记
+	UpdateDiscussion(db *gorm.DB, discussion *repository.Discussion) error
+	// DeleteDiscussionByID 软删除讨论帖
+	DeleteDiscussionByID(db *gorm.DB, id uint) error
+	// GetDiscussionByID 通过id获取讨论帖
+	GetDiscussionByID(db *gorm.DB, id uint) (*repository.Discussion, error)
+	// GetDiscussionList 获取讨论帖列表，置顶的帖子在前
+	GetDiscussionList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.Discussion, error)
+	// GetDiscussionCount 获取讨论帖数量
+	GetDiscussionCount(db *gorm.DB, discussion *request.DiscussionForList) (int64, error)
+	// SetDiscussionPinned 设置讨论帖是否置顶
+	SetDiscussionPinned(db *gorm.DB, id uint, pinned bool) error
+	// IncreaseDiscussionUpvoteCount 增加讨论帖的点赞数，delta可以为负数
+	IncreaseDiscussionUpvoteCount(db *gorm.DB, id uint, delta int) error
+	// IncreaseDiscussionCommentCount 增加讨论帖的回复数，delta可以为负数
+	IncreaseDiscussionCommentCount(db *gorm.DB, id uint, delta int) error
+	// InsertUpvote 添加点赞记录
+	InsertUpvote(db *gorm.DB, upvote *repository.DiscussionUpvote) error
+	// DeleteUpvote 删除点赞记录，返回删除的行数
+	DeleteUpvote(db *gorm.DB, targetType string, targetID uint, userID uint) (int64, error)
+}
+
+type DiscussionDaoImpl struct {
+}
+
+func NewDiscussionDao() DiscussionDao {
+	return &DiscussionDaoImpl{}
+}
+
+func (dao *DiscussionDaoImpl) InsertDiscussion(db *gorm.DB, discussion *repository.Discussion) error {
+	return db.Create(discussion).Error
+}
+
+func (dao *DiscussionDaoImpl) UpdateDiscussion(db *gorm.DB, discussion *repository.Discussion) error {
+	return db.Model(discussion).Where("id = ?", discussion.ID).Updates(map[string]interface{}{
+		"updated_at": discussion.UpdatedAt,
+		"title":      discussion.Title,
+		"content":    discussion.Content,
+		"spoiler":    discussion.Spoiler,
+	}).Error
+}
+
+func (dao *DiscussionDaoImpl) DeleteDiscussionByID(db *gorm.DB, id uint) error {
+	return db.Delete(&repository.Discussion{}, id).Error
+}
+
+func (dao *DiscussionDaoImpl) GetDiscussionByID(db *gorm.DB, id uint) (*repository.Discussion, error) {
+	discussion := &repository.Discussion{}
+	err := db.First(discussion, id).Error
+	return discussion, err
+}
+
+func (dao *DiscussionDaoImpl) GetDiscussionList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.Discussion, error) {
+	var discussion *request.DiscussionForList
+	if pageQuery.Query != nil {
+		discussion = pageQuery.Query.(*request.DiscussionForList)
+	}
+	db = dao.buildDiscussionQuery(db, discussion)
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	var discussions []*repository.Discussion
+	db = db.Offset(offset).Limit(pageQuery.PageSize).Order("pinned desc")
+	if pageQuery.SortProperty != "" && pageQuery.SortRule != "" {
+		order := pageQuery.SortProperty + " " + pageQuery.SortRule
+		db = db.Order(order)
+	} else {
+		db = db.Order("created_at desc")
+	}
+	err := db.Find(&discussions).Error
+	return discussions, err
+}
+
+func (dao *DiscussionDaoImpl) GetDiscussionCount(db *gorm.DB, discussion *request.DiscussionForList) (int64, error) {
+	var count int64
+	db = dao.buildDiscussionQuery(db, discussion)
+	err := db.Model(&repository.Discussion{}).Count(&count).Error
+	return count, err
+}
+
+func (dao *DiscussionDaoImpl) buildDiscussionQuery(db *gorm.DB, discussion *request.DiscussionForList) *gorm.DB {
+	if discussion == nil {
+		return db
+	}
+	if discussion.ProblemID != 0 {
+		db = db.Where("problem_id = ?", discussion.ProblemID)
+	}
+	if discussion.UserID != 0 {
+		db = db.Where("user_id = ?", discussion.UserID)
+	}
+	if discussion.Title != "" {
+		db = db.Where("title like ?", "%"+discussion.Title+"%")
+	}
+	if !discussion.WithSpoiler {
+		db = db.Where("spoiler = ?", false)
+	}
+	return db
+}
+
+func (dao *DiscussionDaoImpl) SetDiscussionPinned(db *gorm.DB, id uint, pinned bool) error {
+	return db.Model(&repository.Discussion{}).Where("id = ?", id).Update("pinned", pinned).Error
+}
+
+func (dao *DiscussionDaoImpl) IncreaseDiscussionUpvoteCount(db *gorm.DB, id uint, delta int) error {
+	return db.Model(&repository.Discussion{}).Where("id = ?", id).
+		UpdateColumn("upvote_count", gorm.Expr("upvote_count + ?", delta)).Error
+}
+
+func (dao *DiscussionDaoImpl) IncreaseDiscussionCommentCount(db *gorm.DB, id uint, delta int) error {
+	return db.Model(&repository.Discussion{}).Where("id = ?", id).
+		UpdateColumn("comment_count", gorm.Expr("comment_count + ?", delta)).Error
+}
+
+func (dao *DiscussionDaoImpl) InsertUpvote(db *gorm.DB, upvote *repository.DiscussionUpvote) error {
+	return db.Create(upvote).Error
+}
+
+func (dao *DiscussionDaoImpl) DeleteUpvote(db *gorm.DB, targetType string, targetID uint, userID uint) (int64, error) {
+	result := db.Unscoped().Where("target_type = ? and target_id = ? and user_id = ?", targetType, targetID, userID).
+		Delete(&repository.DiscussionUpvote{})
+	return result.RowsAffected, result.Error
+}
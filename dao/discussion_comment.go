@@ -0,0 +1,89 @@
+package dao
+
+import (
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+)
+
+type DiscussionCommentDao interface {
+	// InsertDiscussionComment 添加回复
+	InsertDiscussionComment(db *gorm.DB, comment *repository.DiscussionComment) error
+	// DeleteDiscussionCommentByID 软删除回复
+	DeleteDiscussionCommentByID(db *gorm.DB, id uint) error
+	// GetDiscussionCommentByID 通过id获取回复
+	GetDiscussionCommentByID(db *gorm.DB, id uint) (*repository.DiscussionComment, error)
+	// GetRootDiscussionCommentList 分页获取帖子的一级回复，包含已删除的回复
+	GetRootDiscussionCommentList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.DiscussionComment, error)
+	// GetRootDiscussionCommentCount 获取帖子的一级回复数量
+	GetRootDiscussionCommentCount(db *gorm.DB, comment *request.DiscussionCommentForList) (int64, error)
+	// GetDiscussionCommentsByRootIDs 获取一级回复下的所有回复，包含已删除的回复
+	GetDiscussionCommentsByRootIDs(db *gorm.DB, rootIDs []uint) ([]*repository.DiscussionComment, error)
+	// IncreaseDiscussionCommentUpvoteCount 增加回复的点赞数，delta可以为负数
+	IncreaseDiscussionCommentUpvoteCount(db *gorm.DB, id uint, delta int) error
+}
+
+type DiscussionCommentDaoImpl struct {
+}
+
+func NewDiscussionCommentDao() DiscussionCommentDao {
+	return &DiscussionCommentDaoImpl{}
+}
+
+func (dao *DiscussionCommentDaoImpl) InsertDiscussionComment(db *gorm.DB, comment *repository.DiscussionComment) error {
+	return db.Create(comment).Error
+}
+
+func (dao *DiscussionCommentDaoImpl) DeleteDiscussionCommentByID(db *gorm.DB, id uint) error {
+	return db.Delete(&repository.DiscussionComment{}, id).Error
+}
+
+func (dao *DiscussionCommentDaoImpl) GetDiscussionCommentByID(db *gorm.DB, id uint) (*repository.DiscussionComment, error) {
+	comment := &repository.DiscussionComment{}
+	err := db.First(comment, id).Error
+	return comment, err
+}
+
+func (dao *DiscussionCommentDaoImpl) GetRootDiscussionCommentList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.DiscussionComment, error) {
+	var comment *request.DiscussionCommentForList
+	if pageQuery.Query != nil {
+		comment = pageQuery.Query.(*request.DiscussionCommentForList)
+	}
+	if comment != nil && comment.DiscussionID != 0 {
+		db = db.Where("discussion_id = ?", comment.DiscussionID)
+	}
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	var comments []*repository.DiscussionComment
+	db = db.Unscoped().Where("root_id = 0").Offset(offset).Limit(pageQuery.PageSize)
+	if pageQuery.SortProperty != "" && pageQuery.SortRule != "" {
+		order := pageQuery.SortProperty + " " + pageQuery.SortRule
+		db = db.Order(order)
+	} else {
+		db = db.Order("created_at")
+	}
+	err := db.Find(&comments).Error
+	return comments, err
+}
+
+func (dao *DiscussionCommentDaoImpl) GetRootDiscussionCommentCount(db *gorm.DB, comment *request.DiscussionCommentForList) (int64, error) {
+	var count int64
+	if comment != nil && comment.DiscussionID != 0 {
+		db = db.Where("discussion_id = ?", comment.DiscussionID)
+	}
+	err := db.Unscoped().Model(&repository.DiscussionComment{}).Where("root_id = 0").Count(&count).Error
+	return count, err
+}
+
+func (dao *DiscussionCommentDaoImpl) GetDiscussionCommentsByRootIDs(db *gorm.DB, rootIDs []uint) ([]*repository.DiscussionComment, error) {
+	var comments []*repository.DiscussionComment
+	if len(rootIDs) == 0 {
+		return comments, nil
+	}
+	err := db.Unscoped().Where("root_id in ?", rootIDs).Order("created_at").Find(&comments).Error
+	return comments, err
+}
+
+func (dao *DiscussionCommentDaoImpl) IncreaseDiscussionCommentUpvoteCount(db *gorm.DB, id uint, delta int) error {
+	return db.Model(&repository.DiscussionComment{}).Where("id = ?", id).
+		UpdateColumn("upvote_count", gorm.Expr("upvote_count + ?", delta)).Error
+}
@@ -0,0 +1,127 @@
+package dao
+
+import (
+	"funoj-backend/consts"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type MistakeNoteDao interface {
+	// CollectMistakeNotes 将错误次数不少于minErrCount的做题情况加入错题本，已存在或被用户移除过的不会重复加入，userID为0时收集所有用户
+	CollectMistakeNotes(db *gorm.DB, userID uint, minErrCount int, dueAt time.Time) (int64, error)
+	// GetMistakeNote 获取错题本中的一道题目
+	GetMistakeNote(db *gorm.DB, userID uint, problemID uint) (*repository.MistakeNote, error)
+	// GetMistakeNoteList 获取错题本，按下次复习时间排序
+	GetMistakeNoteList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.MistakeNote, error)
+	// GetMistakeNoteCount 获取错题本中的题目数
+	GetMistakeNoteCount(db *gorm.DB, note *request.MistakeNoteForList) (int64, error)
+	// SaveMistakeNote 手动加入错题本，被移除过时重新加入
+	SaveMistakeNote(db *gorm.DB, note *repository.MistakeNote) error
+	// UpdateMistakeNote 更新笔记和复习进度
+	UpdateMistakeNote(db *gorm.DB, note *repository.MistakeNote) error
+	// DeleteMistakeNote 从错题本中移除
+	DeleteMistakeNote(db *gorm.DB, userID uint, problemID uint) error
+	// GetMistakeNoteSetting 获取用户的错题本设置，不存在时返回默认设置
+	GetMistakeNoteSetting(db *gorm.DB, userID uint) (*repository.MistakeNoteSetting, error)
+	// SaveMistakeNoteSetting 保存用户的错题本设置
+	SaveMistakeNoteSetting(db *gorm.DB, setting *repository.MistakeNoteSetting) error
+	// GetMistakeNoteDigests 获取开启了邮件提醒且在before之前有题目需要复习的用户
+	GetMistakeNoteDigests(db *gorm.DB, before time.Time) ([]*repository.MistakeNoteDigest, error)
+}
+
+type MistakeNoteDaoImpl struct {
+}
+
+func NewMistakeNoteDao() MistakeNoteDao {
+	return &MistakeNoteDaoImpl{}
+}
+
+func (dao *MistakeNoteDaoImpl) CollectMistakeNotes(db *gorm.DB, userID uint, minErrCount int, dueAt time.Time) (int64, error) {
+	attempts := db.Model(&repository.ProblemAttempt{}).
+		Select("now(), now(), user_id, problem_id, '', ?, 0, 0, ?", consts.MistakeDefaultEase, dueAt).
+		Where("err_count >= ?", minErrCount)
+	if userID != 0 {
+		attempts = attempts.Where("user_id = ?", userID)
+	}
+	result := db.Exec("insert into mistake_note (created_at, updated_at, user_id, problem_id, note, ease_factor, "+
+		"review_interval, repetitions, due_at) ? on duplicate key update id = id", attempts)
+	return result.RowsAffected, result.Error
+}
+
+func (dao *MistakeNoteDaoImpl) GetMistakeNote(db *gorm.DB, userID uint, problemID uint) (*repository.MistakeNote, error) {
+	note := &repository.MistakeNote{}
+	err := db.Where("user_id = ? and problem_id = ?", userID, problemID).First(note).Error
+	return note, err
+}
+
+func (dao *MistakeNoteDaoImpl) GetMistakeNoteList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.MistakeNote, error) {
+	var note *request.MistakeNoteForList
+	if pageQuery.Query != nil {
+		note = pageQuery.Query.(*request.MistakeNoteForList)
+	}
+	var notes []*repository.MistakeNote
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	err := dao.applyMistakeNoteFilter(db, note).Order("due_at, id").
+		Offset(offset).Limit(pageQuery.PageSize).Find(&notes).Error
+	return notes, err
+}
+
+func (dao *MistakeNoteDaoImpl) GetMistakeNoteCount(db *gorm.DB, note *request.MistakeNoteForList) (int64, error) {
+	var count int64
+	err := dao.applyMistakeNoteFilter(db, note).Model(&repository.MistakeNote{}).Count(&count).Error
+	return count, err
+}
+
+func (dao *MistakeNoteDaoImpl) applyMistakeNoteFilter(db *gorm.DB, note *request.MistakeNoteForList) *gorm.DB {
+	if note != nil && note.UserID != 0 {
+		db = db.Where("user_id = ?", note.UserID)
+	}
+	if note != nil && !note.DueBefore.IsZero() {
+		db = db.Where("due_at < ?", note.DueBefore)
+	}
+	return db
+}
+
+func (dao *MistakeNoteDaoImpl) SaveMistakeNote(db *gorm.DB, note *repository.MistakeNote) error {
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "problem_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "deleted_at", "ease_factor", "review_interval",
+			"repetitions", "due_at", "last_reviewed_at"}),
+	}).Create(note).Error
+}
+
+func (dao *MistakeNoteDaoImpl) UpdateMistakeNote(db *gorm.DB, note *repository.MistakeNote) error {
+	return db.Model(note).Select("note", "ease_factor", "review_interval", "repetitions", "due_at", "last_reviewed_at").
+		Updates(note).Error
+}
+
+func (dao *MistakeNoteDaoImpl) DeleteMistakeNote(db *gorm.DB, userID uint, problemID uint) error {
+	return db.Where("user_id = ? and problem_id = ?", userID, problemID).Delete(&repository.MistakeNote{}).Error
+}
+
+func (dao *MistakeNoteDaoImpl) GetMistakeNoteSetting(db *gorm.DB, userID uint) (*repository.MistakeNoteSetting, error) {
+	setting := &repository.MistakeNoteSetting{UserID: userID}
+	err := db.Where("user_id = ?", userID).Limit(1).Find(setting).Error
+	return setting, err
+}
+
+func (dao *MistakeNoteDaoImpl) SaveMistakeNoteSetting(db *gorm.DB, setting *repository.MistakeNoteSetting) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"updated_at", "email_digest"}),
+	}).Create(setting).Error
+}
+
+func (dao *MistakeNoteDaoImpl) GetMistakeNoteDigests(db *gorm.DB, before time.Time) ([]*repository.MistakeNoteDigest, error) {
+	var digests []*repository.MistakeNoteDigest
+	err := db.Model(&repository.MistakeNote{}).
+		Joins("join mistake_note_setting on mistake_note_setting.user_id = mistake_note.user_id "+
+			"and mistake_note_setting.email_digest = ? and mistake_note_setting.deleted_at is null", true).
+		Select("mistake_note.user_id as user_id, count(*) as count").
+		Where("mistake_note.due_at < ?", before).
+		Group("mistake_note.user_id").Scan(&digests).Error
+	return digests, err
+}
@@ -1,9 +1,11 @@
 package dao
 
 import (
+	"funoj-backend/consts"
 	"funoj-backend/model/form/request"
 	"funoj-backend/model/repository"
 	"gorm.io/gorm"
+	"time"
 )
 
 type ProblemDao interface {
@@ -15,10 +17,14 @@ type ProblemDao interface {
 	GetProblemNameByID(db *gorm.DB, problemID uint) (string, error)
 	// GetProblemByID 根据题目id获取题目
 	GetProblemByID(db *gorm.DB, problemID uint) (*repository.Problem, error)
+	// GetSimpleProblemsByIDs 批量获取题目，只包含编号、名称、难度等基本信息
+	GetSimpleProblemsByIDs(db *gorm.DB, problemIDs []uint) ([]*repository.Problem, error)
+	// GetProblemMenus 获取题目所属的题单
+	GetProblemMenus(db *gorm.DB, problemID uint) ([]*repository.ProblemMenu, error)
 	// InsertProblem 添加题库
 	InsertProblem(db *gorm.DB, problem *repository.Problem) error
 	// UpdateProblem 更新题目
-	// 不修改path
+	// 不修改path，enable由发布流程维护，也不修改
 	UpdateProblem(db *gorm.DB, problem *repository.Problem) error
 	// UpdateProblemField 根据字段进行更新
 	UpdateProblemField(db *gorm.DB, id uint, field string, value string) error
@@ -26,6 +32,10 @@ type ProblemDao interface {
 	CheckProblemNumberExists(db *gorm.DB, problemCode string) (bool, error)
 	// SetProblemEnable 让一个题目可用
 	SetProblemEnable(db *gorm.DB, id uint, enable int) error
+	// UpdateProblemStatus 题目状态仍为fromStatus时更新发布流程状态，同时更新是否可用和定时发布时间，返回是否更新
+	UpdateProblemStatus(db *gorm.DB, id uint, fromStatus int, status int, enable int, publishAt *time.Time) (bool, error)
+	// GetScheduledProblems 获取审核通过且到达定时发布时间的题目
+	GetScheduledProblems(db *gorm.DB, now time.Time) ([]*repository.Problem, error)
 	// DeleteProblemByID 删除题目
 	DeleteProblemByID(db *gorm.DB, id uint) error
 	GetProblemList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.Problem, error)
@@ -63,6 +73,24 @@ func (dao *ProblemDaoImpl) GetProblemNameByID(db *gorm.DB, problemID uint) (stri
 	return problem.Name, err
 }
 
+func (dao *ProblemDaoImpl) GetSimpleProblemsByIDs(db *gorm.DB, problemIDs []uint) ([]*repository.Problem, error) {
+	var problems []*repository.Problem
+	if len(problemIDs) == 0 {
+		return problems, nil
+	}
+	err := db.Select("id", "number", "name", "title", "difficulty", "enable").
+		Where("id in ?", problemIDs).Find(&problems).Error
+	return problems, err
+}
+
+func (dao *ProblemDaoImpl) GetProblemMenus(db *gorm.DB, problemID uint) ([]*repository.ProblemMenu, error) {
+	problem := &repository.Problem{}
+	problem.ID = problemID
+	var menus []*repository.ProblemMenu
+	err := db.Model(problem).Association("Menus").Find(&menus)
+	return menus, err
+}
+
 func (dao *ProblemDaoImpl) GetProblemList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.Problem, error) {
 	var problem *request.ProblemForList
 	if pageQuery.Query != nil {
@@ -133,7 +161,6 @@ func (dao *ProblemDaoImpl) UpdateProblem(db *gorm.DB, problem *repository.Proble
 			"difficulty":  problem.Difficulty,
 			"title":       problem.Title,
 			"languages":   problem.Languages,
-			"enable":      problem.Enable,
 		}).Error; err != nil {
 			return err
 		}
@@ -170,6 +197,23 @@ func (dao *ProblemDaoImpl) SetProblemEnable(db *gorm.DB, id uint, enable int) er
 	return db.Model(&repository.Problem{}).Where("id = ?", id).Update("enable", enable).Error
 }
 
+func (dao *ProblemDaoImpl) UpdateProblemStatus(db *gorm.DB, id uint, fromStatus int, status int, enable int, publishAt *time.Time) (bool, error) {
+	result := db.Model(&repository.Problem{}).Where("id = ? and status = ?", id, fromStatus).Updates(map[string]interface{}{
+		"status":     status,
+		"enable":     enable,
+		"publish_at": publishAt,
+		"updated_at": time.Now(),
+	})
+	return result.RowsAffected != 0, result.Error
+}
+
+func (dao *ProblemDaoImpl) GetScheduledProblems(db *gorm.DB, now time.Time) ([]*repository.Problem, error) {
+	var problems []*repository.Problem
+	err := db.Where("status = ? and publish_at is not null and publish_at <= ?", consts.ProblemStatusApproved, now).
+		Find(&problems).Error
+	return problems, err
+}
+
 func (dao *ProblemDaoImpl) DeleteProblemByID(db *gorm.DB, id uint) error {
 	return db.Delete(&repository.Problem{}, id).Error
 }
@@ -1,8 +1,10 @@
 package dao
 
 import (
+	"funoj-backend/consts"
 	"funoj-backend/model/repository"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProblemAttemptDao interface {
@@ -14,6 +16,26 @@ type ProblemAttemptDao interface {
 	GetProblemAttemptByID(db *gorm.DB, userId uint, problemId uint) (*repository.ProblemAttempt, error)
 	// GetProblemAttemptStatus 通过用户id和题目id查询用户对题目提交状态
 	GetProblemAttemptStatus(db *gorm.DB, userId uint, problemID uint) (int, error)
+	// InitProblemAttempt 用户对题目的提交情况不存在时创建一条空记录
+	InitProblemAttempt(db *gorm.DB, userId uint, problemId uint) error
+	// GetProblemAttemptForUpdate 查询并锁定用户对题目提交情况，需要在事务中使用
+	GetProblemAttemptForUpdate(db *gorm.DB, userId uint, problemId uint) (*repository.ProblemAttempt, error)
+	// UpsertProblemAttempts 批量保存重新计算的提交情况，已有记录在计入了更新的提交时不会被覆盖
+	UpsertProblemAttempts(db *gorm.DB, attempts []*repository.ProblemAttempt) error
+	// DeleteProblemAttemptsWithoutSubmission 删除没有最终判题结果的提交对应的提交情况
+	DeleteProblemAttemptsWithoutSubmission(db *gorm.DB) (int64, error)
+	// GetProblemAttemptStatuses 批量查询用户对多个题目的提交状态，key为题目id，没有提交的题目不在结果中
+	GetProblemAttemptStatuses(db *gorm.DB, userId uint, problemIDs []uint) (map[uint]int, error)
+	// GetSolvedCountByDifficulty 按难度统计用户通过的题目数，Name为难度
+	GetSolvedCountByDifficulty(db *gorm.DB, userId uint) ([]*repository.ProblemAttemptStatistic, error)
+	// GetSolvedCountByTag 按标签统计用户通过的题目数，Name为标签名称
+	GetSolvedCountByTag(db *gorm.DB, userId uint) ([]*repository.ProblemAttemptStatistic, error)
+	// GetSolvedCountByMenu 按题单统计用户通过的题目数，只统计官方题单和用户自己的题单，Name为题单名称
+	GetSolvedCountByMenu(db *gorm.DB, userId uint) ([]*repository.ProblemAttemptStatistic, error)
+	// GetSolvedCount 获取用户通过的题目数
+	GetSolvedCount(db *gorm.DB, userId uint) (int64, error)
+	// GetSolvedUserCount 获取通过题目数不少于solved的用户数
+	GetSolvedUserCount(db *gorm.DB, solved int64) (int64, error)
 }
 
 type ProblemAttemptDaoImpl struct {
@@ -29,12 +51,14 @@ func (dao *ProblemAttemptDaoImpl) InsertProblemAttempt(db *gorm.DB, problemAttem
 
 func (dao *ProblemAttemptDaoImpl) UpdateProblemAttempt(db *gorm.DB, problemAttempt *repository.ProblemAttempt) error {
 	return db.Model(problemAttempt).UpdateColumns(map[string]interface{}{
-		"submission_count": problemAttempt.SubmissionCount,
-		"success_count":    problemAttempt.SuccessCount,
-		"err_count":        problemAttempt.ErrCount,
-		"code":             problemAttempt.Code,
-		"status":           problemAttempt.Status,
-		"updated_at":       problemAttempt.UpdatedAt,
+		"submission_count":   problemAttempt.SubmissionCount,
+		"success_count":      problemAttempt.SuccessCount,
+		"err_count":          problemAttempt.ErrCount,
+		"code":               problemAttempt.Code,
+		"language":           problemAttempt.Language,
+		"status":             problemAttempt.Status,
+		"last_submission_id": problemAttempt.LastSubmissionID,
+		"updated_at":         problemAttempt.UpdatedAt,
 	}).Error
 }
 
@@ -54,3 +78,112 @@ func (dao *ProblemAttemptDaoImpl) GetProblemAttemptStatus(db *gorm.DB, userId ui
 	}
 	return problemAttempt.Status, nil
 }
+
+func (dao *ProblemAttemptDaoImpl) GetProblemAttemptStatuses(db *gorm.DB, userId uint, problemIDs []uint) (map[uint]int, error) {
+	answer := make(map[uint]int, len(problemIDs))
+	if len(problemIDs) == 0 {
+		return answer, nil
+	}
+	var attempts []*repository.ProblemAttempt
+	err := db.Model(&repository.ProblemAttempt{}).Select("problem_id", "status").
+		Where("user_id = ? and problem_id in ?", userId, problemIDs).Find(&attempts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, attempt := range attempts {
+		answer[attempt.ProblemID] = attempt.Status
+	}
+	return answer, nil
+}
+
+func (dao *ProblemAttemptDaoImpl) InitProblemAttempt(db *gorm.DB, userId uint, problemId uint) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&repository.ProblemAttempt{
+		UserID:    userId,
+		ProblemID: problemId,
+	}).Error
+}
+
+func (dao *ProblemAttemptDaoImpl) GetProblemAttemptForUpdate(db *gorm.DB, userId uint, problemId uint) (*repository.ProblemAttempt, error) {
+	problemAttempt := &repository.ProblemAttempt{}
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? and problem_id = ?", userId, problemId).First(problemAttempt).Error
+	return problemAttempt, err
+}
+
+func (dao *ProblemAttemptDaoImpl) UpsertProblemAttempts(db *gorm.DB, attempts []*repository.ProblemAttempt) error {
+	if len(attempts) == 0 {
+		return nil
+	}
+	// mysql按顺序赋值，last_submission_id必须最后更新
+	columns := []string{"submission_count", "success_count", "err_count", "code", "language", "status", "updated_at", "last_submission_id"}
+	assignments := make(clause.Set, len(columns))
+	for i, column := range columns {
+		assignments[i] = clause.Assignment{
+			Column: clause.Column{Name: column},
+			Value:  gorm.Expr("IF(VALUES(last_submission_id) >= last_submission_id, VALUES(" + column + "), " + column + ")"),
+		}
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "problem_id"}},
+		DoUpdates: assignments,
+	}).Create(&attempts).Error
+}
+
+func (dao *ProblemAttemptDaoImpl) DeleteProblemAttemptsWithoutSubmission(db *gorm.DB) (int64, error) {
+	submissions := db.Model(&repository.Submission{}).Select("1").
+		Where("submission.user_id = problem_attempt.user_id and submission.problem_id = problem_attempt.problem_id").
+		Where("submission.status in ?", consts.FinalVerdicts)
+	result := db.Unscoped().Where("not exists (?)", submissions).Delete(&repository.ProblemAttempt{})
+	return result.RowsAffected, result.Error
+}
+
+func (dao *ProblemAttemptDaoImpl) GetSolvedCountByDifficulty(db *gorm.DB, userId uint) ([]*repository.ProblemAttemptStatistic, error) {
+	var statistics []*repository.ProblemAttemptStatistic
+	err := dao.getSolvedAttempts(db, userId).
+		Joins("join problem on problem.id = problem_attempt.problem_id and problem.deleted_at is null").
+		Select("problem.difficulty as name, count(*) as count").
+		Group("problem.difficulty").Order("problem.difficulty").Scan(&statistics).Error
+	return statistics, err
+}
+
+func (dao *ProblemAttemptDaoImpl) GetSolvedCountByTag(db *gorm.DB, userId uint) ([]*repository.ProblemAttemptStatistic, error) {
+	var statistics []*repository.ProblemAttemptStatistic
+	err := dao.getSolvedAttempts(db, userId).
+		Joins("join problem_tag_association on problem_tag_association.problem_id = problem_attempt.problem_id").
+		Joins("join problem_tag on problem_tag.id = problem_tag_association.problem_tag_id and problem_tag.deleted_at is null").
+		Select("problem_tag.name as name, count(*) as count").
+		Group("problem_tag.id, problem_tag.name").Order("count desc").Scan(&statistics).Error
+	return statistics, err
+}
+
+func (dao *ProblemAttemptDaoImpl) GetSolvedCountByMenu(db *gorm.DB, userId uint) ([]*repository.ProblemAttemptStatistic, error) {
+	var statistics []*repository.ProblemAttemptStatistic
+	err := dao.getSolvedAttempts(db, userId).
+		Joins("join problem_menu_association on problem_menu_association.problem_id = problem_attempt.problem_id").
+		Joins("join problem_menu on problem_menu.id = problem_menu_association.problem_menu_id and problem_menu.deleted_at is null").
+		Where("problem_menu.type = ? or problem_menu.creator_id = ?", consts.ProblemMenuTypeOfficial, userId).
+		Select("problem_menu.name as name, count(*) as count").
+		Group("problem_menu.id, problem_menu.name").Order("count desc").Scan(&statistics).Error
+	return statistics, err
+}
+
+func (dao *ProblemAttemptDaoImpl) GetSolvedCount(db *gorm.DB, userId uint) (int64, error) {
+	var count int64
+	err := dao.getSolvedAttempts(db, userId).Count(&count).Error
+	return count, err
+}
+
+func (dao *ProblemAttemptDaoImpl) GetSolvedUserCount(db *gorm.DB, solved int64) (int64, error) {
+	var count int64
+	users := db.Model(&repository.ProblemAttempt{}).Select("user_id").
+		Where("status = ?", consts.AttemptStatusAccepted).
+		Group("user_id").Having("count(*) >= ?", solved)
+	err := db.Table("(?) as solved_user", users).Count(&count).Error
+	return count, err
+}
+
+// getSolvedAttempts 用户已通过的题目
+func (dao *ProblemAttemptDaoImpl) getSolvedAttempts(db *gorm.DB, userId uint) *gorm.DB {
+	return db.Model(&repository.ProblemAttempt{}).
+		Where("problem_attempt.user_id = ? and problem_attempt.status = ?", userId, consts.AttemptStatusAccepted)
+}
@@ -0,0 +1,52 @@
+package dao
+
+import (
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProblemBookmarkDao interface {
+	// InsertProblemBookmark 收藏题目，已收藏时忽略
+	InsertProblemBookmark(db *gorm.DB, userID uint, problemID uint) error
+	// DeleteProblemBookmark 取消收藏
+	DeleteProblemBookmark(db *gorm.DB, userID uint, problemID uint) error
+	// GetProblemBookmarkList 分页获取用户收藏的题目，按收藏时间倒序
+	GetProblemBookmarkList(db *gorm.DB, userID uint, pageQuery *request.PageQuery) ([]*repository.ProblemBookmark, error)
+	// GetProblemBookmarkCount 获取用户收藏的题目数量
+	GetProblemBookmarkCount(db *gorm.DB, userID uint) (int64, error)
+}
+
+type ProblemBookmarkDaoImpl struct {
+}
+
+func NewProblemBookmarkDao() ProblemBookmarkDao {
+	return &ProblemBookmarkDaoImpl{}
+}
+
+func (dao *ProblemBookmarkDaoImpl) InsertProblemBookmark(db *gorm.DB, userID uint, problemID uint) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&repository.ProblemBookmark{
+		UserID:    userID,
+		ProblemID: problemID,
+	}).Error
+}
+
+func (dao *ProblemBookmarkDaoImpl) DeleteProblemBookmark(db *gorm.DB, userID uint, problemID uint) error {
+	return db.Unscoped().Where("user_id = ? and problem_id = ?", userID, problemID).
+		Delete(&repository.ProblemBookmark{}).Error
+}
+
+func (dao *ProblemBookmarkDaoImpl) GetProblemBookmarkList(db *gorm.DB, userID uint, pageQuery *request.PageQuery) ([]*repository.ProblemBookmark, error) {
+	var bookmarks []*repository.ProblemBookmark
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	err := db.Where("user_id = ?", userID).Order("id desc").
+		Offset(offset).Limit(pageQuery.PageSize).Find(&bookmarks).Error
+	return bookmarks, err
+}
+
+func (dao *ProblemBookmarkDaoImpl) GetProblemBookmarkCount(db *gorm.DB, userID uint) (int64, error) {
+	var count int64
+	err := db.Model(&repository.ProblemBookmark{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
@@ -22,6 +22,12 @@ type ProblemCaseDao interface {
 	InsertProblemCase(db *gorm.DB, problemCase *repository.ProblemCase) error
 	// UpdateProblemCase 更新题目用例
 	UpdateProblemCase(db *gorm.DB, problemCase *repository.ProblemCase) error
+	// GetProblemSampleCases 获取题目的样例
+	GetProblemSampleCases(db *gorm.DB, problemID uint) ([]*repository.ProblemCase, error)
+	// GetProblemCaseByName 通过用例名称获取题目用例
+	GetProblemCaseByName(db *gorm.DB, problemID uint, caseName string) (*repository.ProblemCase, error)
+	// UpdateProblemCaseSample 设置用例是否为样例
+	UpdateProblemCaseSample(db *gorm.DB, id uint, sample bool) error
 }
 
 type ProblemCaseDaoImpl struct {
@@ -42,6 +48,9 @@ func (dao *ProblemCaseDaoImpl) GetProblemCaseList(db *gorm.DB, query *request.Pa
 	if problemCase != nil && problemCase.CaseName != "" {
 		db = db.Where("case_name like ?", "%"+problemCase.CaseName+"%")
 	}
+	if problemCase != nil && problemCase.Sample != nil {
+		db = db.Where("sample = ?", *problemCase.Sample)
+	}
 	offset := (query.Page - 1) * query.PageSize
 	var cases []*repository.ProblemCase
 	db = db.Offset(offset).Limit(query.PageSize)
@@ -68,6 +77,9 @@ func (dao *ProblemCaseDaoImpl) GetProblemCaseCount(db *gorm.DB, problemCase *req
 	if problemCase != nil && problemCase.CaseName != "" {
 		db = db.Where("case_name like ?", "%"+problemCase.CaseName+"%")
 	}
+	if problemCase != nil && problemCase.Sample != nil {
+		db = db.Where("sample = ?", *problemCase.Sample)
+	}
 	err := db.Model(&repository.ProblemCase{}).Count(&count).Error
 	return count, err
 }
@@ -93,3 +105,19 @@ func (dao *ProblemCaseDaoImpl) InsertProblemCase(db *gorm.DB, problemCase *repos
 func (dao *ProblemCaseDaoImpl) UpdateProblemCase(db *gorm.DB, problemCase *repository.ProblemCase) error {
 	return db.Model(problemCase).Updates(problemCase).Error
 }
+
+func (dao *ProblemCaseDaoImpl) GetProblemSampleCases(db *gorm.DB, problemID uint) ([]*repository.ProblemCase, error) {
+	var cases []*repository.ProblemCase
+	err := db.Where("problem_id = ? and sample = ?", problemID, true).Order("case_name").Find(&cases).Error
+	return cases, err
+}
+
+func (dao *ProblemCaseDaoImpl) GetProblemCaseByName(db *gorm.DB, problemID uint, caseName string) (*repository.ProblemCase, error) {
+	problemCase := &repository.ProblemCase{}
+	err := db.Where("problem_id = ? and case_name = ?", problemID, caseName).First(problemCase).Error
+	return problemCase, err
+}
+
+func (dao *ProblemCaseDaoImpl) UpdateProblemCaseSample(db *gorm.DB, id uint, sample bool) error {
+	return db.Model(&repository.ProblemCase{}).Where("id = ?", id).Update("sample", sample).Error
+}
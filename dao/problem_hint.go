@@ -0,0 +1,113 @@
+package dao
+
+import (
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProblemHintDao interface {
+	// InsertProblemHint 添加提示
+	InsertProblemHint(db *gorm.DB, hint *repository.ProblemHint) error
+	// UpdateProblemHint 更新提示
+	UpdateProblemHint(db *gorm.DB, hint *repository.ProblemHint) error
+	// DeleteProblemHintByID 删除提示
+	DeleteProblemHintByID(db *gorm.DB, id uint) error
+	// GetProblemHintByID 根据id获取提示
+	GetProblemHintByID(db *gorm.DB, id uint) (*repository.ProblemHint, error)
+	// GetProblemHints 获取题目的所有提示，按顺序排列
+	GetProblemHints(db *gorm.DB, problemID uint) ([]*repository.ProblemHint, error)
+	// InsertProblemHintUnlock 记录解锁提示，已解锁时忽略
+	InsertProblemHintUnlock(db *gorm.DB, unlock *repository.ProblemHintUnlock) error
+	// GetUserProblemHintUnlocks 获取用户在一道题目中解锁的提示
+	GetUserProblemHintUnlocks(db *gorm.DB, userID uint, problemID uint) ([]*repository.ProblemHintUnlock, error)
+	// GetProblemHintUnlockList 分页获取题目的提示解锁记录
+	GetProblemHintUnlockList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.ProblemHintUnlock, error)
+	// GetProblemHintUnlockCount 获取题目的提示解锁记录数量
+	GetProblemHintUnlockCount(db *gorm.DB, query *request.ProblemHintUnlockForList) (int64, error)
+	// GetUserProblemHintPenalty 获取用户在一道题目中因解锁提示扣除的总分
+	GetUserProblemHintPenalty(db *gorm.DB, userID uint, problemID uint) (int, error)
+}
+
+type ProblemHintDaoImpl struct {
+}
+
+func NewProblemHintDao() ProblemHintDao {
+	return &ProblemHintDaoImpl{}
+}
+
+func (dao *ProblemHintDaoImpl) InsertProblemHint(db *gorm.DB, hint *repository.ProblemHint) error {
+	return db.Create(hint).Error
+}
+
+func (dao *ProblemHintDaoImpl) UpdateProblemHint(db *gorm.DB, hint *repository.ProblemHint) error {
+	return db.Model(hint).Where("id = ?", hint.ID).Updates(map[string]interface{}{
+		"updated_at":       hint.UpdatedAt,
+		"sort":             hint.Sort,
+		"content":          hint.Content,
+		"unlock_err_count": hint.UnlockErrCount,
+		"penalty":          hint.Penalty,
+	}).Error
+}
+
+func (dao *ProblemHintDaoImpl) DeleteProblemHintByID(db *gorm.DB, id uint) error {
+	return db.Delete(&repository.ProblemHint{}, id).Error
+}
+
+func (dao *ProblemHintDaoImpl) GetProblemHintByID(db *gorm.DB, id uint) (*repository.ProblemHint, error) {
+	hint := &repository.ProblemHint{}
+	err := db.First(hint, id).Error
+	return hint, err
+}
+
+func (dao *ProblemHintDaoImpl) GetProblemHints(db *gorm.DB, problemID uint) ([]*repository.ProblemHint, error) {
+	var hints []*repository.ProblemHint
+	err := db.Where("problem_id = ?", problemID).Order("sort").Order("id").Find(&hints).Error
+	return hints, err
+}
+
+func (dao *ProblemHintDaoImpl) InsertProblemHintUnlock(db *gorm.DB, unlock *repository.ProblemHintUnlock) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(unlock).Error
+}
+
+func (dao *ProblemHintDaoImpl) GetUserProblemHintUnlocks(db *gorm.DB, userID uint, problemID uint) ([]*repository.ProblemHintUnlock, error) {
+	var unlocks []*repository.ProblemHintUnlock
+	err := db.Where("user_id = ? and problem_id = ?", userID, problemID).Find(&unlocks).Error
+	return unlocks, err
+}
+
+func (dao *ProblemHintDaoImpl) GetProblemHintUnlockList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.ProblemHintUnlock, error) {
+	var query *request.ProblemHintUnlockForList
+	if pageQuery.Query != nil {
+		query = pageQuery.Query.(*request.ProblemHintUnlockForList)
+	}
+	db = dao.buildUnlockQuery(db, query)
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	var unlocks []*repository.ProblemHintUnlock
+	err := db.Order("id desc").Offset(offset).Limit(pageQuery.PageSize).Find(&unlocks).Error
+	return unlocks, err
+}
+
+func (dao *ProblemHintDaoImpl) GetProblemHintUnlockCount(db *gorm.DB, query *request.ProblemHintUnlockForList) (int64, error) {
+	var count int64
+	err := dao.buildUnlockQuery(db, query).Model(&repository.ProblemHintUnlock{}).Count(&count).Error
+	return count, err
+}
+
+func (dao *ProblemHintDaoImpl) GetUserProblemHintPenalty(db *gorm.DB, userID uint, problemID uint) (int, error) {
+	var penalty int
+	err := db.Model(&repository.ProblemHintUnlock{}).Select("coalesce(sum(penalty), 0)").
+		Where("user_id = ? and problem_id = ?", userID, problemID).Scan(&penalty).Error
+	return penalty, err
+}
+
+func (dao *ProblemHintDaoImpl) buildUnlockQuery(db *gorm.DB, query *request.ProblemHintUnlockForList) *gorm.DB {
+	if query != nil && query.ProblemID != 0 {
+		db = db.Where("problem_id = ?", query.ProblemID)
+	}
+	if query != nil && query.UserID != 0 {
+		db = db.Where("user_id = ?", query.UserID)
+	}
+	return db
+}
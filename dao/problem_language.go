@@ -0,0 +1,50 @@
+package dao
+
+import (
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+)
+
+type ProblemLanguageDao interface {
+	// GetProblemLanguages 获取题目允许的所有语言
+	GetProblemLanguages(db *gorm.DB, problemID uint) ([]*repository.ProblemLanguage, error)
+	// GetProblemLanguage 获取题目某个语言的设置
+	GetProblemLanguage(db *gorm.DB, problemID uint, language string) (*repository.ProblemLanguage, error)
+	// ReplaceProblemLanguages 替换题目允许的所有语言
+	ReplaceProblemLanguages(db *gorm.DB, problemID uint, languages []*repository.ProblemLanguage) error
+}
+
+type ProblemLanguageDaoImpl struct {
+}
+
+func NewProblemLanguageDao() ProblemLanguageDao {
+	return &ProblemLanguageDaoImpl{}
+}
+
+func (dao *ProblemLanguageDaoImpl) GetProblemLanguages(db *gorm.DB, problemID uint) ([]*repository.ProblemLanguage, error) {
+	var languages []*repository.ProblemLanguage
+	err := db.Where("problem_id = ?", problemID).Order("id").Find(&languages).Error
+	return languages, err
+}
+
+func (dao *ProblemLanguageDaoImpl) GetProblemLanguage(db *gorm.DB, problemID uint, language string) (*repository.ProblemLanguage, error) {
+	problemLanguage := &repository.ProblemLanguage{}
+	err := db.Where("problem_id = ? and language = ?", problemID, language).First(problemLanguage).Error
+	return problemLanguage, err
+}
+
+func (dao *ProblemLanguageDaoImpl) ReplaceProblemLanguages(db *gorm.DB, problemID uint, languages []*repository.ProblemLanguage) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("problem_id = ?", problemID).Delete(&repository.ProblemLanguage{}).Error; err != nil {
+			return err
+		}
+		if len(languages) == 0 {
+			return nil
+		}
+		for _, language := range languages {
+			language.ID = 0
+			language.ProblemID = problemID
+		}
+		return tx.Create(&languages).Error
+	})
+}
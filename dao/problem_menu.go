@@ -1,9 +1,11 @@
 package dao
 
 import (
+	"funoj-backend/consts"
 	"funoj-backend/model/form/request"
 	"funoj-backend/model/repository"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProblemMenuDao interface {
@@ -13,16 +15,50 @@ type ProblemMenuDao interface {
 	GetProblemMenuByID(db *gorm.DB, bankID uint) (*repository.ProblemMenu, error)
 	// UpdateProblemMenu 更新题单
 	UpdateProblemMenu(db *gorm.DB, bank *repository.ProblemMenu) error
+	// UpdateProblemMenuVisibility 更新题单可见性和分享码
+	UpdateProblemMenuVisibility(db *gorm.DB, id uint, visibility int, shareCode string) error
 	// DeleteProblemMenuByID 删除题单
 	DeleteProblemMenuByID(db *gorm.DB, id uint) error
 	// GetProblemMenuCount 读取题单数量
 	GetProblemMenuCount(db *gorm.DB, problemBank *request.ProblemMenuForList) (int64, error)
 	// GetProblemMenuList 获取题单列表
 	GetProblemMenuList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.ProblemMenu, error)
-	// GetAllProblemMenu 获取所有的题目数据
+	// GetAllProblemMenu 获取所有的官方题单
 	GetAllProblemMenu(db *gorm.DB) ([]*repository.ProblemMenu, error)
-	// GetSimpleProblemMenuList 获取题单列表，只包含id和名称
+	// GetSimpleProblemMenuList 获取官方题单列表，只包含id和名称
 	GetSimpleProblemMenuList(db *gorm.DB) ([]*repository.ProblemMenu, error)
+	// GetProblemMenuSections 获取题单中的分组，按顺序排列
+	GetProblemMenuSections(db *gorm.DB, menuID uint) ([]*repository.ProblemMenuSection, error)
+	// GetProblemMenuSectionByID 根据id获取分组
+	GetProblemMenuSectionByID(db *gorm.DB, id uint) (*repository.ProblemMenuSection, error)
+	// InsertProblemMenuSection 添加分组
+	InsertProblemMenuSection(db *gorm.DB, section *repository.ProblemMenuSection) error
+	// UpdateProblemMenuSection 更新分组名称和顺序
+	UpdateProblemMenuSection(db *gorm.DB, section *repository.ProblemMenuSection) error
+	// DeleteProblemMenuSection 删除分组，分组中的题目变为未分组
+	DeleteProblemMenuSection(db *gorm.DB, id uint) error
+	// GetProblemMenuProblemCount 获取题单中未删除的题目数
+	GetProblemMenuProblemCount(db *gorm.DB, menuID uint) (int64, error)
+	// GetProblemMenuItems 获取题单中题目的分组和顺序，按顺序排列
+	GetProblemMenuItems(db *gorm.DB, menuID uint) ([]*repository.ProblemMenuAssociation, error)
+	// UpdateProblemMenuItem 更新题目在题单中的分组和顺序
+	UpdateProblemMenuItem(db *gorm.DB, item *repository.ProblemMenuAssociation) error
+	// InsertProblemMenuItem 向题单中添加题目，题目已在题单中时忽略
+	InsertProblemMenuItem(db *gorm.DB, item *repository.ProblemMenuAssociation) error
+	// DeleteProblemMenuItem 从题单中移除题目
+	DeleteProblemMenuItem(db *gorm.DB, menuID uint, problemID uint) error
+	// GetProblemMenuMaxSort 获取题单中未分组题目的最大顺序
+	GetProblemMenuMaxSort(db *gorm.DB, menuID uint) (int, error)
+	// GetProblemMenuByShareCode 根据分享码获取题单
+	GetProblemMenuByShareCode(db *gorm.DB, shareCode string) (*repository.ProblemMenu, error)
+	// InsertProblemMenuFollow 关注题单，已关注时忽略
+	InsertProblemMenuFollow(db *gorm.DB, menuID uint, userID uint) error
+	// DeleteProblemMenuFollow 取消关注题单
+	DeleteProblemMenuFollow(db *gorm.DB, menuID uint, userID uint) error
+	// CheckProblemMenuFollowed 检测用户是否关注了题单
+	CheckProblemMenuFollowed(db *gorm.DB, menuID uint, userID uint) (bool, error)
+	// GetFollowedProblemMenus 获取用户关注的题单
+	GetFollowedProblemMenus(db *gorm.DB, userID uint) ([]*repository.ProblemMenu, error)
 }
 
 type ProblemMenuDaoImpl struct {
@@ -46,6 +82,13 @@ func (dao *ProblemMenuDaoImpl) UpdateProblemMenu(db *gorm.DB, problemMenu *repos
 	return db.Model(problemMenu).Updates(problemMenu).Error
 }
 
+func (dao *ProblemMenuDaoImpl) UpdateProblemMenuVisibility(db *gorm.DB, id uint, visibility int, shareCode string) error {
+	return db.Model(&repository.ProblemMenu{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"visibility": visibility,
+		"share_code": shareCode,
+	}).Error
+}
+
 func (dao *ProblemMenuDaoImpl) DeleteProblemMenuByID(db *gorm.DB, id uint) error {
 	return db.Delete(&repository.ProblemMenu{}, id).Error
 }
@@ -58,6 +101,15 @@ func (dao *ProblemMenuDaoImpl) GetProblemMenuCount(db *gorm.DB, problemMenu *req
 	if problemMenu != nil && problemMenu.Description != "" {
 		db = db.Where("description = ?", problemMenu.Description)
 	}
+	if problemMenu != nil && problemMenu.CreatorID != 0 {
+		db = db.Where("creator_id = ?", problemMenu.CreatorID)
+	}
+	if problemMenu != nil && problemMenu.Type != nil {
+		db = db.Where("type = ?", *problemMenu.Type)
+	}
+	if problemMenu != nil && problemMenu.Visibility != nil {
+		db = db.Where("visibility = ?", *problemMenu.Visibility)
+	}
 	err := db.Model(&repository.ProblemMenu{}).Count(&count).Error
 	return count, err
 }
@@ -73,6 +125,15 @@ func (dao *ProblemMenuDaoImpl) GetProblemMenuList(db *gorm.DB, pageQuery *reques
 	if problemMenu != nil && problemMenu.Description != "" {
 		db = db.Where("description like ?", "%"+problemMenu.Description+"%")
 	}
+	if problemMenu != nil && problemMenu.CreatorID != 0 {
+		db = db.Where("creator_id = ?", problemMenu.CreatorID)
+	}
+	if problemMenu != nil && problemMenu.Type != nil {
+		db = db.Where("type = ?", *problemMenu.Type)
+	}
+	if problemMenu != nil && problemMenu.Visibility != nil {
+		db = db.Where("visibility = ?", *problemMenu.Visibility)
+	}
 	offset := (pageQuery.Page - 1) * pageQuery.PageSize
 	var menus []*repository.ProblemMenu
 	db = db.Offset(offset).Limit(pageQuery.PageSize)
@@ -86,12 +147,116 @@ func (dao *ProblemMenuDaoImpl) GetProblemMenuList(db *gorm.DB, pageQuery *reques
 
 func (dao *ProblemMenuDaoImpl) GetAllProblemMenu(db *gorm.DB) ([]*repository.ProblemMenu, error) {
 	var menus []*repository.ProblemMenu
-	err := db.Find(&menus).Error
+	err := db.Where("type = ?", consts.ProblemMenuTypeOfficial).Find(&menus).Error
 	return menus, err
 }
 
 func (dao *ProblemMenuDaoImpl) GetSimpleProblemMenuList(db *gorm.DB) ([]*repository.ProblemMenu, error) {
 	var menus []*repository.ProblemMenu
-	err := db.Select("id", "name").Find(&menus).Error
+	err := db.Select("id", "name").Where("type = ?", consts.ProblemMenuTypeOfficial).Find(&menus).Error
+	return menus, err
+}
+
+func (dao *ProblemMenuDaoImpl) GetProblemMenuProblemCount(db *gorm.DB, menuID uint) (int64, error) {
+	var count int64
+	err := db.Model(&repository.ProblemMenuAssociation{}).
+		Joins("join problem on problem.id = problem_menu_association.problem_id and problem.deleted_at is null").
+		Where("problem_menu_association.problem_menu_id = ?", menuID).Count(&count).Error
+	return count, err
+}
+
+func (dao *ProblemMenuDaoImpl) GetProblemMenuSections(db *gorm.DB, menuID uint) ([]*repository.ProblemMenuSection, error) {
+	var sections []*repository.ProblemMenuSection
+	err := db.Where("menu_id = ?", menuID).Order("sort").Order("id").Find(&sections).Error
+	return sections, err
+}
+
+func (dao *ProblemMenuDaoImpl) GetProblemMenuSectionByID(db *gorm.DB, id uint) (*repository.ProblemMenuSection, error) {
+	section := &repository.ProblemMenuSection{}
+	err := db.Where("id = ?", id).First(section).Error
+	return section, err
+}
+
+func (dao *ProblemMenuDaoImpl) InsertProblemMenuSection(db *gorm.DB, section *repository.ProblemMenuSection) error {
+	return db.Create(section).Error
+}
+
+func (dao *ProblemMenuDaoImpl) UpdateProblemMenuSection(db *gorm.DB, section *repository.ProblemMenuSection) error {
+	return db.Model(section).Updates(map[string]interface{}{
+		"name": section.Name,
+		"sort": section.Sort,
+	}).Error
+}
+
+func (dao *ProblemMenuDaoImpl) DeleteProblemMenuSection(db *gorm.DB, id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&repository.ProblemMenuAssociation{}).Where("section_id = ?", id).
+			Update("section_id", 0).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&repository.ProblemMenuSection{}, id).Error
+	})
+}
+
+func (dao *ProblemMenuDaoImpl) GetProblemMenuItems(db *gorm.DB, menuID uint) ([]*repository.ProblemMenuAssociation, error) {
+	var items []*repository.ProblemMenuAssociation
+	err := db.Where("problem_menu_id = ?", menuID).Order("sort").Order("problem_id").Find(&items).Error
+	return items, err
+}
+
+func (dao *ProblemMenuDaoImpl) UpdateProblemMenuItem(db *gorm.DB, item *repository.ProblemMenuAssociation) error {
+	return db.Model(&repository.ProblemMenuAssociation{}).
+		Where("problem_menu_id = ? and problem_id = ?", item.ProblemMenuID, item.ProblemID).
+		Updates(map[string]interface{}{
+			"section_id": item.SectionID,
+			"sort":       item.Sort,
+		}).Error
+}
+
+func (dao *ProblemMenuDaoImpl) InsertProblemMenuItem(db *gorm.DB, item *repository.ProblemMenuAssociation) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(item).Error
+}
+
+func (dao *ProblemMenuDaoImpl) DeleteProblemMenuItem(db *gorm.DB, menuID uint, problemID uint) error {
+	return db.Where("problem_menu_id = ? and problem_id = ?", menuID, problemID).
+		Delete(&repository.ProblemMenuAssociation{}).Error
+}
+
+func (dao *ProblemMenuDaoImpl) GetProblemMenuMaxSort(db *gorm.DB, menuID uint) (int, error) {
+	var sort int
+	err := db.Model(&repository.ProblemMenuAssociation{}).Select("coalesce(max(sort), 0)").
+		Where("problem_menu_id = ? and section_id = 0", menuID).Scan(&sort).Error
+	return sort, err
+}
+
+func (dao *ProblemMenuDaoImpl) GetProblemMenuByShareCode(db *gorm.DB, shareCode string) (*repository.ProblemMenu, error) {
+	problemMenu := &repository.ProblemMenu{}
+	err := db.Where("share_code = ?", shareCode).First(problemMenu).Error
+	return problemMenu, err
+}
+
+func (dao *ProblemMenuDaoImpl) InsertProblemMenuFollow(db *gorm.DB, menuID uint, userID uint) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&repository.ProblemMenuFollow{
+		MenuID: menuID,
+		UserID: userID,
+	}).Error
+}
+
+func (dao *ProblemMenuDaoImpl) DeleteProblemMenuFollow(db *gorm.DB, menuID uint, userID uint) error {
+	return db.Unscoped().Where("menu_id = ? and user_id = ?", menuID, userID).
+		Delete(&repository.ProblemMenuFollow{}).Error
+}
+
+func (dao *ProblemMenuDaoImpl) CheckProblemMenuFollowed(db *gorm.DB, menuID uint, userID uint) (bool, error) {
+	var count int64
+	err := db.Model(&repository.ProblemMenuFollow{}).Where("menu_id = ? and user_id = ?", menuID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (dao *ProblemMenuDaoImpl) GetFollowedProblemMenus(db *gorm.DB, userID uint) ([]*repository.ProblemMenu, error) {
+	var menus []*repository.ProblemMenu
+	err := db.Joins("join problem_menu_follow f on f.menu_id = problem_menu.id and f.deleted_at is null").
+		Where("f.user_id = ?", userID).Order("f.id desc").Find(&menus).Error
 	return menus, err
 }
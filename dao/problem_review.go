@@ -0,0 +1,30 @@
+package dao
+
+import (
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+)
+
+type ProblemReviewDao interface {
+	// InsertProblemReview 添加题目状态变更记录
+	InsertProblemReview(db *gorm.DB, review *repository.ProblemReview) error
+	// GetProblemReviewsByProblemID 获取题目的所有状态变更记录，按时间先后排序
+	GetProblemReviewsByProblemID(db *gorm.DB, problemID uint) ([]*repository.ProblemReview, error)
+}
+
+type ProblemReviewDaoImpl struct {
+}
+
+func NewProblemReviewDao() ProblemReviewDao {
+	return &ProblemReviewDaoImpl{}
+}
+
+func (dao *ProblemReviewDaoImpl) InsertProblemReview(db *gorm.DB, review *repository.ProblemReview) error {
+	return db.Create(review).Error
+}
+
+func (dao *ProblemReviewDaoImpl) GetProblemReviewsByProblemID(db *gorm.DB, problemID uint) ([]*repository.ProblemReview, error) {
+	var reviews []*repository.ProblemReview
+	err := db.Where("problem_id = ?", problemID).Order("id").Find(&reviews).Error
+	return reviews, err
+}
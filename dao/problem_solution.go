@@ -0,0 +1,115 @@
+package dao
+
+import (
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+)
+
+type ProblemSolutionDao interface {
+	// InsertProblemSolution 添加题解，同时添加参考代码
+	InsertProblemSolution(db *gorm.DB, solution *repository.ProblemSolution) error
+	// UpdateProblemSolution 更新题解，参考代码整体替换
+	UpdateProblemSolution(db *gorm.DB, solution *repository.ProblemSolution) error
+	// DeleteProblemSolutionByID 删除题解及其参考代码
+	DeleteProblemSolutionByID(db *gorm.DB, id uint) error
+	// GetProblemSolutionByID 通过id获取题解，包含参考代码
+	GetProblemSolutionByID(db *gorm.DB, id uint) (*repository.ProblemSolution, error)
+	// GetProblemSolutionList 获取题解列表，不包含参考代码
+	GetProblemSolutionList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.ProblemSolution, error)
+	// GetProblemSolutionCount 获取题解数量
+	GetProblemSolutionCount(db *gorm.DB, solution *request.ProblemSolutionForList) (int64, error)
+	// GetProblemSolutionsByProblemID 获取一个题目的所有题解，包含参考代码
+	GetProblemSolutionsByProblemID(db *gorm.DB, problemID uint) ([]*repository.ProblemSolution, error)
+}
+
+type ProblemSolutionDaoImpl struct {
+}
+
+func NewProblemSolutionDao() ProblemSolutionDao {
+	return &ProblemSolutionDaoImpl{}
+}
+
+func (dao *ProblemSolutionDaoImpl) InsertProblemSolution(db *gorm.DB, solution *repository.ProblemSolution) error {
+	return db.Create(solution).Error
+}
+
+func (dao *ProblemSolutionDaoImpl) UpdateProblemSolution(db *gorm.DB, solution *repository.ProblemSolution) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(solution).Where("id = ?", solution.ID).Updates(map[string]interface{}{
+			"updated_at":   solution.UpdatedAt,
+			"title":        solution.Title,
+			"content":      solution.Content,
+			"visible_type": solution.VisibleType,
+			"visible_at":   solution.VisibleAt,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("solution_id = ?", solution.ID).Delete(&repository.ProblemSolutionCode{}).Error; err != nil {
+			return err
+		}
+		for _, code := range solution.Codes {
+			code.ID = 0
+			code.SolutionID = solution.ID
+		}
+		if len(solution.Codes) == 0 {
+			return nil
+		}
+		return tx.Create(&solution.Codes).Error
+	})
+}
+
+func (dao *ProblemSolutionDaoImpl) DeleteProblemSolutionByID(db *gorm.DB, id uint) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("solution_id = ?", id).Delete(&repository.ProblemSolutionCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&repository.ProblemSolution{}, id).Error
+	})
+}
+
+func (dao *ProblemSolutionDaoImpl) GetProblemSolutionByID(db *gorm.DB, id uint) (*repository.ProblemSolution, error) {
+	solution := &repository.ProblemSolution{}
+	err := db.Preload("Codes").First(solution, id).Error
+	return solution, err
+}
+
+func (dao *ProblemSolutionDaoImpl) GetProblemSolutionList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.ProblemSolution, error) {
+	var solution *request.ProblemSolutionForList
+	if pageQuery.Query != nil {
+		solution = pageQuery.Query.(*request.ProblemSolutionForList)
+	}
+	if solution != nil && solution.ProblemID != 0 {
+		db = db.Where("problem_id = ?", solution.ProblemID)
+	}
+	if solution != nil && solution.Title != "" {
+		db = db.Where("title like ?", "%"+solution.Title+"%")
+	}
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	var solutions []*repository.ProblemSolution
+	db = db.Offset(offset).Limit(pageQuery.PageSize)
+	if pageQuery.SortProperty != "" && pageQuery.SortRule != "" {
+		order := pageQuery.SortProperty + " " + pageQuery.SortRule
+		db = db.Order(order)
+	}
+	err := db.Omit("content").Find(&solutions).Error
+	return solutions, err
+}
+
+func (dao *ProblemSolutionDaoImpl) GetProblemSolutionCount(db *gorm.DB, solution *request.ProblemSolutionForList) (int64, error) {
+	var count int64
+	if solution != nil && solution.ProblemID != 0 {
+		db = db.Where("problem_id = ?", solution.ProblemID)
+	}
+	if solution != nil && solution.Title != "" {
+		db = db.Where("title like ?", "%"+solution.Title+"%")
+	}
+	err := db.Model(&repository.ProblemSolution{}).Count(&count).Error
+	return count, err
+}
+
+func (dao *ProblemSolutionDaoImpl) GetProblemSolutionsByProblemID(db *gorm.DB, problemID uint) ([]*repository.ProblemSolution, error) {
+	var solutions []*repository.ProblemSolution
+	err := db.Preload("Codes").Where("problem_id = ?", problemID).Order("id").Find(&solutions).Error
+	return solutions, err
+}
@@ -0,0 +1,88 @@
+package dao
+
+import (
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type ProblemStatisticDao interface {
+	// IncreaseProblemStatistic 增量更新题目统计，不存在则创建
+	IncreaseProblemStatistic(db *gorm.DB, problemID uint, accepted bool, newSolver bool) error
+	// IncreaseProblemStatisticItem 增量更新题目分布统计，不存在则创建
+	IncreaseProblemStatisticItem(db *gorm.DB, problemID uint, category string, item string) error
+	// GetProblemStatisticByProblemID 获取题目统计
+	GetProblemStatisticByProblemID(db *gorm.DB, problemID uint) (*repository.ProblemStatistic, error)
+	// GetProblemStatisticsByProblemIDs 批量获取题目统计
+	GetProblemStatisticsByProblemIDs(db *gorm.DB, problemIDs []uint) ([]*repository.ProblemStatistic, error)
+	// GetProblemStatisticItems 获取题目的所有分布统计
+	GetProblemStatisticItems(db *gorm.DB, problemID uint) ([]*repository.ProblemStatisticItem, error)
+}
+
+type ProblemStatisticDaoImpl struct {
+}
+
+func NewProblemStatisticDao() ProblemStatisticDao {
+	return &ProblemStatisticDaoImpl{}
+}
+
+func (dao *ProblemStatisticDaoImpl) IncreaseProblemStatistic(db *gorm.DB, problemID uint, accepted bool, newSolver bool) error {
+	statistic := &repository.ProblemStatistic{
+		ProblemID:       problemID,
+		SubmissionCount: 1,
+	}
+	updates := map[string]interface{}{
+		"submission_count": gorm.Expr("submission_count + ?", 1),
+		"updated_at":       time.Now(),
+	}
+	if accepted {
+		statistic.AcceptedCount = 1
+		updates["accepted_count"] = gorm.Expr("accepted_count + ?", 1)
+	}
+	if newSolver {
+		statistic.SolverCount = 1
+		updates["solver_count"] = gorm.Expr("solver_count + ?", 1)
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "problem_id"}},
+		DoUpdates: clause.Assignments(updates),
+	}).Create(statistic).Error
+}
+
+func (dao *ProblemStatisticDaoImpl) IncreaseProblemStatisticItem(db *gorm.DB, problemID uint, category string, item string) error {
+	statisticItem := &repository.ProblemStatisticItem{
+		ProblemID: problemID,
+		Category:  category,
+		Item:      item,
+		Count:     1,
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "problem_id"}, {Name: "category"}, {Name: "item"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"count":      gorm.Expr("count + ?", 1),
+			"updated_at": time.Now(),
+		}),
+	}).Create(statisticItem).Error
+}
+
+func (dao *ProblemStatisticDaoImpl) GetProblemStatisticByProblemID(db *gorm.DB, problemID uint) (*repository.ProblemStatistic, error) {
+	statistic := &repository.ProblemStatistic{}
+	err := db.Where("problem_id = ?", problemID).Find(statistic).Error
+	return statistic, err
+}
+
+func (dao *ProblemStatisticDaoImpl) GetProblemStatisticsByProblemIDs(db *gorm.DB, problemIDs []uint) ([]*repository.ProblemStatistic, error) {
+	var statistics []*repository.ProblemStatistic
+	if len(problemIDs) == 0 {
+		return statistics, nil
+	}
+	err := db.Where("problem_id in ?", problemIDs).Find(&statistics).Error
+	return statistics, err
+}
+
+func (dao *ProblemStatisticDaoImpl) GetProblemStatisticItems(db *gorm.DB, problemID uint) ([]*repository.ProblemStatisticItem, error) {
+	var items []*repository.ProblemStatisticItem
+	err := db.Where("problem_id = ?", problemID).Find(&items).Error
+	return items, err
+}
@@ -0,0 +1,67 @@
+package dao
+
+import (
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+)
+
+type ProblemTemplateDao interface {
+	// InsertProblemTemplate 添加一个模板版本
+	InsertProblemTemplate(db *gorm.DB, template *repository.ProblemTemplate) error
+	// GetLatestProblemTemplate 获取题目某个语言最新版本的模板，problemID为0时获取全局默认模板
+	GetLatestProblemTemplate(db *gorm.DB, problemID uint, language string) (*repository.ProblemTemplate, error)
+	// GetLatestProblemTemplates 获取题目所有语言最新版本的模板
+	GetLatestProblemTemplates(db *gorm.DB, problemID uint) ([]*repository.ProblemTemplate, error)
+	// GetProblemTemplateByVersion 获取题目某个语言指定版本的模板
+	GetProblemTemplateByVersion(db *gorm.DB, problemID uint, language string, version int) (*repository.ProblemTemplate, error)
+	// GetProblemTemplateHistory 获取题目某个语言的所有版本，新版本在前
+	GetProblemTemplateHistory(db *gorm.DB, problemID uint, language string) ([]*repository.ProblemTemplate, error)
+	// DeleteProblemTemplates 删除题目某个语言的所有版本
+	DeleteProblemTemplates(db *gorm.DB, problemID uint, language string) error
+}
+
+type ProblemTemplateDaoImpl struct {
+}
+
+func NewProblemTemplateDao() ProblemTemplateDao {
+	return &ProblemTemplateDaoImpl{}
+}
+
+func (dao *ProblemTemplateDaoImpl) InsertProblemTemplate(db *gorm.DB, template *repository.ProblemTemplate) error {
+	return db.Create(template).Error
+}
+
+func (dao *ProblemTemplateDaoImpl) GetLatestProblemTemplate(db *gorm.DB, problemID uint, language string) (*repository.ProblemTemplate, error) {
+	template := &repository.ProblemTemplate{}
+	err := db.Where("problem_id = ? and language = ?", problemID, language).
+		Order("version desc").First(template).Error
+	return template, err
+}
+
+func (dao *ProblemTemplateDaoImpl) GetLatestProblemTemplates(db *gorm.DB, problemID uint) ([]*repository.ProblemTemplate, error) {
+	var templates []*repository.ProblemTemplate
+	latest := db.Model(&repository.ProblemTemplate{}).Select("language", "max(version)").
+		Where("problem_id = ?", problemID).Group("language")
+	err := db.Where("problem_id = ? and (language, version) in (?)", problemID, latest).
+		Find(&templates).Error
+	return templates, err
+}
+
+func (dao *ProblemTemplateDaoImpl) GetProblemTemplateByVersion(db *gorm.DB, problemID uint, language string, version int) (*repository.ProblemTemplate, error) {
+	template := &repository.ProblemTemplate{}
+	err := db.Where("problem_id = ? and language = ? and version = ?", problemID, language, version).
+		First(template).Error
+	return template, err
+}
+
+func (dao *ProblemTemplateDaoImpl) GetProblemTemplateHistory(db *gorm.DB, problemID uint, language string) ([]*repository.ProblemTemplate, error) {
+	var templates []*repository.ProblemTemplate
+	err := db.Where("problem_id = ? and language = ?", problemID, language).
+		Order("version desc").Find(&templates).Error
+	return templates, err
+}
+
+func (dao *ProblemTemplateDaoImpl) DeleteProblemTemplates(db *gorm.DB, problemID uint, language string) error {
+	return db.Unscoped().Where("problem_id = ? and language = ?", problemID, language).
+		Delete(&repository.ProblemTemplate{}).Error
+}
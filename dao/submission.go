@@ -1,7 +1,7 @@
 package dao
 
 import (
-	"errors"
+	"funoj-backend/consts"
 	"funoj-backend/model/form/request"
 	"funoj-backend/model/repository"
 	"gorm.io/gorm"
@@ -11,16 +11,45 @@ import (
 type SubmissionDao interface {
 	// GetLastSubmission 获取最后一次提交情况
 	GetLastSubmission(db *gorm.DB, userID uint, problemID uint) (*repository.Submission, error)
+	// GetSubmissionByID 根据id获取提交
+	GetSubmissionByID(db *gorm.DB, id uint) (*repository.Submission, error)
+	// GetSubmissionByShareCode 根据分享码获取提交
+	GetSubmissionByShareCode(db *gorm.DB, shareCode string) (*repository.Submission, error)
+	// UpdateSubmissionShareCode 设置提交的分享码，为空表示取消分享
+	UpdateSubmissionShareCode(db *gorm.DB, id uint, shareCode string) error
 	// GetSubmissionList 获取提交列表
 	GetSubmissionList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.Submission, error)
 	// GetSubmissionCount 获取提交数
 	GetSubmissionCount(db *gorm.DB, submission *request.SubmissionForList) (int64, error)
-	// GetUserSimpleSubmissionsByTime 获取用户一段时间内的提交概况
-	GetUserSimpleSubmissionsByTime(db *gorm.DB, userID uint, begin time.Time, end time.Time) ([]*repository.Submission, error)
-	// CheckUserIsSubmittedByTime 检验用户是否在一段时间内进行过提交
-	CheckUserIsSubmittedByTime(db *gorm.DB, userID uint, begin time.Time, end time.Time) (bool, error)
+	// EachSubmission 逐行读取符合条件的提交并调用fn，不加载代码和用例数据，fn返回错误时停止
+	EachSubmission(db *gorm.DB, submission *request.SubmissionForList, order string, fn func(*repository.Submission) error) error
+	// GetUserSubmissionActivities 按日期汇总用户的所有提交数，日期按IANA时区timezone计算，为空时使用数据库会话的时区
+	GetUserSubmissionActivities(db *gorm.DB, userID uint, timezone string) ([]*repository.SubmissionActivity, error)
 	// InsertSubmission 插入提交记录
 	InsertSubmission(db *gorm.DB, submission *repository.Submission) error
+	// CheckUserAcceptedProblem 检验用户在某次提交之前是否已经通过了题目，beforeID为0时不限制提交
+	CheckUserAcceptedProblem(db *gorm.DB, userID uint, problemID uint, beforeID uint) (bool, error)
+	// CheckReferenceSolutionAccepted 检验题目题解中的参考代码是否有通过的提交，按语言和代码完全相同匹配，已归档的提交不参与匹配
+	CheckReferenceSolutionAccepted(db *gorm.DB, problemID uint) (bool, error)
+	// GetSubmissionAttemptSummaries 按用户和题目汇总提交次数，只统计最终判题结果，LastSubmissionID为最后一次提交的id，
+	// 按(user_id, problem_id)升序返回排在(afterUserID, afterProblemID)之后的limit组
+	GetSubmissionAttemptSummaries(db *gorm.DB, afterUserID uint, afterProblemID uint, limit int) ([]*repository.ProblemAttempt, error)
+	// GetUserLanguageStatistics 按语言统计用户的提交数和通过数，只统计最终判题结果
+	GetUserLanguageStatistics(db *gorm.DB, userID uint) ([]*repository.SubmissionStatistic, error)
+	// GetUserDailyStatistics 按天统计用户从begin开始的提交数和通过数，Name为服务器时区的日期
+	GetUserDailyStatistics(db *gorm.DB, userID uint, begin time.Time) ([]*repository.SubmissionStatistic, error)
+	// GetUserFirstVerdictCount 获取用户提交过的题目数，以及其中第一次提交就通过的题目数
+	GetUserFirstVerdictCount(db *gorm.DB, userID uint) (int64, int64, error)
+	// GetUserAttemptsToAccept 获取用户通过的题目数，以及这些题目第一次通过前（含）的提交次数之和
+	GetUserAttemptsToAccept(db *gorm.DB, userID uint) (int64, int64, error)
+	// GetSubmissionCodes 批量获取提交的代码和语言
+	GetSubmissionCodes(db *gorm.DB, ids []uint) ([]*repository.Submission, error)
+	// GetArchivableSubmissions 获取created_at早于before且还未归档的提交，按id升序
+	GetArchivableSubmissions(db *gorm.DB, before time.Time, limit int) ([]*repository.Submission, error)
+	// ArchiveSubmission 记录提交的归档路径并清空已归档的字段，已归档的提交不会被修改
+	ArchiveSubmission(db *gorm.DB, id uint, archivePath string) error
+	// MarkSubmissionAttemptApplied 标记提交已计入做题情况，返回是否由本次调用标记，已标记过时返回false
+	MarkSubmissionAttemptApplied(db *gorm.DB, id uint) (bool, error)
 }
 
 type SubmissionDaoImpl struct {
@@ -36,58 +65,241 @@ func (dao *SubmissionDaoImpl) GetLastSubmission(db *gorm.DB, userID uint, proble
 	return submission, err
 }
 
+func (dao *SubmissionDaoImpl) GetSubmissionByID(db *gorm.DB, id uint) (*repository.Submission, error) {
+	submission := &repository.Submission{}
+	err := db.First(submission, id).Error
+	return submission, err
+}
+
+func (dao *SubmissionDaoImpl) GetSubmissionByShareCode(db *gorm.DB, shareCode string) (*repository.Submission, error) {
+	submission := &repository.Submission{}
+	err := db.Where("share_code = ?", shareCode).First(submission).Error
+	return submission, err
+}
+
+func (dao *SubmissionDaoImpl) UpdateSubmissionShareCode(db *gorm.DB, id uint, shareCode string) error {
+	return db.Model(&repository.Submission{}).Where("id = ?", id).Update("share_code", shareCode).Error
+}
+
 func (dao *SubmissionDaoImpl) GetSubmissionList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.Submission, error) {
 	var submission *request.SubmissionForList
 	if pageQuery.Query != nil {
 		submission = pageQuery.Query.(*request.SubmissionForList)
 	}
 	var submissions []*repository.Submission
-	if submission.UserID != 0 {
-		db = db.Where("user_id = ?", submission.UserID)
-	}
-	if submission.ProblemID != 0 {
-		db = db.Where("problem_id = ?", submission.ProblemID)
+	db = dao.applySubmissionFilter(db, submission)
+	if pageQuery.SortProperty != "" && pageQuery.SortRule != "" {
+		order := pageQuery.SortProperty + " " + pageQuery.SortRule
+		db = db.Order(order).Order("id " + pageQuery.SortRule)
 	}
 	offset := (pageQuery.Page - 1) * pageQuery.PageSize
-	err := db.Limit(pageQuery.PageSize).Offset(offset).Find(&submissions).Error
+	err := db.Omit(submissionLargeColumns...).Limit(pageQuery.PageSize).Offset(offset).Find(&submissions).Error
 	return submissions, err
 }
 
 func (dao *SubmissionDaoImpl) GetSubmissionCount(db *gorm.DB, submission *request.SubmissionForList) (int64, error) {
 	var count int64
-	if submission != nil && submission.UserID != 0 {
+	err := dao.applySubmissionFilter(db, submission).Model(&repository.Submission{}).Count(&count).Error
+	return count, err
+}
+
+func (dao *SubmissionDaoImpl) EachSubmission(db *gorm.DB, submission *request.SubmissionForList, order string, fn func(*repository.Submission) error) error {
+	db = dao.applySubmissionFilter(db, submission).Model(&repository.Submission{}).Omit(submissionLargeColumns...)
+	if order != "" {
+		db = db.Order(order)
+	}
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		item := &repository.Submission{}
+		if err = db.ScanRows(rows, item); err != nil {
+			return err
+		}
+		if err = fn(item); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// submissionLargeColumns 列表和导出中不需要的大字段
+var submissionLargeColumns = []string{"code", "workspace", "case_data", "expected_output", "user_output"}
+
+// applySubmissionFilter 添加提交的查询条件，用户名、题目编号和题单通过子查询过滤
+func (dao *SubmissionDaoImpl) applySubmissionFilter(db *gorm.DB, submission *request.SubmissionForList) *gorm.DB {
+	if submission == nil {
+		return db
+	}
+	if submission.ID != 0 {
+		db = db.Where("id = ?", submission.ID)
+	}
+	if submission.UserID != 0 {
 		db = db.Where("user_id = ?", submission.UserID)
 	}
-	if submission != nil && submission.ProblemID != 0 {
+	if submission.ProblemID != 0 {
 		db = db.Where("problem_id = ?", submission.ProblemID)
 	}
-	err := db.Model(&repository.Submission{}).Count(&count).Error
-	return count, err
+	if submission.Status != 0 {
+		db = db.Where("status = ?", submission.Status)
+	}
+	if submission.Language != "" {
+		db = db.Where("language = ?", submission.Language)
+	}
+	if submission.UserName != "" {
+		db = db.Where("user_id in (?)", db.Session(&gorm.Session{NewDB: true}).Model(&repository.SysUser{}).
+			Select("id").Where("user_name like ?", "%"+submission.UserName+"%"))
+	}
+	if submission.ProblemNumber != "" {
+		db = db.Where("problem_id in (?)", db.Session(&gorm.Session{NewDB: true}).Model(&repository.Problem{}).
+			Select("id").Where("number = ?", submission.ProblemNumber))
+	}
+	if submission.MenuID != 0 {
+		db = db.Where("problem_id in (?)", db.Session(&gorm.Session{NewDB: true}).
+			Table("problem_menu_association").Select("problem_id").Where("problem_menu_id = ?", submission.MenuID))
+	}
+	if !submission.StartTime.IsZero() {
+		db = db.Where("created_at >= ?", submission.StartTime)
+	}
+	if !submission.EndTime.IsZero() {
+		db = db.Where("created_at <= ?", submission.EndTime)
+	}
+	return db
+}
+
+func (dao *SubmissionDaoImpl) GetUserSubmissionActivities(db *gorm.DB, userID uint, timezone string) ([]*repository.SubmissionActivity, error) {
+	var activities []*repository.SubmissionActivity
+	date := gorm.Expr("date(created_at)")
+	if timezone != "" {
+		date = gorm.Expr("date(convert_tz(created_at, @@session.time_zone, ?))", timezone)
+	}
+	err := db.Model(&repository.Submission{}).
+		Select("ifnull(date_format(?, '%Y-%m-%d'), '') as date, count(*) as count", date).
+		Where("user_id = ?", userID).
+		Group("date").Order("date").Scan(&activities).Error
+	return activities, err
+}
+
+func (dao *SubmissionDaoImpl) InsertSubmission(db *gorm.DB, submission *repository.Submission) error {
+	return db.Create(submission).Error
+}
+
+func (dao *SubmissionDaoImpl) CheckUserAcceptedProblem(db *gorm.DB, userID uint, problemID uint, beforeID uint) (bool, error) {
+	var count int64
+	db = db.Model(&repository.Submission{}).
+		Where("user_id = ? and problem_id = ? and status = ?", userID, problemID, consts.Accepted)
+	if beforeID != 0 {
+		db = db.Where("id < ?", beforeID)
+	}
+	err := db.Limit(1).Count(&count).Error
+	return count != 0, err
+}
+
+func (dao *SubmissionDaoImpl) CheckReferenceSolutionAccepted(db *gorm.DB, problemID uint) (bool, error) {
+	codes := db.Model(&repository.ProblemSolutionCode{}).
+		Select("problem_solution_code.language, problem_solution_code.code").
+		Joins("join problem_solution on problem_solution.id = problem_solution_code.solution_id and problem_solution.deleted_at is null").
+		Where("problem_solution.problem_id = ?", problemID)
+	var count int64
+	err := db.Model(&repository.Submission{}).
+		Where("problem_id = ? and status = ? and (language, code) in (?)", problemID, consts.Accepted, codes).
+		Limit(1).Count(&count).Error
+	return count != 0, err
+}
+
+func (dao *SubmissionDaoImpl) GetSubmissionAttemptSummaries(db *gorm.DB, afterUserID uint, afterProblemID uint, limit int) ([]*repository.ProblemAttempt, error) {
+	var attempts []*repository.ProblemAttempt
+	err := db.Model(&repository.Submission{}).
+		Select("user_id, problem_id, count(*) as submission_count, "+
+			"sum(case when status = ? then 1 else 0 end) as success_count, "+
+			"sum(case when status <> ? then 1 else 0 end) as err_count, "+
+			"max(id) as last_submission_id", consts.Accepted, consts.Accepted).
+		Where("status in ? and (user_id, problem_id) > (?, ?)", consts.FinalVerdicts, afterUserID, afterProblemID).
+		Group("user_id, problem_id").Order("user_id, problem_id").
+		Limit(limit).Scan(&attempts).Error
+	return attempts, err
 }
 
-func (dao *SubmissionDaoImpl) GetUserSimpleSubmissionsByTime(db *gorm.DB, userID uint, begin time.Time, end time.Time) ([]*repository.Submission, error) {
+func (dao *SubmissionDaoImpl) GetSubmissionCodes(db *gorm.DB, ids []uint) ([]*repository.Submission, error) {
 	var submissions []*repository.Submission
-	err := db.Where("user_id = ? and created_at >= ? and created_at <= ?", userID, begin, end).
-		Select("created_at").Find(&submissions).Error
-	if err != nil {
-		return nil, err
+	if len(ids) == 0 {
+		return submissions, nil
 	}
+	err := db.Select("id", "code", "language", "archive_path").Where("id in ?", ids).Find(&submissions).Error
 	return submissions, err
 }
 
-func (dao *SubmissionDaoImpl) CheckUserIsSubmittedByTime(db *gorm.DB, userID uint, begin time.Time, end time.Time) (bool, error) {
-	submission := &repository.Submission{}
-	if err := db.Model(submission).Where("user_id = ?", userID).
-		Where("created_at >= ? and created_at <= ?", begin, end).Take(submission).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return false, nil
-		} else {
-			return false, err
-		}
-	}
-	return true, nil
+func (dao *SubmissionDaoImpl) GetArchivableSubmissions(db *gorm.DB, before time.Time, limit int) ([]*repository.Submission, error) {
+	var submissions []*repository.Submission
+	err := db.Select("id", "created_at", "code", "case_data", "expected_output", "user_output").
+		Where("created_at < ? and (archive_path is null or archive_path = '')", before).
+		Order("id").Limit(limit).Find(&submissions).Error
+	return submissions, err
 }
 
-func (dao *SubmissionDaoImpl) InsertSubmission(db *gorm.DB, submission *repository.Submission) error {
-	return db.Create(submission).Error
+func (dao *SubmissionDaoImpl) ArchiveSubmission(db *gorm.DB, id uint, archivePath string) error {
+	return db.Model(&repository.Submission{}).Where("id = ? and (archive_path is null or archive_path = '')", id).
+		UpdateColumns(map[string]interface{}{
+			"archive_path":    archivePath,
+			"code":            "",
+			"case_data":       "",
+			"expected_output": "",
+			"user_output":     "",
+		}).Error
+}
+
+func (dao *SubmissionDaoImpl) GetUserLanguageStatistics(db *gorm.DB, userID uint) ([]*repository.SubmissionStatistic, error) {
+	var statistics []*repository.SubmissionStatistic
+	err := db.Model(&repository.Submission{}).
+		Select("language as name, count(*) as count, sum(case when status = ? then 1 else 0 end) as accepted_count", consts.Accepted).
+		Where("user_id = ? and status in ?", userID, consts.FinalVerdicts).
+		Group("language").Order("count desc").Scan(&statistics).Error
+	return statistics, err
+}
+
+func (dao *SubmissionDaoImpl) GetUserDailyStatistics(db *gorm.DB, userID uint, begin time.Time) ([]*repository.SubmissionStatistic, error) {
+	var statistics []*repository.SubmissionStatistic
+	err := db.Model(&repository.Submission{}).
+		Select("date_format(created_at, '%Y-%m-%d') as name, count(*) as count, "+
+			"sum(case when status = ? then 1 else 0 end) as accepted_count", consts.Accepted).
+		Where("user_id = ? and status in ? and created_at >= ?", userID, consts.FinalVerdicts, begin).
+		Group("name").Order("name").Scan(&statistics).Error
+	return statistics, err
+}
+
+func (dao *SubmissionDaoImpl) GetUserFirstVerdictCount(db *gorm.DB, userID uint) (int64, int64, error) {
+	result := &struct {
+		Attempted int64
+		Accepted  int64
+	}{}
+	firstIDs := db.Model(&repository.Submission{}).Select("min(id)").
+		Where("user_id = ? and status in ?", userID, consts.FinalVerdicts).Group("problem_id")
+	err := db.Model(&repository.Submission{}).
+		Select("count(*) as attempted, coalesce(sum(case when status = ? then 1 else 0 end), 0) as accepted", consts.Accepted).
+		Where("id in (?)", firstIDs).Scan(result).Error
+	return result.Attempted, result.Accepted, err
+}
+
+func (dao *SubmissionDaoImpl) GetUserAttemptsToAccept(db *gorm.DB, userID uint) (int64, int64, error) {
+	result := &struct {
+		Solved   int64
+		Attempts int64
+	}{}
+	firstAccepted := db.Model(&repository.Submission{}).Select("problem_id, min(id) as accepted_id").
+		Where("user_id = ? and status = ?", userID, consts.Accepted).Group("problem_id")
+	err := db.Table("submission").
+		Joins("join (?) as first_accepted on first_accepted.problem_id = submission.problem_id", firstAccepted).
+		Select("count(distinct submission.problem_id) as solved, count(*) as attempts").
+		Where("submission.user_id = ? and submission.status in ? and submission.id <= first_accepted.accepted_id",
+			userID, consts.FinalVerdicts).
+		Where("submission.deleted_at is null").Scan(result).Error
+	return result.Solved, result.Attempts, err
+}
+
+func (dao *SubmissionDaoImpl) MarkSubmissionAttemptApplied(db *gorm.DB, id uint) (bool, error) {
+	result := db.Model(&repository.Submission{}).Where("id = ? and attempt_applied = ?", id, false).
+		UpdateColumn("attempt_applied", true)
+	return result.RowsAffected > 0, result.Error
 }
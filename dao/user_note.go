@@ -0,0 +1,107 @@
+package dao
+
+import (
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserNoteDao interface {
+	// GetUserNoteByID 根据id获取笔记
+	GetUserNoteByID(db *gorm.DB, id uint) (*repository.UserNote, error)
+	// GetUserNote 获取用户对题目或提交的笔记，submissionID为0时获取对题目的笔记
+	GetUserNote(db *gorm.DB, userID uint, problemID uint, submissionID uint) (*repository.UserNote, error)
+	// SaveUserNote 保存笔记，已存在时更新内容
+	SaveUserNote(db *gorm.DB, note *repository.UserNote) error
+	// DeleteUserNote 删除笔记
+	DeleteUserNote(db *gorm.DB, id uint) error
+	// GetUserNoteList 获取笔记列表，最近修改的在前
+	GetUserNoteList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.UserNote, error)
+	// GetUserNoteCount 获取笔记数
+	GetUserNoteCount(db *gorm.DB, note *request.UserNoteForList) (int64, error)
+	// EachUserNote 逐行读取用户的所有笔记并调用fn，按题目和提交排序，fn返回错误时停止
+	EachUserNote(db *gorm.DB, userID uint, fn func(*repository.UserNote) error) error
+}
+
+type UserNoteDaoImpl struct {
+}
+
+func NewUserNoteDao() UserNoteDao {
+	return &UserNoteDaoImpl{}
+}
+
+func (dao *UserNoteDaoImpl) GetUserNoteByID(db *gorm.DB, id uint) (*repository.UserNote, error) {
+	note := &repository.UserNote{}
+	err := db.First(note, id).Error
+	return note, err
+}
+
+func (dao *UserNoteDaoImpl) GetUserNote(db *gorm.DB, userID uint, problemID uint, submissionID uint) (*repository.UserNote, error) {
+	note := &repository.UserNote{}
+	err := db.Where("user_id = ? and problem_id = ? and submission_id = ?", userID, problemID, submissionID).
+		First(note).Error
+	return note, err
+}
+
+func (dao *UserNoteDaoImpl) SaveUserNote(db *gorm.DB, note *repository.UserNote) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "problem_id"}, {Name: "submission_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"content", "updated_at", "deleted_at"}),
+	}).Create(note).Error
+}
+
+func (dao *UserNoteDaoImpl) DeleteUserNote(db *gorm.DB, id uint) error {
+	// 硬删除，之后可以再次添加
+	return db.Unscoped().Delete(&repository.UserNote{}, id).Error
+}
+
+func (dao *UserNoteDaoImpl) GetUserNoteList(db *gorm.DB, pageQuery *request.PageQuery) ([]*repository.UserNote, error) {
+	var note *request.UserNoteForList
+	if pageQuery.Query != nil {
+		note = pageQuery.Query.(*request.UserNoteForList)
+	}
+	var notes []*repository.UserNote
+	offset := (pageQuery.Page - 1) * pageQuery.PageSize
+	err := dao.applyUserNoteFilter(db, note).Order("updated_at desc, id desc").
+		Offset(offset).Limit(pageQuery.PageSize).Find(&notes).Error
+	return notes, err
+}
+
+func (dao *UserNoteDaoImpl) GetUserNoteCount(db *gorm.DB, note *request.UserNoteForList) (int64, error) {
+	var count int64
+	err := dao.applyUserNoteFilter(db, note).Model(&repository.UserNote{}).Count(&count).Error
+	return count, err
+}
+
+func (dao *UserNoteDaoImpl) applyUserNoteFilter(db *gorm.DB, note *request.UserNoteForList) *gorm.DB {
+	if note != nil && note.UserID != 0 {
+		db = db.Where("user_id = ?", note.UserID)
+	}
+	if note != nil && note.ProblemID != 0 {
+		db = db.Where("problem_id = ?", note.ProblemID)
+	}
+	if note != nil && note.Keyword != "" {
+		db = db.Where("content like ?", "%"+note.Keyword+"%")
+	}
+	return db
+}
+
+func (dao *UserNoteDaoImpl) EachUserNote(db *gorm.DB, userID uint, fn func(*repository.UserNote) error) error {
+	db = db.Model(&repository.UserNote{}).Where("user_id = ?", userID).Order("problem_id, submission_id")
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		note := &repository.UserNote{}
+		if err = db.ScanRows(rows, note); err != nil {
+			return err
+		}
+		if err = fn(note); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -3,6 +3,7 @@ package db
 import (
 	"fmt"
 	"funoj-backend/config"
+	"funoj-backend/model/repository"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -17,5 +18,12 @@ func InitMysql(cfg *config.MySqlConfig) error {
 		cfg.User, cfg.Password, cfg.Host, cfg.Port, cfg.DB)
 	var err error
 	Mysql, err = gorm.Open(mysql.Open(dsn), &gorm.Config{})
-	return err
+	if err != nil {
+		return err
+	}
+	// 题单与题目的关联表中包含分组和顺序
+	if err = Mysql.SetupJoinTable(&repository.ProblemMenu{}, "Problems", &repository.ProblemMenuAssociation{}); err != nil {
+		return err
+	}
+	return Mysql.SetupJoinTable(&repository.Problem{}, "Menus", &repository.ProblemMenuAssociation{})
 }
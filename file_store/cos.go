@@ -27,6 +27,10 @@ func NewProblemCOS(config *config.COSConfig) Store {
 	return NewCOS(config, config.ProblemBucketName)
 }
 
+func NewArchiveCOS(config *config.COSConfig) Store {
+	return NewCOS(config, config.ArchiveBucketName)
+}
+
 func NewCOS(config *config.COSConfig, bucketName string) Store {
 	u, _ := url.Parse(fmt.Sprintf("http://%s-%s.cos.%s.myqcloud.com",
 		bucketName, config.AppID, config.Region))
@@ -235,6 +239,29 @@ func (c *cosStore) DeleteFolder(storePath string) error {
 	return nil
 }
 
+func (c *cosStore) CopyFolder(srcPath string, dstPath string) error {
+	// 获取指定前缀下的文件列表
+	options := &cos.BucketGetOptions{
+		Prefix: srcPath,
+	}
+	res, _, err := c.client.Bucket.Get(context.Background(), options)
+	if err != nil {
+		fmt.Println("Failed to get bucket:", err)
+		return err
+	}
+	host := c.client.BaseURL.BucketURL.Host
+	// 遍历文件列表并复制每个文件
+	for _, object := range res.Contents {
+		filePathInCos := strings.Replace(object.Key, srcPath, dstPath, 1)
+		_, _, copyErr := c.client.Object.Copy(context.Background(), filePathInCos, host+"/"+object.Key, nil)
+		if copyErr != nil {
+			fmt.Println("Failed to copy file:", copyErr)
+			return copyErr
+		}
+	}
+	return nil
+}
+
 func (c *cosStore) UploadFolder(storePath string, localPath string) {
 
 	// 对每个文件进行上传
@@ -17,4 +17,6 @@ type Store interface {
 	UploadFolder(storePath string, localPath string)
 	// DeleteFolder 删除文件夹
 	DeleteFolder(storePath string) error
+	// CopyFolder 在对象存储内复制文件夹，srcPath下的文件复制到dstPath下
+	CopyFolder(srcPath string, dstPath string) error
 }
@@ -17,6 +17,8 @@ type AccountInfo struct {
 	Gender       int    `json:"gender"`
 	BirthDay     string `json:"birthDay"`
 	CodingAge    int    `json:"codingAge"`
+	// 做题统计
+	Statistic *AccountStatistic `json:"statistic"`
 }
 
 func NewAccountInfo(user *repository.SysUser) *AccountInfo {
@@ -32,3 +34,34 @@ func NewAccountInfo(user *repository.SysUser) *AccountInfo {
 		CodingAge:    time.Now().Year() - user.CreatedAt.Year(),
 	}
 }
+
+// AccountStatistic 用户的做题统计
+type AccountStatistic struct {
+	// 通过的题目数
+	SolvedCount int64 `json:"solvedCount"`
+	// 按难度1-5统计的通过题目数，下标0为难度1
+	SolvedByDifficulty []int64          `json:"solvedByDifficulty"`
+	SolvedByTag        []*StatisticItem `json:"solvedByTag"`
+	SolvedByMenu       []*StatisticItem `json:"solvedByMenu"`
+	// 各语言的提交数和通过数
+	Languages []*VerdictStatistic `json:"languages"`
+	// 第一次提交就通过的题目占提交过的题目的比例
+	FirstTryAcceptRate float64 `json:"firstTryAcceptRate"`
+	// 通过的题目平均提交几次才通过
+	AverageAttemptsToAccept float64 `json:"averageAttemptsToAccept"`
+	// 最近每天的提交数和通过数，Name为日期
+	RecentVerdicts []*VerdictStatistic `json:"recentVerdicts"`
+	// 通过题目数超过了百分之多少的用户
+	Percentile float64 `json:"percentile"`
+}
+
+type StatisticItem struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+type VerdictStatistic struct {
+	Name          string `json:"name"`
+	Count         int64  `json:"count"`
+	AcceptedCount int64  `json:"acceptedCount"`
+}
@@ -4,3 +4,12 @@ type ActivityItem struct {
 	Date  string `json:"date"`
 	Count int    `json:"count"`
 }
+
+// ActivityMapDto 活动图和连续提交天数，日期为用户所在时区
+type ActivityMapDto struct {
+	Items []*ActivityItem `json:"items"`
+	// 截至今天（今天还未提交时截至昨天）连续提交的天数
+	CurrentStreak int `json:"currentStreak"`
+	// 最长连续提交的天数
+	LongestStreak int `json:"longestStreak"`
+}
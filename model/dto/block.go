@@ -0,0 +1,24 @@
+package dto
+
+import "funoj-backend/model/repository"
+
+// BlockProgramDto 积木程序及翻译后的代码
+type BlockProgramDto struct {
+	SubmissionID uint   `json:"submissionID,omitempty"`
+	ProblemID    uint   `json:"problemID"`
+	SourceType   int    `json:"sourceType"`
+	Workspace    string `json:"workspace"`
+	Language     string `json:"language"`
+	Code         string `json:"code"`
+}
+
+func NewBlockProgramDto(submission *repository.Submission) *BlockProgramDto {
+	return &BlockProgramDto{
+		SubmissionID: submission.ID,
+		ProblemID:    submission.ProblemID,
+		SourceType:   submission.SourceType,
+		Workspace:    submission.Workspace,
+		Language:     submission.Language,
+		Code:         submission.Code,
+	}
+}
@@ -0,0 +1,21 @@
+package dto
+
+import (
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
+)
+
+// CodeDraftSnapshotDto 草稿的历史版本
+type CodeDraftSnapshotDto struct {
+	Version   int        `json:"version"`
+	Code      string     `json:"code"`
+	CreatedAt utils.Time `json:"createdAt"`
+}
+
+func NewCodeDraftSnapshotDto(snapshot *repository.CodeDraftSnapshot) *CodeDraftSnapshotDto {
+	return &CodeDraftSnapshotDto{
+		Version:   snapshot.Version,
+		Code:      snapshot.Code,
+		CreatedAt: utils.Time(snapshot.CreatedAt),
+	}
+}
@@ -0,0 +1,49 @@
+package dto
+
+// DebugRequest 客户端发送的调试消息
+type DebugRequest struct {
+	// 消息类型，见 consts.DebugMsgStart 等
+	Type string `json:"type"`
+	// 客户端生成的序号，原样返回，用于对应请求和响应
+	Seq int `json:"seq"`
+	// start 的语言、代码、标准输入和初始断点
+	Language    string `json:"language,omitempty"`
+	Code        string `json:"code,omitempty"`
+	Input       string `json:"input,omitempty"`
+	Breakpoints []int  `json:"breakpoints,omitempty"`
+	// break、clear 的行号
+	Line int `json:"line,omitempty"`
+	// locals 的栈帧，0为当前栈帧
+	Frame int `json:"frame,omitempty"`
+}
+
+// DebugResponse 服务端返回的调试消息，不同的消息使用不同的字段
+type DebugResponse struct {
+	// 消息类型，见 consts.DebugMsgReady 等
+	Type string `json:"type"`
+	Seq  int    `json:"seq,omitempty"`
+	// 程序暂停的位置
+	Location    *DebugFrame      `json:"location,omitempty"`
+	Frames      []*DebugFrame    `json:"frames,omitempty"`
+	Variables   []*DebugVariable `json:"variables,omitempty"`
+	Breakpoints []int            `json:"breakpoints,omitempty"`
+	// 程序输出或编译信息
+	Output   string `json:"output,omitempty"`
+	Message  string `json:"message,omitempty"`
+	ExitCode *int   `json:"exitCode,omitempty"`
+}
+
+// DebugFrame 调用栈中的一帧
+type DebugFrame struct {
+	Level    int    `json:"level"`
+	FuncName string `json:"funcName"`
+	// 用户代码之外的函数没有行号
+	Line int `json:"line"`
+}
+
+// DebugVariable 栈帧中的变量
+type DebugVariable struct {
+	Name  string `json:"name"`
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value"`
+}
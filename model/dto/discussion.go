@@ -0,0 +1,96 @@
+package dto
+
+import (
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
+)
+
+// DiscussionDtoForList 讨论帖列表
+type DiscussionDtoForList struct {
+	ID           uint       `json:"id"`
+	ProblemID    uint       `json:"problemID"`
+	Title        string     `json:"title"`
+	UserName     string     `json:"userName"`
+	Spoiler      bool       `json:"spoiler"`
+	Pinned       bool       `json:"pinned"`
+	UpvoteCount  int        `json:"upvoteCount"`
+	CommentCount int        `json:"commentCount"`
+	CreatedAt    utils.Time `json:"createdAt"`
+}
+
+func NewDiscussionDtoForList(discussion *repository.Discussion) *DiscussionDtoForList {
+	return &DiscussionDtoForList{
+		ID:           discussion.ID,
+		ProblemID:    discussion.ProblemID,
+		Title:        discussion.Title,
+		Spoiler:      discussion.Spoiler,
+		Pinned:       discussion.Pinned,
+		UpvoteCount:  discussion.UpvoteCount,
+		CommentCount: discussion.CommentCount,
+		CreatedAt:    utils.Time(discussion.CreatedAt),
+	}
+}
+
+// DiscussionDto 讨论帖详细信息
+type DiscussionDto struct {
+	ID           uint       `json:"id"`
+	ProblemID    uint       `json:"problemID"`
+	UserID       uint       `json:"userID"`
+	UserName     string     `json:"userName"`
+	Title        string     `json:"title"`
+	Content      string     `json:"content"`
+	Spoiler      bool       `json:"spoiler"`
+	Pinned       bool       `json:"pinned"`
+	UpvoteCount  int        `json:"upvoteCount"`
+	CommentCount int        `json:"commentCount"`
+	CreatedAt    utils.Time `json:"createdAt"`
+	UpdatedAt    utils.Time `json:"updatedAt"`
+}
+
+func NewDiscussionDto(discussion *repository.Discussion) *DiscussionDto {
+	return &DiscussionDto{
+		ID:           discussion.ID,
+		ProblemID:    discussion.ProblemID,
+		UserID:       discussion.UserID,
+		Title:        discussion.Title,
+		Content:      discussion.Content,
+		Spoiler:      discussion.Spoiler,
+		Pinned:       discussion.Pinned,
+		UpvoteCount:  discussion.UpvoteCount,
+		CommentCount: discussion.CommentCount,
+		CreatedAt:    utils.Time(discussion.CreatedAt),
+		UpdatedAt:    utils.Time(discussion.UpdatedAt),
+	}
+}
+
+// DiscussionCommentDto 讨论帖回复，一级回复中包含其下的所有回复
+type DiscussionCommentDto struct {
+	ID          uint       `json:"id"`
+	RootID      uint       `json:"rootID"`
+	ParentID    uint       `json:"parentID"`
+	UserID      uint       `json:"userID"`
+	UserName    string     `json:"userName"`
+	Content     string     `json:"content"`
+	Spoiler     bool       `json:"spoiler"`
+	UpvoteCount int        `json:"upvoteCount"`
+	CreatedAt   utils.Time `json:"createdAt"`
+	// 是否已删除，已删除的回复不返回内容
+	Deleted bool `json:"deleted"`
+	// 是否因为剧透而隐藏内容
+	Hidden  bool                    `json:"hidden"`
+	Replies []*DiscussionCommentDto `json:"replies"`
+}
+
+func NewDiscussionCommentDto(comment *repository.DiscussionComment) *DiscussionCommentDto {
+	return &DiscussionCommentDto{
+		ID:          comment.ID,
+		RootID:      comment.RootID,
+		ParentID:    comment.ParentID,
+		UserID:      comment.UserID,
+		Content:     comment.Content,
+		Spoiler:     comment.Spoiler,
+		UpvoteCount: comment.UpvoteCount,
+		CreatedAt:   utils.Time(comment.CreatedAt),
+		Deleted:     comment.DeletedAt.Valid,
+	}
+}
@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
+)
+
+// MistakeNoteDto 错题本中的一道题目
+type MistakeNoteDto struct {
+	ProblemID     uint   `json:"problemID"`
+	ProblemNumber string `json:"problemNumber"`
+	ProblemName   string `json:"problemName"`
+	Note          string `json:"note"`
+	// 当前的复习间隔，天
+	ReviewInterval int        `json:"reviewInterval"`
+	Repetitions    int        `json:"repetitions"`
+	DueAt          utils.Time `json:"dueAt"`
+	// 从未复习过时为空
+	LastReviewedAt *utils.Time `json:"lastReviewedAt"`
+	CreatedAt      utils.Time  `json:"createdAt"`
+}
+
+func NewMistakeNoteDto(note *repository.MistakeNote) *MistakeNoteDto {
+	answer := &MistakeNoteDto{
+		ProblemID:      note.ProblemID,
+		Note:           note.Note,
+		ReviewInterval: note.ReviewInterval,
+		Repetitions:    note.Repetitions,
+		DueAt:          utils.Time(note.DueAt),
+		CreatedAt:      utils.Time(note.CreatedAt),
+	}
+	if note.LastReviewedAt != nil {
+		lastReviewedAt := utils.Time(*note.LastReviewedAt)
+		answer.LastReviewedAt = &lastReviewedAt
+	}
+	return answer
+}
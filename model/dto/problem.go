@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"funoj-backend/consts"
 	"funoj-backend/model/repository"
 	"funoj-backend/utils"
 )
@@ -16,7 +17,17 @@ type ProblemDtoForGet struct {
 	Difficulty  int    `json:"difficulty"`
 	// 支持的语言用,分割
 	Languages string `json:"languages"`
-	Enable    int    `json:"enable"`
+	// 支持的语言及其时间、内存限制倍率
+	LanguageSettings []*ProblemLanguageDto `json:"languageSettings"`
+	Enable           int                   `json:"enable"`
+	// 发布流程状态
+	Status int `json:"status"`
+	// 提交统计
+	Statistic *ProblemStatisticDto `json:"statistic"`
+	// 各个语言的模板代码
+	Templates []*ProblemTemplateDto `json:"templates"`
+	// 样例，隐藏用例不会返回
+	Samples []*ProblemSampleDto `json:"samples"`
 }
 
 func NewProblemDtoForGet(problem *repository.Problem) *ProblemDtoForGet {
@@ -29,6 +40,7 @@ func NewProblemDtoForGet(problem *repository.Problem) *ProblemDtoForGet {
 		Difficulty:  problem.Difficulty,
 		Languages:   problem.Languages,
 		Enable:      problem.Enable,
+		Status:      GetProblemStatus(problem),
 	}
 	return response
 }
@@ -44,6 +56,11 @@ type ProblemDtoForList struct {
 	Path       string     `json:"path"`
 	Difficulty int        `json:"difficulty"`
 	Enable     int        `json:"enable"`
+	Status     int        `json:"status"`
+	// 通过率，百分比
+	AcceptanceRate float64 `json:"acceptanceRate"`
+	// 通过人数
+	SolverCount int64 `json:"solverCount"`
 }
 
 func NewProblemDtoForList(problem *repository.Problem) *ProblemDtoForList {
@@ -56,6 +73,7 @@ func NewProblemDtoForList(problem *repository.Problem) *ProblemDtoForList {
 		Title:      problem.Title,
 		Difficulty: problem.Difficulty,
 		Enable:     problem.Enable,
+		Status:     GetProblemStatus(problem),
 	}
 	return response
 }
@@ -70,6 +88,10 @@ type ProblemDtoForUserList struct {
 	Difficulty  int    `json:"difficulty"`
 	// 学生做题状态
 	Status int `json:"status"`
+	// 通过率，百分比
+	AcceptanceRate float64 `json:"acceptanceRate"`
+	// 通过人数
+	SolverCount int64 `json:"solverCount"`
 }
 
 func NewProblemDtoForUserList(problem *repository.Problem) *ProblemDtoForUserList {
@@ -82,3 +104,14 @@ func NewProblemDtoForUserList(problem *repository.Problem) *ProblemDtoForUserLis
 		Difficulty:  problem.Difficulty,
 	}
 }
+
+// GetProblemStatus 获取题目的发布流程状态，旧数据没有状态，启用的视为已发布，其余视为草稿
+func GetProblemStatus(problem *repository.Problem) int {
+	if problem.Status != 0 {
+		return problem.Status
+	}
+	if problem.Enable == 1 {
+		return consts.ProblemStatusPublished
+	}
+	return consts.ProblemStatusDraft
+}
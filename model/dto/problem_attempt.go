@@ -0,0 +1,11 @@
+package dto
+
+// ProblemAttemptRepairDto 重新计算用户做题情况的结果
+type ProblemAttemptRepairDto struct {
+	// 重新计算的记录数
+	Updated int `json:"updated"`
+	// 没有提交而被删除的记录数
+	Deleted int64 `json:"deleted"`
+	// 耗时，毫秒
+	Duration int64 `json:"duration"`
+}
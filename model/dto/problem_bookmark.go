@@ -0,0 +1,28 @@
+package dto
+
+import (
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
+)
+
+// ProblemBookmarkDto 用户收藏的题目
+type ProblemBookmarkDto struct {
+	ProblemID  uint       `json:"problemID"`
+	Number     string     `json:"number"`
+	Name       string     `json:"name"`
+	Title      string     `json:"title"`
+	Difficulty int        `json:"difficulty"`
+	Status     int        `json:"status"`
+	CreatedAt  utils.Time `json:"createdAt"`
+}
+
+func NewProblemBookmarkDto(bookmark *repository.ProblemBookmark, problem *repository.Problem) *ProblemBookmarkDto {
+	return &ProblemBookmarkDto{
+		ProblemID:  bookmark.ProblemID,
+		Number:     problem.Number,
+		Name:       problem.Name,
+		Title:      problem.Title,
+		Difficulty: problem.Difficulty,
+		CreatedAt:  utils.Time(bookmark.CreatedAt),
+	}
+}
@@ -10,6 +10,7 @@ type ProblemCaseDtoForList struct {
 	CaseName  string     `json:"caseName"`
 	Input     string     `json:"input"`
 	Output    string     `json:"output"`
+	Sample    bool       `json:"sample"`
 	CreatedAt utils.Time `json:"createdAt"`
 }
 
@@ -19,6 +20,7 @@ func NewProblemCaseDtoForList(problemCase *repository.ProblemCase) *ProblemCaseD
 		CaseName:  problemCase.CaseName,
 		Input:     problemCase.Input,
 		Output:    problemCase.Output,
+		Sample:    problemCase.Sample,
 		CreatedAt: utils.Time(problemCase.CreatedAt),
 	}
 }
@@ -28,6 +30,7 @@ type ProblemCaseDto struct {
 	CaseName string `json:"caseName"`
 	Input    string `json:"input"`
 	Output   string `json:"output"`
+	Sample   bool   `json:"sample"`
 }
 
 func NewProblemCaseDto(problemCase *repository.ProblemCase) *ProblemCaseDto {
@@ -36,5 +39,21 @@ func NewProblemCaseDto(problemCase *repository.ProblemCase) *ProblemCaseDto {
 		CaseName: problemCase.CaseName,
 		Input:    problemCase.Input,
 		Output:   problemCase.Output,
+		Sample:   problemCase.Sample,
+	}
+}
+
+// ProblemSampleDto 题目样例，在题目详情中展示
+type ProblemSampleDto struct {
+	CaseName string `json:"caseName"`
+	Input    string `json:"input"`
+	Output   string `json:"output"`
+}
+
+func NewProblemSampleDto(problemCase *repository.ProblemCase) *ProblemSampleDto {
+	return &ProblemSampleDto{
+		CaseName: problemCase.CaseName,
+		Input:    problemCase.Input,
+		Output:   problemCase.Output,
 	}
 }
@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
+)
+
+// UserProblemHintDto 用户查看的提示，未解锁时不返回内容
+type UserProblemHintDto struct {
+	ID      uint   `json:"id"`
+	Sort    int    `json:"sort"`
+	Content string `json:"content"`
+	Locked  bool   `json:"locked"`
+	// 是否为主动解锁
+	Manual         bool `json:"manual"`
+	UnlockErrCount int  `json:"unlockErrCount"`
+	// 距离自动解锁还需要的错误提交次数，0表示不会自动解锁或已解锁
+	RemainingErrCount int `json:"remainingErrCount"`
+	Penalty           int `json:"penalty"`
+}
+
+func NewUserProblemHintDto(hint *repository.ProblemHint) *UserProblemHintDto {
+	return &UserProblemHintDto{
+		ID:             hint.ID,
+		Sort:           hint.Sort,
+		Locked:         true,
+		UnlockErrCount: hint.UnlockErrCount,
+		Penalty:        hint.Penalty,
+	}
+}
+
+// ProblemHintUnlockDto 提示解锁记录
+type ProblemHintUnlockDto struct {
+	ID        uint       `json:"id"`
+	HintID    uint       `json:"hintID"`
+	ProblemID uint       `json:"problemID"`
+	UserID    uint       `json:"userID"`
+	UserName  string     `json:"userName"`
+	Manual    bool       `json:"manual"`
+	Penalty   int        `json:"penalty"`
+	ErrCount  int        `json:"errCount"`
+	CreatedAt utils.Time `json:"createdAt"`
+}
+
+func NewProblemHintUnlockDto(unlock *repository.ProblemHintUnlock) *ProblemHintUnlockDto {
+	return &ProblemHintUnlockDto{
+		ID:        unlock.ID,
+		HintID:    unlock.HintID,
+		ProblemID: unlock.ProblemID,
+		UserID:    unlock.UserID,
+		Manual:    unlock.Manual,
+		Penalty:   unlock.Penalty,
+		ErrCount:  unlock.ErrCount,
+		CreatedAt: utils.Time(unlock.CreatedAt),
+	}
+}
@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"funoj-backend/model/repository"
+	"time"
+)
+
+// ProblemLanguageDto 题目允许的语言及其限制倍率
+type ProblemLanguageDto struct {
+	Language         string  `json:"language"`
+	TimeMultiplier   float64 `json:"timeMultiplier"`
+	MemoryMultiplier float64 `json:"memoryMultiplier"`
+}
+
+func NewProblemLanguageDto(language *repository.ProblemLanguage) *ProblemLanguageDto {
+	return &ProblemLanguageDto{
+		Language:         language.Language,
+		TimeMultiplier:   language.TimeMultiplier,
+		MemoryMultiplier: language.MemoryMultiplier,
+	}
+}
+
+// GetTimeLimit 根据倍率计算该语言实际的时间限制
+func (p *ProblemLanguageDto) GetTimeLimit(limit time.Duration) time.Duration {
+	return time.Duration(float64(limit) * p.TimeMultiplier)
+}
+
+// GetMemoryLimit 根据倍率计算该语言实际的内存限制
+func (p *ProblemLanguageDto) GetMemoryLimit(limit int64) int64 {
+	return int64(float64(limit) * p.MemoryMultiplier)
+}
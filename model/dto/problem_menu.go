@@ -15,6 +15,10 @@ type ProblemMenuDtoForList struct {
 	UpdatedAt    utils.Time `json:"updatedAt"`
 	CreatorName  string     `json:"creatorName"`
 	ProblemCount int64      `json:"problemCount"`
+	Type         int        `json:"type"`
+	Visibility   int        `json:"visibility"`
+	// 分享码，只返回给题单创建者
+	ShareCode string `json:"shareCode,omitempty"`
 }
 
 func NewProblemMenuDtoForList(menu *repository.ProblemMenu) *ProblemMenuDtoForList {
@@ -25,6 +29,8 @@ func NewProblemMenuDtoForList(menu *repository.ProblemMenu) *ProblemMenuDtoForLi
 		Description: menu.Description,
 		CreatedAt:   utils.Time(menu.CreatedAt),
 		UpdatedAt:   utils.Time(menu.UpdatedAt),
+		Type:        menu.Type,
+		Visibility:  menu.Visibility,
 	}
 }
 
@@ -41,3 +47,55 @@ func NewProblemMenuDtoForSimpleList(menu *repository.ProblemMenu) *ProblemMenuDt
 	}
 	return response
 }
+
+// ProblemMenuItemDto 题单中的题目
+type ProblemMenuItemDto struct {
+	ProblemID  uint   `json:"problemID"`
+	Number     string `json:"number"`
+	Name       string `json:"name"`
+	Title      string `json:"title"`
+	Difficulty int    `json:"difficulty"`
+	Sort       int    `json:"sort"`
+	// 用户的做题状态，见 consts.AttemptStatusNotStarted 等
+	Status int `json:"status"`
+}
+
+func NewProblemMenuItemDto(item *repository.ProblemMenuAssociation, problem *repository.Problem) *ProblemMenuItemDto {
+	return &ProblemMenuItemDto{
+		ProblemID:  item.ProblemID,
+		Number:     problem.Number,
+		Name:       problem.Name,
+		Title:      problem.Title,
+		Difficulty: problem.Difficulty,
+		Sort:       item.Sort,
+	}
+}
+
+// ProblemMenuSectionDto 题单中的分组，ID为0表示未分组的题目
+type ProblemMenuSectionDto struct {
+	ID             uint                  `json:"id"`
+	Name           string                `json:"name"`
+	Sort           int                   `json:"sort"`
+	Problems       []*ProblemMenuItemDto `json:"problems"`
+	SolvedCount    int                   `json:"solvedCount"`
+	AttemptedCount int                   `json:"attemptedCount"`
+}
+
+// ProblemMenuProgressDto 用户在题单中的做题进度
+type ProblemMenuProgressDto struct {
+	MenuID       uint `json:"menuID"`
+	ProblemCount int  `json:"problemCount"`
+	SolvedCount  int  `json:"solvedCount"`
+	// 尝试过但未通过的题目数量
+	AttemptedCount int `json:"attemptedCount"`
+	// 按顺序第一道未通过的题目，全部通过时为空
+	NextProblem *ProblemMenuItemDto      `json:"nextProblem"`
+	Sections    []*ProblemMenuSectionDto `json:"sections"`
+}
+
+// ProblemMenuDetailDto 题单信息以及用户的做题进度
+type ProblemMenuDetailDto struct {
+	Menu     *ProblemMenuDtoForList  `json:"menu"`
+	Progress *ProblemMenuProgressDto `json:"progress"`
+	Followed bool                    `json:"followed"`
+}
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"encoding/json"
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
+)
+
+// ProblemChecklistDto 题目提交审核、审核通过前的检查清单
+type ProblemChecklistDto struct {
+	// 是否存在用例
+	HasCases bool `json:"hasCases"`
+	// 参考代码是否通过，即题目作者是否有通过的提交
+	ReferencePassed bool `json:"referencePassed"`
+	// 题目描述是否不为空
+	StatementNotEmpty bool `json:"statementNotEmpty"`
+}
+
+// Passed 检查清单是否全部通过
+func (c *ProblemChecklistDto) Passed() bool {
+	return c.HasCases && c.ReferencePassed && c.StatementNotEmpty
+}
+
+// ProblemReviewDto 题目状态变更记录
+type ProblemReviewDto struct {
+	ID           uint                 `json:"id"`
+	OperatorName string               `json:"operatorName"`
+	FromStatus   int                  `json:"fromStatus"`
+	ToStatus     int                  `json:"toStatus"`
+	Comment      string               `json:"comment"`
+	Checklist    *ProblemChecklistDto `json:"checklist"`
+	CreatedAt    utils.Time           `json:"createdAt"`
+}
+
+func NewProblemReviewDto(review *repository.ProblemReview) *ProblemReviewDto {
+	response := &ProblemReviewDto{
+		ID:         review.ID,
+		FromStatus: review.FromStatus,
+		ToStatus:   review.ToStatus,
+		Comment:    review.Comment,
+		CreatedAt:  utils.Time(review.CreatedAt),
+	}
+	if review.Checklist != "" {
+		checklist := &ProblemChecklistDto{}
+		if err := json.Unmarshal([]byte(review.Checklist), checklist); err == nil {
+			response.Checklist = checklist
+		}
+	}
+	return response
+}
@@ -0,0 +1,70 @@
+package dto
+
+import (
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
+)
+
+// ProblemSolutionDtoForList 题解列表
+type ProblemSolutionDtoForList struct {
+	ID          uint       `json:"id"`
+	ProblemID   uint       `json:"problemID"`
+	Title       string     `json:"title"`
+	AuthorName  string     `json:"authorName"`
+	VisibleType int        `json:"visibleType"`
+	VisibleAt   utils.Time `json:"visibleAt"`
+	CreatedAt   utils.Time `json:"createdAt"`
+	UpdatedAt   utils.Time `json:"updatedAt"`
+}
+
+func NewProblemSolutionDtoForList(solution *repository.ProblemSolution) *ProblemSolutionDtoForList {
+	return &ProblemSolutionDtoForList{
+		ID:          solution.ID,
+		ProblemID:   solution.ProblemID,
+		Title:       solution.Title,
+		VisibleType: solution.VisibleType,
+		VisibleAt:   utils.Time(solution.VisibleAt),
+		CreatedAt:   utils.Time(solution.CreatedAt),
+		UpdatedAt:   utils.Time(solution.UpdatedAt),
+	}
+}
+
+// ProblemSolutionDto 题解详细信息
+type ProblemSolutionDto struct {
+	ID          uint                      `json:"id"`
+	ProblemID   uint                      `json:"problemID"`
+	Title       string                    `json:"title"`
+	Content     string                    `json:"content"`
+	AuthorName  string                    `json:"authorName"`
+	VisibleType int                       `json:"visibleType"`
+	VisibleAt   utils.Time                `json:"visibleAt"`
+	UpdatedAt   utils.Time                `json:"updatedAt"`
+	Codes       []*ProblemSolutionCodeDto `json:"codes"`
+	// 对当前用户是否未公开，未公开时不返回内容和代码
+	Locked bool `json:"locked"`
+}
+
+type ProblemSolutionCodeDto struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
+
+func NewProblemSolutionDto(solution *repository.ProblemSolution) *ProblemSolutionDto {
+	response := &ProblemSolutionDto{
+		ID:          solution.ID,
+		ProblemID:   solution.ProblemID,
+		Title:       solution.Title,
+		Content:     solution.Content,
+		VisibleType: solution.VisibleType,
+		VisibleAt:   utils.Time(solution.VisibleAt),
+		UpdatedAt:   utils.Time(solution.UpdatedAt),
+	}
+	response.Codes = make([]*ProblemSolutionCodeDto, len(solution.Codes))
+	for i, code := range solution.Codes {
+		response.Codes[i] = &ProblemSolutionCodeDto{
+			Language: code.Language,
+			Code:     code.Code,
+		}
+	}
+	return response
+}
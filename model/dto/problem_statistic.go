@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"funoj-backend/consts"
+	"funoj-backend/model/repository"
+	"math"
+	"strconv"
+)
+
+// ProblemStatisticDto 题目统计信息
+type ProblemStatisticDto struct {
+	SubmissionCount int64 `json:"submissionCount"`
+	AcceptedCount   int64 `json:"acceptedCount"`
+	SolverCount     int64 `json:"solverCount"`
+	// 通过率，百分比
+	AcceptanceRate float64 `json:"acceptanceRate"`
+	// 各个判题状态的提交数
+	StatusDistribution map[int]int64 `json:"statusDistribution"`
+	// 各个语言的提交数
+	LanguageDistribution map[string]int64 `json:"languageDistribution"`
+	// 通过的提交的耗时分位数，单位毫秒
+	TimePercentiles []*PercentileItem `json:"timePercentiles"`
+	// 通过的提交的内存分位数，单位字节
+	MemoryPercentiles []*PercentileItem `json:"memoryPercentiles"`
+}
+
+type PercentileItem struct {
+	Percentile int   `json:"percentile"`
+	Value      int64 `json:"value"`
+}
+
+func NewProblemStatisticDto(statistic *repository.ProblemStatistic, items []*repository.ProblemStatisticItem) *ProblemStatisticDto {
+	response := &ProblemStatisticDto{
+		SubmissionCount:      statistic.SubmissionCount,
+		AcceptedCount:        statistic.AcceptedCount,
+		SolverCount:          statistic.SolverCount,
+		AcceptanceRate:       GetAcceptanceRate(statistic.AcceptedCount, statistic.SubmissionCount),
+		StatusDistribution:   make(map[int]int64),
+		LanguageDistribution: make(map[string]int64),
+	}
+	for _, item := range items {
+		switch item.Category {
+		case consts.StatisticCategoryStatus:
+			status, err := strconv.Atoi(item.Item)
+			if err == nil {
+				response.StatusDistribution[status] = item.Count
+			}
+		case consts.StatisticCategoryLanguage:
+			response.LanguageDistribution[item.Item] = item.Count
+		}
+	}
+	return response
+}
+
+// GetAcceptanceRate 计算通过率，返回保留两位小数的百分比
+func GetAcceptanceRate(acceptedCount int64, submissionCount int64) float64 {
+	if submissionCount == 0 {
+		return 0
+	}
+	return math.Round(float64(acceptedCount)*10000/float64(submissionCount)) / 100
+}
@@ -0,0 +1,26 @@
+package dto
+
+import (
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
+)
+
+// ProblemTemplateDto 题目某个语言的模板代码
+type ProblemTemplateDto struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+	Version  int    `json:"version"`
+	// 是否为全局默认模板
+	IsDefault bool       `json:"isDefault"`
+	CreatedAt utils.Time `json:"createdAt"`
+}
+
+func NewProblemTemplateDto(template *repository.ProblemTemplate) *ProblemTemplateDto {
+	return &ProblemTemplateDto{
+		Language:  template.Language,
+		Code:      template.Code,
+		Version:   template.Version,
+		IsDefault: template.ProblemID == 0,
+		CreatedAt: utils.Time(template.CreatedAt),
+	}
+}
@@ -8,6 +8,7 @@ import (
 type SubmissionDto struct {
 	ID           uint       `json:"id"`
 	ProblemName  string     `json:"problemName"`
+	Language     string     `json:"language"`
 	Status       int        `json:"status"`
 	ErrorMessage string     `json:"errorMessage"`
 	CreatedAt    utils.Time `json:"createdAt"`
@@ -16,8 +17,120 @@ type SubmissionDto struct {
 func NewSubmissionDto(submission *repository.Submission) *SubmissionDto {
 	return &SubmissionDto{
 		ID:           submission.ID,
+		Language:     submission.Language,
 		Status:       submission.Status,
 		ErrorMessage: submission.ErrorMessage,
 		CreatedAt:    utils.Time(submission.CreatedAt),
 	}
 }
+
+// SubmissionDetailDto 提交详情，失败用例不是样例时非管理员看不到用例数据
+type SubmissionDetailDto struct {
+	ID          uint   `json:"id"`
+	ProblemID   uint   `json:"problemID"`
+	ProblemName string `json:"problemName"`
+	Language    string `json:"language"`
+	Code        string `json:"code"`
+	SourceType  int    `json:"sourceType"`
+	Status      int    `json:"status"`
+	// 失败的用例
+	ErrorMessage   string `json:"errorMessage"`
+	CaseName       string `json:"caseName"`
+	CaseData       string `json:"caseData"`
+	ExpectedOutput string `json:"expectedOutput"`
+	// 去掉可视化指令行后的输出
+	UserOutput string `json:"userOutput"`
+	// 输出中的可视化指令，没有指令时为空
+	Timeline *VisualTimeline `json:"timeline,omitempty"`
+	// 耗时，毫秒
+	TimeUsed   int64      `json:"timeUsed"`
+	MemoryUsed int64      `json:"memoryUsed"`
+	CreatedAt  utils.Time `json:"createdAt"`
+	// 分享码，只返回给提交者
+	ShareCode string `json:"shareCode,omitempty"`
+	// 提交者的笔记，只返回给提交者
+	Note string `json:"note,omitempty"`
+}
+
+func NewSubmissionDetailDto(submission *repository.Submission) *SubmissionDetailDto {
+	return &SubmissionDetailDto{
+		ID:             submission.ID,
+		ProblemID:      submission.ProblemID,
+		Language:       submission.Language,
+		Code:           submission.Code,
+		SourceType:     submission.SourceType,
+		Status:         submission.Status,
+		ErrorMessage:   submission.ErrorMessage,
+		CaseName:       submission.CaseName,
+		CaseData:       submission.CaseData,
+		ExpectedOutput: submission.ExpectedOutput,
+		UserOutput:     submission.UserOutput,
+		TimeUsed:       submission.TimeUsed.Milliseconds(),
+		MemoryUsed:     submission.MemoryUsed,
+		CreatedAt:      utils.Time(submission.CreatedAt),
+	}
+}
+
+// SharedSubmissionDto 分享的提交，只包含代码和判题结果
+type SharedSubmissionDto struct {
+	ProblemID   uint       `json:"problemID"`
+	ProblemName string     `json:"problemName"`
+	UserName    string     `json:"userName"`
+	Language    string     `json:"language"`
+	Code        string     `json:"code"`
+	Status      int        `json:"status"`
+	TimeUsed    int64      `json:"timeUsed"`
+	MemoryUsed  int64      `json:"memoryUsed"`
+	CreatedAt   utils.Time `json:"createdAt"`
+}
+
+func NewSharedSubmissionDto(submission *repository.Submission) *SharedSubmissionDto {
+	return &SharedSubmissionDto{
+		ProblemID:  submission.ProblemID,
+		Language:   submission.Language,
+		Code:       submission.Code,
+		Status:     submission.Status,
+		TimeUsed:   submission.TimeUsed.Milliseconds(),
+		MemoryUsed: submission.MemoryUsed,
+		CreatedAt:  utils.Time(submission.CreatedAt),
+	}
+}
+
+// SubmissionDiffDto 两次提交的代码对比
+type SubmissionDiffDto struct {
+	Old *SubmissionDto `json:"old"`
+	New *SubmissionDto `json:"new"`
+	// 新增和删除的行数
+	Added   int               `json:"added"`
+	Removed int               `json:"removed"`
+	Lines   []*utils.DiffLine `json:"lines"`
+}
+
+// SubmissionDtoForAdmin 管理员查看的提交列表，也用于导出
+type SubmissionDtoForAdmin struct {
+	ID            uint   `json:"id"`
+	UserID        uint   `json:"userID"`
+	UserName      string `json:"userName"`
+	ProblemID     uint   `json:"problemID"`
+	ProblemNumber string `json:"problemNumber"`
+	ProblemName   string `json:"problemName"`
+	Language      string `json:"language"`
+	Status        int    `json:"status"`
+	// 耗时，毫秒
+	TimeUsed   int64      `json:"timeUsed"`
+	MemoryUsed int64      `json:"memoryUsed"`
+	CreatedAt  utils.Time `json:"createdAt"`
+}
+
+func NewSubmissionDtoForAdmin(submission *repository.Submission) *SubmissionDtoForAdmin {
+	return &SubmissionDtoForAdmin{
+		ID:         submission.ID,
+		UserID:     submission.UserID,
+		ProblemID:  submission.ProblemID,
+		Language:   submission.Language,
+		Status:     submission.Status,
+		TimeUsed:   submission.TimeUsed.Milliseconds(),
+		MemoryUsed: submission.MemoryUsed,
+		CreatedAt:  utils.Time(submission.CreatedAt),
+	}
+}
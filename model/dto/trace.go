@@ -0,0 +1,54 @@
+package dto
+
+// ExecutionTrace 可视化执行的轨迹，前端按步骤播放
+type ExecutionTrace struct {
+	Language string       `json:"language"`
+	Steps    []*TraceStep `json:"steps"`
+	// 编译失败时的编译信息，此时没有步骤
+	CompileOutput string `json:"compileOutput,omitempty"`
+	// 程序是否执行结束，截断时为false
+	Exited   bool `json:"exited"`
+	ExitCode int  `json:"exitCode"`
+	// 是否因为步数、大小或时间超过限制被截断
+	Truncated bool `json:"truncated"`
+	// 截断原因，见 consts.TraceTruncatedSteps 等
+	TruncatedReason string `json:"truncatedReason,omitempty"`
+}
+
+// TraceStep 执行中的一步，在执行这一行之前暂停时记录
+type TraceStep struct {
+	Line     int    `json:"line"`
+	Event    string `json:"event"`
+	FuncName string `json:"funcName"`
+	// 上一步之后新增的输出，c程序的标准输出有缓冲，输出可能出现在之后的步骤中
+	Stdout string `json:"stdout,omitempty"`
+	// 调用栈，栈底在前，只包含用户代码中的栈帧
+	Stack []*TraceFrame `json:"stack"`
+	// 从局部变量中的指针能访问到的堆对象，key为对象地址
+	Heap map[string]*TraceHeapObject `json:"heap,omitempty"`
+	// 堆对象数量超过限制，没有全部读取
+	HeapTruncated bool   `json:"heapTruncated,omitempty"`
+	ExceptionMsg  string `json:"exceptionMsg,omitempty"`
+}
+
+// TraceFrame 调用栈中的一帧
+type TraceFrame struct {
+	FuncName string        `json:"funcName"`
+	Line     int           `json:"line"`
+	Locals   []*TraceValue `json:"locals"`
+}
+
+// TraceValue 局部变量或堆对象的字段，值为调试器格式化后的文本，非空指针的Ref为指向的堆对象地址
+type TraceValue struct {
+	Name  string `json:"name"`
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value"`
+	Ref   string `json:"ref,omitempty"`
+}
+
+// TraceHeapObject 指针指向的对象，结构体按字段展开，其它类型只有值
+type TraceHeapObject struct {
+	Type   string        `json:"type"`
+	Value  string        `json:"value,omitempty"`
+	Fields []*TraceValue `json:"fields,omitempty"`
+}
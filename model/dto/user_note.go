@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
+)
+
+// UserNoteDto 用户的笔记，SubmissionID为0时是对整道题目的笔记
+type UserNoteDto struct {
+	ID            uint       `json:"id"`
+	ProblemID     uint       `json:"problemID"`
+	ProblemNumber string     `json:"problemNumber"`
+	ProblemName   string     `json:"problemName"`
+	SubmissionID  uint       `json:"submissionID"`
+	Content       string     `json:"content"`
+	UpdatedAt     utils.Time `json:"updatedAt"`
+}
+
+func NewUserNoteDto(note *repository.UserNote) *UserNoteDto {
+	return &UserNoteDto{
+		ID:           note.ID,
+		ProblemID:    note.ProblemID,
+		SubmissionID: note.SubmissionID,
+		Content:      note.Content,
+		UpdatedAt:    utils.Time(note.UpdatedAt),
+	}
+}
+
+// UserNoteExportDto 导出的笔记，提交的笔记附带当时的代码和结果
+type UserNoteExportDto struct {
+	*UserNoteDto
+	Language string `json:"language,omitempty"`
+	Status   int    `json:"status,omitempty"`
+	Code     string `json:"code,omitempty"`
+}
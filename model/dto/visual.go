@@ -0,0 +1,42 @@
+package dto
+
+import "encoding/json"
+
+// VisualTimeline 程序输出的可视化指令，按输出顺序排列
+type VisualTimeline struct {
+	Commands []*VisualCommand `json:"commands"`
+	// 是否因为数量或大小超过限制被截断
+	Truncated bool `json:"truncated"`
+	// 截断原因，见 consts.VisualTruncatedCount 等
+	TruncatedReason string `json:"truncatedReason,omitempty"`
+	// 被忽略的非法指令及原因，最多记录若干条
+	Errors []string `json:"errors,omitempty"`
+}
+
+// VisualCommand 一条可视化指令，不同的指令使用不同的字段
+type VisualCommand struct {
+	Seq    int    `json:"seq"`
+	Op     string `json:"op"`
+	Target string `json:"target"`
+	// array.init 的初始值
+	Values []json.RawMessage `json:"values,omitempty"`
+	// array.set、pointer.move 的下标
+	Index *int `json:"index,omitempty"`
+	// array.set 的新值
+	Value json.RawMessage `json:"value,omitempty"`
+	// array.swap 交换的两个下标
+	I *int `json:"i,omitempty"`
+	J *int `json:"j,omitempty"`
+	// array.highlight 高亮的下标
+	Indices []int `json:"indices,omitempty"`
+	// graph.node 的节点id，pointer.move 的指针名称
+	ID    string `json:"id,omitempty"`
+	Name  string `json:"name,omitempty"`
+	Label string `json:"label,omitempty"`
+	// 节点或边的状态，比如visited、current，由前端决定样式
+	State string `json:"state,omitempty"`
+	// graph.edge 的两个端点
+	From   string   `json:"from,omitempty"`
+	To     string   `json:"to,omitempty"`
+	Weight *float64 `json:"weight,omitempty"`
+}
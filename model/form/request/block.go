@@ -0,0 +1,9 @@
+package request
+
+// BlockProgram 积木程序
+type BlockProgram struct {
+	ProblemID uint `json:"problemID"`
+	// 工作区格式，见 consts.SourceTypeBlocklyXml 等
+	SourceType int    `json:"sourceType"`
+	Workspace  string `json:"workspace"`
+}
@@ -0,0 +1,13 @@
+package request
+
+type DiscussionForList struct {
+	ProblemID uint   `json:"problemID"`
+	UserID    uint   `json:"userID"`
+	Title     string `json:"title"`
+	// 是否包含剧透的帖子
+	WithSpoiler bool `json:"withSpoiler"`
+}
+
+type DiscussionCommentForList struct {
+	DiscussionID uint `json:"discussionID"`
+}
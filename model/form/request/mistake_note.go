@@ -0,0 +1,9 @@
+package request
+
+import "time"
+
+type MistakeNoteForList struct {
+	UserID uint `json:"userID"`
+	// 只查询在这个时间之前需要复习的题目，为零值时不限制
+	DueBefore time.Time `json:"dueBefore"`
+}
@@ -4,4 +4,6 @@ type ProblemCaseForList struct {
 	ID        uint   `json:"ID"`
 	ProblemID uint   `json:"problemID"`
 	CaseName  string `json:"caseName"`
+	// 为空时不筛选
+	Sample *bool `json:"sample"`
 }
@@ -0,0 +1,6 @@
+package request
+
+type ProblemHintUnlockForList struct {
+	ProblemID uint `json:"problemID"`
+	UserID    uint `json:"userID"`
+}
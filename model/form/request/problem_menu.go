@@ -4,4 +4,23 @@ type ProblemMenuForList struct {
 	ID          uint   `json:"id"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	CreatorID   uint   `json:"creatorID"`
+	Type        *int   `json:"type"`
+	Visibility  *int   `json:"visibility"`
+}
+
+// UserProblemMenu 用户创建或修改自己的题单
+type UserProblemMenu struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Icon        string `json:"icon"`
+	Description string `json:"description"`
+	// 可见性，见 consts.ProblemMenuVisibilityPublic 等，创建时为空表示私有，修改时为空表示不变
+	Visibility *int `json:"visibility"`
+}
+
+// ProblemMenuSectionOrder 题单排序中的一个分组，SectionID为0表示未分组，ProblemIDs为分组中按顺序排列的题目
+type ProblemMenuSectionOrder struct {
+	SectionID  uint   `json:"sectionID"`
+	ProblemIDs []uint `json:"problemIDs"`
 }
@@ -0,0 +1,25 @@
+package request
+
+import "time"
+
+type ProblemSolutionForList struct {
+	ProblemID uint   `json:"problemID"`
+	Title     string `json:"title"`
+}
+
+// ProblemSolution 管理员添加或修改题解
+type ProblemSolution struct {
+	ID          uint                   `json:"id"`
+	ProblemID   uint                   `json:"problemID"`
+	Title       string                 `json:"title"`
+	Content     string                 `json:"content"`
+	VisibleType int                    `json:"visibleType"`
+	VisibleAt   time.Time              `json:"visibleAt"`
+	Codes       []*ProblemSolutionCode `json:"codes"`
+}
+
+// ProblemSolutionCode 题解中某个语言的参考代码
+type ProblemSolutionCode struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+}
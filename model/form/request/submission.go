@@ -1,7 +1,17 @@
 package request
 
+import "time"
+
 type SubmissionForList struct {
 	ID        uint `json:"id"`
 	UserID    uint `json:"userID"`
 	ProblemID uint `json:"problemID"`
+	// 以下为管理员查询使用的条件，零值表示不限制
+	Status        int       `json:"status"`
+	Language      string    `json:"language"`
+	UserName      string    `json:"userName"`
+	ProblemNumber string    `json:"problemNumber"`
+	MenuID        uint      `json:"menuID"`
+	StartTime     time.Time `json:"startTime"`
+	EndTime       time.Time `json:"endTime"`
 }
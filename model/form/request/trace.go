@@ -0,0 +1,8 @@
+package request
+
+// TraceRun 可视化执行的代码和标准输入
+type TraceRun struct {
+	Language string `json:"language"`
+	Code     string `json:"code"`
+	Input    string `json:"input"`
+}
@@ -0,0 +1,8 @@
+package request
+
+type UserNoteForList struct {
+	UserID    uint `json:"userID"`
+	ProblemID uint `json:"problemID"`
+	// 在笔记内容中搜索
+	Keyword string `json:"keyword"`
+}
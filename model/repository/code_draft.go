@@ -0,0 +1,30 @@
+package repository
+
+import "gorm.io/gorm"
+
+// CodeDraft 用户在题目某个语言中未提交的代码，自动保存时先写入redis，定时保存到这里
+type CodeDraft struct {
+	gorm.Model
+	UserID    uint   `gorm:"column:user_id;uniqueIndex:idx_draft_user_problem_language" json:"userID"`
+	ProblemID uint   `gorm:"column:problem_id;uniqueIndex:idx_draft_user_problem_language" json:"problemID"`
+	Language  string `gorm:"column:language;type:varchar(32);uniqueIndex:idx_draft_user_problem_language" json:"language"`
+	Code      string `gorm:"column:code;type:text" json:"code"`
+}
+
+func (m *CodeDraft) TableName() string {
+	return "code_draft"
+}
+
+// CodeDraftSnapshot 草稿的历史版本，每次保存到mysql时代码有变化则新增一个版本，只保留最近的若干个
+type CodeDraftSnapshot struct {
+	gorm.Model
+	UserID    uint   `gorm:"column:user_id;uniqueIndex:idx_snapshot_user_problem_language_version" json:"userID"`
+	ProblemID uint   `gorm:"column:problem_id;uniqueIndex:idx_snapshot_user_problem_language_version" json:"problemID"`
+	Language  string `gorm:"column:language;type:varchar(32);uniqueIndex:idx_snapshot_user_problem_language_version" json:"language"`
+	Version   int    `gorm:"column:version;uniqueIndex:idx_snapshot_user_problem_language_version" json:"version"`
+	Code      string `gorm:"column:code;type:text" json:"code"`
+}
+
+func (m *CodeDraftSnapshot) TableName() string {
+	return "code_draft_snapshot"
+}
@@ -0,0 +1,54 @@
+package repository
+
+import "gorm.io/gorm"
+
+// Discussion 题目下的讨论帖
+type Discussion struct {
+	gorm.Model
+	ProblemID uint   `gorm:"column:problem_id;index" json:"problemID"`
+	UserID    uint   `gorm:"column:user_id" json:"userID"`
+	Title     string `gorm:"column:title" json:"title"`
+	// markdown格式的内容
+	Content string `gorm:"column:content;type:text" json:"content"`
+	// 是否包含剧透，包含剧透的帖子只对通过题目的用户可见
+	Spoiler bool `gorm:"column:spoiler" json:"spoiler"`
+	// 是否置顶
+	Pinned       bool `gorm:"column:pinned" json:"pinned"`
+	UpvoteCount  int  `gorm:"column:upvote_count" json:"upvoteCount"`
+	CommentCount int  `gorm:"column:comment_count" json:"commentCount"`
+}
+
+func (m *Discussion) TableName() string {
+	return "discussion"
+}
+
+// DiscussionComment 讨论帖的回复
+type DiscussionComment struct {
+	gorm.Model
+	DiscussionID uint `gorm:"column:discussion_id;index" json:"discussionID"`
+	// 所属的一级回复id，一级回复为0
+	RootID uint `gorm:"column:root_id;index" json:"rootID"`
+	// 回复的回复id，回复帖子时为0
+	ParentID    uint   `gorm:"column:parent_id" json:"parentID"`
+	UserID      uint   `gorm:"column:user_id" json:"userID"`
+	Content     string `gorm:"column:content;type:text" json:"content"`
+	Spoiler     bool   `gorm:"column:spoiler" json:"spoiler"`
+	UpvoteCount int    `gorm:"column:upvote_count" json:"upvoteCount"`
+}
+
+func (m *DiscussionComment) TableName() string {
+	return "discussion_comment"
+}
+
+// DiscussionUpvote 用户对帖子或回复的点赞
+type DiscussionUpvote struct {
+	gorm.Model
+	// 点赞对象类型，见 consts.UpvoteTargetDiscussion 等
+	TargetType string `gorm:"column:target_type;type:varchar(32);uniqueIndex:idx_target_user" json:"targetType"`
+	TargetID   uint   `gorm:"column:target_id;uniqueIndex:idx_target_user" json:"targetID"`
+	UserID     uint   `gorm:"column:user_id;uniqueIndex:idx_target_user" json:"userID"`
+}
+
+func (m *DiscussionUpvote) TableName() string {
+	return "discussion_upvote"
+}
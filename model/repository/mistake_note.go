@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// MistakeNote 错题本中的一道题目，由做题情况自动收集，按间隔重复算法安排复习
+type MistakeNote struct {
+	gorm.Model
+	UserID    uint `gorm:"column:user_id;uniqueIndex:idx_mistake_user_problem" json:"userID"`
+	ProblemID uint `gorm:"column:problem_id;uniqueIndex:idx_mistake_user_problem" json:"problemID"`
+	// 用户的笔记
+	Note string `gorm:"column:note;type:text" json:"note"`
+	// 难度系数，复习越困难越小
+	EaseFactor float64 `gorm:"column:ease_factor" json:"easeFactor"`
+	// 当前的复习间隔，天
+	ReviewInterval int `gorm:"column:review_interval" json:"reviewInterval"`
+	// 连续复习成功的次数
+	Repetitions int `gorm:"column:repetitions" json:"repetitions"`
+	// 下次复习的日期
+	DueAt          time.Time  `gorm:"column:due_at;index" json:"dueAt"`
+	LastReviewedAt *time.Time `gorm:"column:last_reviewed_at" json:"lastReviewedAt"`
+}
+
+func (m *MistakeNote) TableName() string {
+	return "mistake_note"
+}
+
+// MistakeNoteSetting 用户的错题本设置
+type MistakeNoteSetting struct {
+	gorm.Model
+	UserID uint `gorm:"column:user_id;uniqueIndex" json:"userID"`
+	// 是否每天发送待复习题目的邮件
+	EmailDigest bool `gorm:"column:email_digest" json:"emailDigest"`
+}
+
+func (m *MistakeNoteSetting) TableName() string {
+	return "mistake_note_setting"
+}
+
+// MistakeNoteDigest 用户待复习的题目数，不对应数据表
+type MistakeNoteDigest struct {
+	UserID uint  `gorm:"column:user_id"`
+	Count  int64 `gorm:"column:count"`
+}
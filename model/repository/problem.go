@@ -1,6 +1,9 @@
 package repository
 
-import "gorm.io/gorm"
+import (
+	"gorm.io/gorm"
+	"time"
+)
 
 type Problem struct {
 	gorm.Model
@@ -12,8 +15,14 @@ type Problem struct {
 	Difficulty  int    `gorm:"column:difficulty" json:"difficulty"`
 	// 0空值，1启用，-1停用
 	Enable int `gorm:"column:enable" json:"enable"`
-	// 支持的语言用,分割
+	// 发布流程状态，见 consts.ProblemStatusDraft 等，0为旧数据，根据Enable判断
+	Status int `gorm:"column:status" json:"status"`
+	// 定时发布的时间，审核通过后到达该时间自动发布
+	PublishAt *time.Time `gorm:"column:publish_at" json:"publishAt"`
+	// 支持的语言用,分割，与LanguageSettings保持同步
 	Languages string `gorm:"column:languages" json:"languages"`
+	// 支持的语言及其时间、内存限制倍率
+	LanguageSettings []*ProblemLanguage `gorm:"foreignKey:ProblemID" json:"languageSettings"`
 	// 所属题单
 	Menus []*ProblemMenu `gorm:"many2many:problem_menu_association" json:"menus"`
 	// 所属标签
@@ -5,8 +5,8 @@ import "gorm.io/gorm"
 // ProblemAttempt 用户在一道题目中的做题情况
 type ProblemAttempt struct {
 	gorm.Model
-	ProblemID       uint `gorm:"column:problem_id" json:"problemID"`
-	UserID          uint `gorm:"column:user_id" json:"userID"`
+	ProblemID       uint `gorm:"column:problem_id;uniqueIndex:idx_attempt_user_problem" json:"problemID"`
+	UserID          uint `gorm:"column:user_id;uniqueIndex:idx_attempt_user_problem" json:"userID"`
 	SubmissionCount int  `gorm:"column:submission_count" json:"submissionCount"`
 	SuccessCount    int  `gorm:"column:success_count" json:"successCount"`
 	ErrCount        int  `gorm:"column:err_count" json:"errCount"`
@@ -15,8 +15,16 @@ type ProblemAttempt struct {
 	Language string `gorm:"column:language" json:"language"`
 	// 0 未开始，1进行中 2 提交成功
 	Status int `gorm:"column:status" json:"status"`
+	// 已经计入的最后一次提交，重复处理同一次提交时不会重复计数
+	LastSubmissionID uint `gorm:"column:last_submission_id" json:"lastSubmissionID"`
 }
 
 func (m *ProblemAttempt) TableName() string {
 	return "problem_attempt"
 }
+
+// ProblemAttemptStatistic 按某个维度统计的通过题目数，不对应数据表
+type ProblemAttemptStatistic struct {
+	Name  string `gorm:"column:name"`
+	Count int64  `gorm:"column:count"`
+}
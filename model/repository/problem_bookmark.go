@@ -0,0 +1,14 @@
+package repository
+
+import "gorm.io/gorm"
+
+// ProblemBookmark 用户收藏的题目
+type ProblemBookmark struct {
+	gorm.Model
+	UserID    uint `gorm:"column:user_id;uniqueIndex:idx_user_problem" json:"userID"`
+	ProblemID uint `gorm:"column:problem_id;uniqueIndex:idx_user_problem" json:"problemID"`
+}
+
+func (m *ProblemBookmark) TableName() string {
+	return "problem_bookmark"
+}
@@ -9,6 +9,8 @@ type ProblemCase struct {
 	CaseName  string `gorm:"column:case_name" json:"caseName"`
 	Input     string `gorm:"column:input" json:"input"`
 	Output    string `gorm:"column:output" json:"output"`
+	// 是否为样例，样例在题目中展示并用于运行代码，隐藏用例只返回判题结果
+	Sample bool `gorm:"column:sample" json:"sample"`
 }
 
 func (m *ProblemCase) TableName() string {
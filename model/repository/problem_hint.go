@@ -0,0 +1,37 @@
+package repository
+
+import "gorm.io/gorm"
+
+// ProblemHint 题目提示，按顺序逐个解锁
+type ProblemHint struct {
+	gorm.Model
+	ProblemID uint   `gorm:"column:problem_id;index" json:"problemID"`
+	Sort      int    `gorm:"column:sort" json:"sort"`
+	Content   string `gorm:"column:content;type:text" json:"content"`
+	// 错误提交达到该次数后自动解锁，0表示只能主动解锁
+	UnlockErrCount int `gorm:"column:unlock_err_count" json:"unlockErrCount"`
+	// 主动解锁时在作业中扣除的分数
+	Penalty int `gorm:"column:penalty" json:"penalty"`
+}
+
+func (m *ProblemHint) TableName() string {
+	return "problem_hint"
+}
+
+// ProblemHintUnlock 用户解锁提示的记录
+type ProblemHintUnlock struct {
+	gorm.Model
+	HintID    uint `gorm:"column:hint_id;uniqueIndex:idx_hint_user" json:"hintID"`
+	UserID    uint `gorm:"column:user_id;uniqueIndex:idx_hint_user;index:idx_user_problem" json:"userID"`
+	ProblemID uint `gorm:"column:problem_id;index:idx_user_problem" json:"problemID"`
+	// 是否为用户主动解锁，否则为错误次数达到后自动解锁
+	Manual bool `gorm:"column:manual" json:"manual"`
+	// 解锁时记录的扣分，自动解锁不扣分
+	Penalty int `gorm:"column:penalty" json:"penalty"`
+	// 解锁时的错误提交次数
+	ErrCount int `gorm:"column:err_count" json:"errCount"`
+}
+
+func (m *ProblemHintUnlock) TableName() string {
+	return "problem_hint_unlock"
+}
@@ -0,0 +1,18 @@
+package repository
+
+import "gorm.io/gorm"
+
+// ProblemLanguage 题目允许使用的语言，以及该语言的时间、内存限制倍率
+type ProblemLanguage struct {
+	gorm.Model
+	ProblemID uint   `gorm:"column:problem_id;uniqueIndex:idx_problem_language" json:"problemID"`
+	Language  string `gorm:"column:language;type:varchar(32);uniqueIndex:idx_problem_language" json:"language"`
+	// 时间限制倍率，比如java为2表示时间限制是题目限制的两倍
+	TimeMultiplier float64 `gorm:"column:time_multiplier" json:"timeMultiplier"`
+	// 内存限制倍率
+	MemoryMultiplier float64 `gorm:"column:memory_multiplier" json:"memoryMultiplier"`
+}
+
+func (m *ProblemLanguage) TableName() string {
+	return "problem_language"
+}
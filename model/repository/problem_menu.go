@@ -5,13 +5,57 @@ import "gorm.io/gorm"
 // 题单
 type ProblemMenu struct {
 	gorm.Model
-	Name        string     `gorm:"column:name" json:"name"`
-	Icon        string     `gorm:"column:icon" json:"icon"`
-	Description string     `gorm:"column:description" json:"description"`
-	CreatorID   uint       `gorm:"column:creator_id" json:"creatorID"`
-	Problems    []*Problem `gorm:"many2many:problem_menu_association" json:"problems"`
+	Name        string `gorm:"column:name" json:"name"`
+	Icon        string `gorm:"column:icon" json:"icon"`
+	Description string `gorm:"column:description" json:"description"`
+	CreatorID   uint   `gorm:"column:creator_id" json:"creatorID"`
+	// 题单类型，见 consts.ProblemMenuTypeOfficial 等
+	Type int `gorm:"column:type" json:"type"`
+	// 可见性，见 consts.ProblemMenuVisibilityPublic 等
+	Visibility int `gorm:"column:visibility" json:"visibility"`
+	// 分享码，可见性为分享时通过分享码访问
+	ShareCode string     `gorm:"column:share_code;type:varchar(64);index" json:"-"`
+	Problems  []*Problem `gorm:"many2many:problem_menu_association" json:"problems"`
 }
 
 func (m *ProblemMenu) TableName() string {
 	return "problem_menu"
 }
+
+// ProblemMenuSection 题单中的分组
+type ProblemMenuSection struct {
+	gorm.Model
+	MenuID uint   `gorm:"column:menu_id;index" json:"menuID"`
+	Name   string `gorm:"column:name" json:"name"`
+	// 分组在题单中的顺序，从小到大
+	Sort int `gorm:"column:sort" json:"sort"`
+}
+
+func (m *ProblemMenuSection) TableName() string {
+	return "problem_menu_section"
+}
+
+// ProblemMenuAssociation 题单与题目的关联，记录题目所在的分组和顺序
+type ProblemMenuAssociation struct {
+	ProblemMenuID uint `gorm:"column:problem_menu_id;primaryKey" json:"problemMenuID"`
+	ProblemID     uint `gorm:"column:problem_id;primaryKey" json:"problemID"`
+	// 所在分组，0表示未分组
+	SectionID uint `gorm:"column:section_id" json:"sectionID"`
+	// 题目在分组中的顺序，从小到大
+	Sort int `gorm:"column:sort" json:"sort"`
+}
+
+func (m *ProblemMenuAssociation) TableName() string {
+	return "problem_menu_association"
+}
+
+// ProblemMenuFollow 用户关注的题单
+type ProblemMenuFollow struct {
+	gorm.Model
+	MenuID uint `gorm:"column:menu_id;uniqueIndex:idx_menu_user" json:"menuID"`
+	UserID uint `gorm:"column:user_id;uniqueIndex:idx_menu_user" json:"userID"`
+}
+
+func (m *ProblemMenuFollow) TableName() string {
+	return "problem_menu_follow"
+}
@@ -0,0 +1,20 @@
+package repository
+
+import "gorm.io/gorm"
+
+// ProblemReview 题目发布流程中的一次状态变更记录
+type ProblemReview struct {
+	gorm.Model
+	ProblemID  uint `gorm:"column:problem_id;index" json:"problemID"`
+	OperatorID uint `gorm:"column:operator_id" json:"operatorID"`
+	FromStatus int  `gorm:"column:from_status" json:"fromStatus"`
+	ToStatus   int  `gorm:"column:to_status" json:"toStatus"`
+	// 审核意见
+	Comment string `gorm:"column:comment;type:text" json:"comment"`
+	// 变更时的检查清单结果，json格式
+	Checklist string `gorm:"column:checklist;type:text" json:"checklist"`
+}
+
+func (m *ProblemReview) TableName() string {
+	return "problem_review"
+}
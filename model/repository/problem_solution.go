@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+// ProblemSolution 题目的官方题解
+type ProblemSolution struct {
+	gorm.Model
+	ProblemID uint   `gorm:"column:problem_id;index" json:"problemID"`
+	AuthorID  uint   `gorm:"column:author_id" json:"authorID"`
+	Title     string `gorm:"column:title" json:"title"`
+	// markdown格式的题解内容
+	Content string `gorm:"column:content;type:text" json:"content"`
+	// 可见规则，1总是可见，2通过题目后可见，3指定时间后可见
+	VisibleType int `gorm:"column:visible_type" json:"visibleType"`
+	// VisibleType为3时，题解的公开时间
+	VisibleAt time.Time `gorm:"column:visible_at" json:"visibleAt"`
+	// 各个语言的参考代码
+	Codes []*ProblemSolutionCode `gorm:"foreignKey:SolutionID" json:"codes"`
+}
+
+func (m *ProblemSolution) TableName() string {
+	return "problem_solution"
+}
+
+// ProblemSolutionCode 题解中某个语言的参考代码
+type ProblemSolutionCode struct {
+	gorm.Model
+	SolutionID uint   `gorm:"column:solution_id;index" json:"solutionID"`
+	Language   string `gorm:"column:language" json:"language"`
+	Code       string `gorm:"column:code;type:text" json:"code"`
+}
+
+func (m *ProblemSolutionCode) TableName() string {
+	return "problem_solution_code"
+}
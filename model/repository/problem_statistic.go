@@ -0,0 +1,34 @@
+package repository
+
+import "gorm.io/gorm"
+
+// ProblemStatistic 题目提交统计，由判题结果增量维护
+type ProblemStatistic struct {
+	gorm.Model
+	ProblemID uint `gorm:"column:problem_id;uniqueIndex:idx_problem_id" json:"problemID"`
+	// 总提交数
+	SubmissionCount int64 `gorm:"column:submission_count" json:"submissionCount"`
+	// 通过的提交数
+	AcceptedCount int64 `gorm:"column:accepted_count" json:"acceptedCount"`
+	// 通过的用户数
+	SolverCount int64 `gorm:"column:solver_count" json:"solverCount"`
+}
+
+func (m *ProblemStatistic) TableName() string {
+	return "problem_statistic"
+}
+
+// ProblemStatisticItem 题目提交的分布统计，比如各个判题状态、各个语言的提交数
+type ProblemStatisticItem struct {
+	gorm.Model
+	ProblemID uint `gorm:"column:problem_id;uniqueIndex:idx_problem_category_item" json:"problemID"`
+	// 统计类别，见 consts.StatisticCategoryStatus 等
+	Category string `gorm:"column:category;type:varchar(32);uniqueIndex:idx_problem_category_item" json:"category"`
+	// 统计项，比如状态码、语言名称、耗时分桶
+	Item  string `gorm:"column:item;type:varchar(64);uniqueIndex:idx_problem_category_item" json:"item"`
+	Count int64  `gorm:"column:count" json:"count"`
+}
+
+func (m *ProblemStatisticItem) TableName() string {
+	return "problem_statistic_item"
+}
@@ -6,7 +6,7 @@ import "gorm.io/gorm"
 type ProblemTag struct {
 	gorm.Model
 	Name     string     `gorm:"column:name" json:"name"`
-	Problems []*Problem `gorm:"many2many:problem_menu_association" json:"problems"`
+	Problems []*Problem `gorm:"many2many:problem_tag_association" json:"problems"`
 }
 
 func (m *ProblemTag) TableName() string {
@@ -0,0 +1,18 @@
+package repository
+
+import "gorm.io/gorm"
+
+// ProblemTemplate 题目某个语言的模板代码，每次修改新增一个版本
+type ProblemTemplate struct {
+	gorm.Model
+	// 题目id，为0时表示全局默认模板
+	ProblemID uint   `gorm:"column:problem_id;uniqueIndex:idx_problem_language_version" json:"problemID"`
+	Language  string `gorm:"column:language;type:varchar(32);uniqueIndex:idx_problem_language_version" json:"language"`
+	Version   int    `gorm:"column:version;uniqueIndex:idx_problem_language_version" json:"version"`
+	Code      string `gorm:"column:code;type:text" json:"code"`
+	CreatorID uint   `gorm:"column:creator_id" json:"creatorID"`
+}
+
+func (m *ProblemTemplate) TableName() string {
+	return "problem_template"
+}
@@ -14,10 +14,16 @@ type Submission struct {
 	ProblemID uint `gorm:"column:problem_id" json:"problemID"`
 	// 使用的编程语言
 	Language string `gorm:"column:language" json:"language"`
-	// 用户代码
+	// 用户代码，积木程序为翻译后的代码
 	Code string `gorm:"column:code" json:"code"`
+	// 代码来源，见 consts.SourceTypeCode 等
+	SourceType int `gorm:"column:source_type" json:"sourceType"`
+	// 积木程序的原始工作区，用于重新加载到编辑器
+	Workspace string `gorm:"column:workspace;type:mediumtext" json:"workspace"`
 	// 状态
 	Status int `gorm:"column:status" json:"status"`
+	// 分享码，为空表示未分享
+	ShareCode string `gorm:"column:share_code;index" json:"-"`
 	// 异常信息
 	ErrorMessage string `gorm:"column:error_message" json:"errorMessage"`
 	// 用例名称
@@ -30,8 +36,26 @@ type Submission struct {
 	UserOutput string        `gorm:"user_output" json:"userOutput"`
 	TimeUsed   time.Duration // 判题使用时间
 	MemoryUsed int64         // 内存使用量（以字节为单位）
+	// 归档在对象存储中的路径，不为空时代码、用例数据、期望输出和用户输出已从数据表中清空
+	ArchivePath string `gorm:"column:archive_path;type:varchar(255);not null;default:''" json:"-"`
+	// 是否已计入用户做题情况，保证同一次提交只计入一次
+	AttemptApplied bool `gorm:"column:attempt_applied;not null;default:false" json:"-"`
 }
 
 func (m *Submission) TableName() string {
 	return "submission"
 }
+
+// SubmissionActivity 提交数按用户所在时区的日期汇总的查询结果，不对应数据表
+type SubmissionActivity struct {
+	// 日期，格式为2006-01-02，数据库没有加载时区数据时为空
+	Date  string `gorm:"column:date"`
+	Count int    `gorm:"column:count"`
+}
+
+// SubmissionStatistic 按语言或日期统计的提交数，不对应数据表
+type SubmissionStatistic struct {
+	Name          string `gorm:"column:name"`
+	Count         int64  `gorm:"column:count"`
+	AcceptedCount int64  `gorm:"column:accepted_count"`
+}
@@ -0,0 +1,17 @@
+package repository
+
+import "gorm.io/gorm"
+
+// UserNote 用户的私人笔记，SubmissionID为0时是对整道题目的笔记，否则是对某次提交的笔记
+type UserNote struct {
+	gorm.Model
+	UserID       uint `gorm:"column:user_id;uniqueIndex:idx_note_user_problem_submission" json:"userID"`
+	ProblemID    uint `gorm:"column:problem_id;uniqueIndex:idx_note_user_problem_submission" json:"problemID"`
+	SubmissionID uint `gorm:"column:submission_id;uniqueIndex:idx_note_user_problem_submission" json:"submissionID"`
+	// Markdown格式的内容
+	Content string `gorm:"column:content;type:mediumtext" json:"content"`
+}
+
+func (m *UserNote) TableName() string {
+	return "user_note"
+}
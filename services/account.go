@@ -2,6 +2,7 @@ package services
 
 import (
 	conf "funoj-backend/config"
+	"funoj-backend/consts"
 	e "funoj-backend/consts/error"
 	"funoj-backend/dao"
 	"funoj-backend/db"
@@ -14,6 +15,7 @@ import (
 	"log"
 	"mime/multipart"
 	"path"
+	"strconv"
 	"time"
 )
 
@@ -27,7 +29,7 @@ type AccountService interface {
 	UploadAvatar(file *multipart.FileHeader) (string, *e.Error)
 	// ReadAvatar 读取头像
 	ReadAvatar(ctx *gin.Context, avatarName string)
-	// GetAccountInfo 获取账号信息
+	// GetAccountInfo 获取账号信息和做题统计
 	GetAccountInfo(ctx *gin.Context) (*dto.AccountInfo, *e.Error)
 	// UpdateAccountInfo 更新账号信息
 	UpdateAccountInfo(ctx *gin.Context, user *repository.SysUser) *e.Error
@@ -38,14 +40,19 @@ type AccountService interface {
 }
 
 type AccountServiceImpl struct {
-	config     *conf.AppConfig
-	sysUserDao dao.SysUserDao
+	config            *conf.AppConfig
+	sysUserDao        dao.SysUserDao
+	submissionDao     dao.SubmissionDao
+	problemAttemptDao dao.ProblemAttemptDao
 }
 
-func NewAccountService(config *conf.AppConfig, userDao dao.SysUserDao) AccountService {
+func NewAccountService(config *conf.AppConfig, userDao dao.SysUserDao, submissionDao dao.SubmissionDao,
+	problemAttemptDao dao.ProblemAttemptDao) AccountService {
 	return &AccountServiceImpl{
-		config:     config,
-		sysUserDao: userDao,
+		config:            config,
+		sysUserDao:        userDao,
+		submissionDao:     submissionDao,
+		problemAttemptDao: problemAttemptDao,
 	}
 }
 
@@ -82,7 +89,104 @@ func (svc *AccountServiceImpl) GetAccountInfo(ctx *gin.Context) (*dto.AccountInf
 	if err != nil {
 		return nil, e.ErrMysql
 	}
-	return dto.NewAccountInfo(u), nil
+	answer := dto.NewAccountInfo(u)
+	if answer.Statistic, err = svc.getAccountStatistic(user.ID); err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	return answer, nil
+}
+
+// getAccountStatistic 统计用户的做题情况
+func (svc *AccountServiceImpl) getAccountStatistic(userID uint) (*dto.AccountStatistic, error) {
+	answer := &dto.AccountStatistic{
+		SolvedByDifficulty: make([]int64, consts.ProblemMaxDifficulty),
+	}
+	var err error
+	if answer.SolvedCount, err = svc.problemAttemptDao.GetSolvedCount(db.Mysql, userID); err != nil {
+		return nil, err
+	}
+	difficulties, err := svc.problemAttemptDao.GetSolvedCountByDifficulty(db.Mysql, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, difficulty := range difficulties {
+		level, _ := strconv.Atoi(difficulty.Name)
+		if level >= 1 && level <= consts.ProblemMaxDifficulty {
+			answer.SolvedByDifficulty[level-1] = difficulty.Count
+		}
+	}
+	tags, err := svc.problemAttemptDao.GetSolvedCountByTag(db.Mysql, userID)
+	if err != nil {
+		return nil, err
+	}
+	answer.SolvedByTag = newStatisticItems(tags)
+	menus, err := svc.problemAttemptDao.GetSolvedCountByMenu(db.Mysql, userID)
+	if err != nil {
+		return nil, err
+	}
+	answer.SolvedByMenu = newStatisticItems(menus)
+	languages, err := svc.submissionDao.GetUserLanguageStatistics(db.Mysql, userID)
+	if err != nil {
+		return nil, err
+	}
+	answer.Languages = newVerdictStatistics(languages)
+	attempted, firstAccepted, err := svc.submissionDao.GetUserFirstVerdictCount(db.Mysql, userID)
+	if err != nil {
+		return nil, err
+	}
+	if attempted != 0 {
+		answer.FirstTryAcceptRate = float64(firstAccepted) / float64(attempted)
+	}
+	solved, attempts, err := svc.submissionDao.GetUserAttemptsToAccept(db.Mysql, userID)
+	if err != nil {
+		return nil, err
+	}
+	if solved != 0 {
+		answer.AverageAttemptsToAccept = float64(attempts) / float64(solved)
+	}
+	begin := time.Now().AddDate(0, 0, 1-consts.AccountRecentVerdictDays)
+	begin = time.Date(begin.Year(), begin.Month(), begin.Day(), 0, 0, 0, 0, time.Local)
+	recent, err := svc.submissionDao.GetUserDailyStatistics(db.Mysql, userID, begin)
+	if err != nil {
+		return nil, err
+	}
+	answer.RecentVerdicts = newVerdictStatistics(recent)
+	// 通过题目数不少于自己的用户都排在自己前面或并列
+	if answer.SolvedCount != 0 {
+		userCount, err := svc.sysUserDao.GetUserCount(db.Mysql, nil)
+		if err != nil {
+			return nil, err
+		}
+		notBehind, err := svc.problemAttemptDao.GetSolvedUserCount(db.Mysql, answer.SolvedCount)
+		if err != nil {
+			return nil, err
+		}
+		if userCount != 0 && userCount >= notBehind {
+			answer.Percentile = float64(userCount-notBehind) / float64(userCount) * 100
+		}
+	}
+	return answer, nil
+}
+
+func newStatisticItems(statistics []*repository.ProblemAttemptStatistic) []*dto.StatisticItem {
+	items := make([]*dto.StatisticItem, len(statistics))
+	for i, statistic := range statistics {
+		items[i] = &dto.StatisticItem{Name: statistic.Name, Count: statistic.Count}
+	}
+	return items
+}
+
+func newVerdictStatistics(statistics []*repository.SubmissionStatistic) []*dto.VerdictStatistic {
+	items := make([]*dto.VerdictStatistic, len(statistics))
+	for i, statistic := range statistics {
+		items[i] = &dto.VerdictStatistic{
+			Name:          statistic.Name,
+			Count:         statistic.Count,
+			AcceptedCount: statistic.AcceptedCount,
+		}
+	}
+	return items
 }
 
 func (svc *AccountServiceImpl) UpdateAccountInfo(ctx *gin.Context, user *repository.SysUser) *e.Error {
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	conf "funoj-backend/config"
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BlockService 积木编程，将Blockly工作区校验并翻译成 consts.BlockTargetLanguage 的代码后按普通代码判题
+type BlockService interface {
+	// TranslateBlockProgram 翻译积木程序，用于预览生成的代码，指定题目时检查题目是否允许该语言
+	TranslateBlockProgram(program *request.BlockProgram) (*dto.BlockProgramDto, *e.Error)
+	// BuildBlockSubmission 翻译积木程序并填充到提交中，保存原始工作区，之后交给判题并通过 SubmissionService.InsertSubmission 保存
+	BuildBlockSubmission(submission *repository.Submission, sourceType int, workspace string) *e.Error
+	// GetSubmissionWorkspace 获取积木提交的原始工作区，用于重新加载到编辑器，只有提交者和管理员可以获取
+	GetSubmissionWorkspace(ctx *gin.Context, submissionID uint) (*dto.BlockProgramDto, *e.Error)
+}
+
+type BlockServiceImpl struct {
+	config             *conf.AppConfig
+	submissionDao      dao.SubmissionDao
+	problemDao         dao.ProblemDao
+	problemLanguageDao dao.ProblemLanguageDao
+}
+
+func NewBlockService(config *conf.AppConfig, submissionDao dao.SubmissionDao, problemDao dao.ProblemDao,
+	problemLanguageDao dao.ProblemLanguageDao) BlockService {
+	return &BlockServiceImpl{
+		config:             config,
+		submissionDao:      submissionDao,
+		problemDao:         problemDao,
+		problemLanguageDao: problemLanguageDao,
+	}
+}
+
+func (svc *BlockServiceImpl) TranslateBlockProgram(program *request.BlockProgram) (*dto.BlockProgramDto, *e.Error) {
+	if program.ProblemID != 0 {
+		if _, err := checkProblemLanguage(svc.problemDao, svc.problemLanguageDao, program.ProblemID, consts.BlockTargetLanguage); err != nil {
+			return nil, err
+		}
+	}
+	code, err := translateBlockWorkspace(program.SourceType, program.Workspace)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.BlockProgramDto{
+		ProblemID:  program.ProblemID,
+		SourceType: program.SourceType,
+		Workspace:  program.Workspace,
+		Language:   consts.BlockTargetLanguage,
+		Code:       code,
+	}, nil
+}
+
+func (svc *BlockServiceImpl) BuildBlockSubmission(submission *repository.Submission, sourceType int, workspace string) *e.Error {
+	code, err := translateBlockWorkspace(sourceType, workspace)
+	if err != nil {
+		return err
+	}
+	submission.Code = code
+	submission.Language = consts.BlockTargetLanguage
+	submission.SourceType = sourceType
+	submission.Workspace = workspace
+	return nil
+}
+
+func (svc *BlockServiceImpl) GetSubmissionWorkspace(ctx *gin.Context, submissionID uint) (*dto.BlockProgramDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	submission, err := svc.submissionDao.GetSubmissionByID(db.Mysql, submissionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrSubmissionNotExist
+	}
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	if submission.UserID != user.ID && !isAdmin(user) {
+		return nil, e.ErrSubmissionNotExist
+	}
+	if submission.SourceType == consts.SourceTypeCode {
+		return nil, e.ErrBlockWorkspaceInvalid
+	}
+	if err = loadSubmissionArchive(svc.config, submission); err != nil {
+		return nil, e.ErrServer
+	}
+	return dto.NewBlockProgramDto(submission), nil
+}
+
+// translateBlockWorkspace 校验大小和格式，解析后翻译成代码，错误信息返回给用户
+func translateBlockWorkspace(sourceType int, workspace string) (string, *e.Error) {
+	if workspace == "" || len(workspace) > consts.BlockMaxWorkspaceSize {
+		return "", e.ErrBlockWorkspaceInvalid
+	}
+	var program *blockProgram
+	var err error
+	switch sourceType {
+	case consts.SourceTypeBlocklyXml:
+		program, err = parseXmlBlockProgram(workspace)
+	case consts.SourceTypeBlocklyJson:
+		program, err = parseJsonBlockProgram(workspace)
+	default:
+		return "", e.ErrBlockWorkspaceInvalid
+	}
+	if err != nil {
+		return "", e.NewError(e.CodeBlockWorkspaceInvalid, "积木程序不合法: "+err.Error(), e.ErrTypeBadReq)
+	}
+	code, err := translateBlockProgram(program)
+	if err != nil {
+		return "", e.NewError(e.CodeBlockWorkspaceInvalid, "积木程序不合法: "+err.Error(), e.ErrTypeBadReq)
+	}
+	return code, nil
+}
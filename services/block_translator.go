@@ -0,0 +1,672 @@
+package services
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"funoj-backend/consts"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// blockNode 积木的统一表示，xml和json格式解析后都转换为该结构
+type blockNode struct {
+	Type   string
+	Fields map[string]string
+	Inputs map[string]*blockNode
+	Next   *blockNode
+}
+
+// blockProgram 解析后的积木程序
+type blockProgram struct {
+	// 顶层积木，按工作区中的顺序执行
+	Blocks []*blockNode
+	// 变量id -> 变量名称
+	Variables map[string]string
+	// 变量的定义顺序
+	VariableIDs []string
+}
+
+func (p *blockProgram) addVariable(id string, name string) {
+	if id == "" {
+		id = name
+	}
+	if _, ok := p.Variables[id]; ok {
+		return
+	}
+	p.Variables[id] = name
+	p.VariableIDs = append(p.VariableIDs, id)
+}
+
+// blockParser 解析时统计积木数量和嵌套层数
+type blockParser struct {
+	count   int
+	program *blockProgram
+}
+
+func (p *blockParser) enter(depth int) error {
+	p.count++
+	if p.count > consts.BlockMaxCount {
+		return fmt.Errorf("积木数量超过%d个", consts.BlockMaxCount)
+	}
+	if depth > consts.BlockMaxDepth {
+		return fmt.Errorf("积木嵌套超过%d层", consts.BlockMaxDepth)
+	}
+	return nil
+}
+
+// jsonWorkspace Blockly.serialization.workspaces.save 的结果
+type jsonWorkspace struct {
+	Blocks struct {
+		Blocks []*jsonBlock `json:"blocks"`
+	} `json:"blocks"`
+	Variables []struct {
+		Name string `json:"name"`
+		ID   string `json:"id"`
+	} `json:"variables"`
+}
+
+type jsonBlock struct {
+	Type   string                     `json:"type"`
+	Fields map[string]json.RawMessage `json:"fields"`
+	Inputs map[string]*jsonConnection `json:"inputs"`
+	Next   *jsonConnection            `json:"next"`
+}
+
+type jsonConnection struct {
+	Block  *jsonBlock `json:"block"`
+	Shadow *jsonBlock `json:"shadow"`
+}
+
+func (c *jsonConnection) target() *jsonBlock {
+	if c == nil {
+		return nil
+	}
+	if c.Block != nil {
+		return c.Block
+	}
+	return c.Shadow
+}
+
+func parseJsonBlockProgram(workspace string) (*blockProgram, error) {
+	ws := &jsonWorkspace{}
+	if err := json.Unmarshal([]byte(workspace), ws); err != nil {
+		return nil, fmt.Errorf("工作区不是合法的json")
+	}
+	p := &blockParser{program: &blockProgram{Variables: make(map[string]string)}}
+	for _, variable := range ws.Variables {
+		p.program.addVariable(variable.ID, variable.Name)
+	}
+	for _, block := range ws.Blocks.Blocks {
+		node, err := p.parseJsonBlock(block, 1)
+		if err != nil {
+			return nil, err
+		}
+		p.program.Blocks = append(p.program.Blocks, node)
+	}
+	return p.program, nil
+}
+
+func (p *blockParser) parseJsonBlock(block *jsonBlock, depth int) (*blockNode, error) {
+	if block == nil {
+		return nil, nil
+	}
+	if err := p.enter(depth); err != nil {
+		return nil, err
+	}
+	node := &blockNode{
+		Type:   block.Type,
+		Fields: make(map[string]string, len(block.Fields)),
+		Inputs: make(map[string]*blockNode, len(block.Inputs)),
+	}
+	for name, raw := range block.Fields {
+		value, err := parseJsonField(raw)
+		if err != nil {
+			return nil, fmt.Errorf("积木%s的字段%s不合法", block.Type, name)
+		}
+		node.Fields[name] = value
+	}
+	for name, input := range block.Inputs {
+		child, err := p.parseJsonBlock(input.target(), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			node.Inputs[name] = child
+		}
+	}
+	// 后续积木和当前积木在同一层
+	next, err := p.parseJsonBlock(block.Next.target(), depth)
+	if err != nil {
+		return nil, err
+	}
+	node.Next = next
+	return node, nil
+}
+
+// parseJsonField 字段可以是字符串、数字、布尔值，变量字段为 {"id": "..."}
+func parseJsonField(raw json.RawMessage) (string, error) {
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", err
+	}
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case map[string]interface{}:
+		if id, ok := v["id"].(string); ok {
+			return id, nil
+		}
+		if name, ok := v["name"].(string); ok {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported field")
+}
+
+// xmlWorkspace Blockly.Xml.workspaceToDom 的结果
+type xmlWorkspace struct {
+	XMLName   xml.Name `xml:"xml"`
+	Variables []struct {
+		ID   string `xml:"id,attr"`
+		Name string `xml:",chardata"`
+	} `xml:"variables>variable"`
+	Blocks []*xmlBlock `xml:"block"`
+}
+
+type xmlBlock struct {
+	Type       string         `xml:"type,attr"`
+	Fields     []*xmlField    `xml:"field"`
+	Values     []*xmlInput    `xml:"value"`
+	Statements []*xmlInput    `xml:"statement"`
+	Next       *xmlConnection `xml:"next"`
+}
+
+type xmlField struct {
+	Name  string `xml:"name,attr"`
+	ID    string `xml:"id,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlConnection struct {
+	Block  *xmlBlock `xml:"block"`
+	Shadow *xmlBlock `xml:"shadow"`
+}
+
+func (c *xmlConnection) target() *xmlBlock {
+	if c == nil {
+		return nil
+	}
+	if c.Block != nil {
+		return c.Block
+	}
+	return c.Shadow
+}
+
+type xmlInput struct {
+	Name string `xml:"name,attr"`
+	xmlConnection
+}
+
+func parseXmlBlockProgram(workspace string) (*blockProgram, error) {
+	ws := &xmlWorkspace{}
+	if err := xml.Unmarshal([]byte(workspace), ws); err != nil {
+		return nil, fmt.Errorf("工作区不是合法的xml")
+	}
+	p := &blockParser{program: &blockProgram{Variables: make(map[string]string)}}
+	for _, variable := range ws.Variables {
+		p.program.addVariable(variable.ID, strings.TrimSpace(variable.Name))
+	}
+	for _, block := range ws.Blocks {
+		node, err := p.parseXmlBlock(block, 1)
+		if err != nil {
+			return nil, err
+		}
+		p.program.Blocks = append(p.program.Blocks, node)
+	}
+	return p.program, nil
+}
+
+func (p *blockParser) parseXmlBlock(block *xmlBlock, depth int) (*blockNode, error) {
+	if block == nil {
+		return nil, nil
+	}
+	if err := p.enter(depth); err != nil {
+		return nil, err
+	}
+	node := &blockNode{
+		Type:   block.Type,
+		Fields: make(map[string]string, len(block.Fields)),
+		Inputs: make(map[string]*blockNode, len(block.Values)+len(block.Statements)),
+	}
+	for _, field := range block.Fields {
+		node.Fields[field.Name] = field.Value
+		// 变量字段使用变量id，旧版本的xml没有id时使用变量名称
+		if field.Name == "VAR" {
+			p.program.addVariable(field.ID, field.Value)
+			if field.ID != "" {
+				node.Fields[field.Name] = field.ID
+			}
+		}
+	}
+	inputs := append(block.Values, block.Statements...)
+	for _, input := range inputs {
+		child, err := p.parseXmlBlock(input.target(), depth+1)
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			node.Inputs[input.Name] = child
+		}
+	}
+	next, err := p.parseXmlBlock(block.Next.target(), depth)
+	if err != nil {
+		return nil, err
+	}
+	node.Next = next
+	return node, nil
+}
+
+// blockGenerator 将积木程序翻译成C代码，变量统一为double类型
+type blockGenerator struct {
+	program *blockProgram
+	// 变量id -> C变量名称
+	names     map[string]string
+	body      strings.Builder
+	indent    int
+	loopDepth int
+	// 生成的临时变量数量
+	temps int
+	// 是否使用了输入
+	useInput bool
+}
+
+func translateBlockProgram(program *blockProgram) (string, error) {
+	g := &blockGenerator{
+		program: program,
+		names:   make(map[string]string, len(program.VariableIDs)),
+		indent:  1,
+	}
+	for i, id := range program.VariableIDs {
+		g.names[id] = "var" + strconv.Itoa(i+1)
+	}
+	for _, block := range program.Blocks {
+		if err := g.statements(block); err != nil {
+			return "", err
+		}
+	}
+	code := &strings.Builder{}
+	code.WriteString("#include <stdio.h>\n#include <math.h>\n\n")
+	if g.useInput {
+		code.WriteString("double read_number() {\n    double x = 0;\n    scanf(\"%lf\", &x);\n    return x;\n}\n\n")
+	}
+	code.WriteString("int main() {\n")
+	for _, id := range program.VariableIDs {
+		code.WriteString(fmt.Sprintf("    double %s = 0; /* %s */\n", g.names[id], escapeBlockComment(program.Variables[id])))
+	}
+	code.WriteString(g.body.String())
+	code.WriteString("    return 0;\n}\n")
+	return code.String(), nil
+}
+
+func (g *blockGenerator) line(format string, args ...interface{}) {
+	g.body.WriteString(strings.Repeat("    ", g.indent))
+	g.body.WriteString(fmt.Sprintf(format, args...))
+	g.body.WriteString("\n")
+}
+
+func (g *blockGenerator) temp(prefix string) string {
+	g.temps++
+	return prefix + strconv.Itoa(g.temps)
+}
+
+func (g *blockGenerator) variable(block *blockNode) (string, error) {
+	name, ok := g.names[block.Fields["VAR"]]
+	if !ok {
+		return "", fmt.Errorf("积木%s使用了未定义的变量", block.Type)
+	}
+	return name, nil
+}
+
+// statements 生成一串语句积木
+func (g *blockGenerator) statements(block *blockNode) error {
+	for ; block != nil; block = block.Next {
+		if err := g.statement(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// block 生成代码块中的语句，增加一层缩进
+func (g *blockGenerator) block(block *blockNode) error {
+	g.indent++
+	defer func() { g.indent-- }()
+	return g.statements(block)
+}
+
+func (g *blockGenerator) statement(block *blockNode) error {
+	switch block.Type {
+	case "variables_set":
+		name, err := g.variable(block)
+		if err != nil {
+			return err
+		}
+		value, err := g.number(block, "VALUE")
+		if err != nil {
+			return err
+		}
+		g.line("%s = %s;", name, value)
+	case "math_change":
+		name, err := g.variable(block)
+		if err != nil {
+			return err
+		}
+		delta, err := g.number(block, "DELTA")
+		if err != nil {
+			return err
+		}
+		g.line("%s += %s;", name, delta)
+	case "text_print":
+		input := block.Inputs["TEXT"]
+		if input != nil && input.Type == "text" {
+			g.line("printf(\"%%s\\n\", %s);", quoteBlockText(input.Fields["TEXT"]))
+			return nil
+		}
+		value, err := g.number(block, "TEXT")
+		if err != nil {
+			return err
+		}
+		g.line("printf(\"%%.15g\\n\", (double)(%s));", value)
+	case "controls_if":
+		return g.ifStatement(block)
+	case "controls_repeat_ext", "controls_repeat":
+		var times string
+		if block.Type == "controls_repeat" {
+			n, err := strconv.Atoi(block.Fields["TIMES"])
+			if err != nil {
+				return fmt.Errorf("积木%s的次数不合法", block.Type)
+			}
+			times = strconv.Itoa(n)
+		} else {
+			var err error
+			if times, err = g.number(block, "TIMES"); err != nil {
+				return err
+			}
+		}
+		counter := g.temp("cnt")
+		g.line("for (long %s = 0; %s < (long)(%s); %s++) {", counter, counter, times, counter)
+		if err := g.loop(block.Inputs["DO"]); err != nil {
+			return err
+		}
+		g.line("}")
+	case "controls_whileUntil":
+		condition, err := g.number(block, "BOOL")
+		if err != nil {
+			return err
+		}
+		if block.Fields["MODE"] == "UNTIL" {
+			condition = "!(" + condition + ")"
+		}
+		g.line("while (%s) {", condition)
+		if err = g.loop(block.Inputs["DO"]); err != nil {
+			return err
+		}
+		g.line("}")
+	case "controls_for":
+		return g.forStatement(block)
+	case "controls_flow_statements":
+		if g.loopDepth == 0 {
+			return fmt.Errorf("积木%s只能在循环中使用", block.Type)
+		}
+		switch block.Fields["FLOW"] {
+		case "BREAK":
+			g.line("break;")
+		case "CONTINUE":
+			g.line("continue;")
+		default:
+			return fmt.Errorf("积木%s的类型不合法", block.Type)
+		}
+	default:
+		// 单独放置的表达式积木，计算后丢弃结果
+		value, err := g.expression(block)
+		if err != nil {
+			return err
+		}
+		g.line("(void)(%s);", value)
+	}
+	return nil
+}
+
+func (g *blockGenerator) loop(block *blockNode) error {
+	g.loopDepth++
+	defer func() { g.loopDepth-- }()
+	return g.block(block)
+}
+
+// ifStatement 条件为IF0..IFn，分支为DO0..DOn，ELSE为否则分支
+func (g *blockGenerator) ifStatement(block *blockNode) error {
+	for i := 0; ; i++ {
+		key := strconv.Itoa(i)
+		if i > 0 && block.Inputs["IF"+key] == nil && block.Inputs["DO"+key] == nil {
+			break
+		}
+		condition, err := g.number(block, "IF"+key)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			g.line("if (%s) {", condition)
+		} else {
+			g.line("} else if (%s) {", condition)
+		}
+		if err = g.block(block.Inputs["DO"+key]); err != nil {
+			return err
+		}
+	}
+	if elseBlock, ok := block.Inputs["ELSE"]; ok {
+		g.line("} else {")
+		if err := g.block(elseBlock); err != nil {
+			return err
+		}
+	}
+	g.line("}")
+	return nil
+}
+
+// forStatement 与Blockly的语义一致，起点大于终点时倒序，步长取绝对值
+func (g *blockGenerator) forStatement(block *blockNode) error {
+	name, err := g.variable(block)
+	if err != nil {
+		return err
+	}
+	from, err := g.number(block, "FROM")
+	if err != nil {
+		return err
+	}
+	to, err := g.number(block, "TO")
+	if err != nil {
+		return err
+	}
+	by, err := g.number(block, "BY")
+	if err != nil {
+		return err
+	}
+	fromName, toName, byName := g.temp("from"), g.temp("to"), g.temp("by")
+	g.line("{")
+	g.indent++
+	g.line("double %s = %s, %s = %s, %s = fabs(%s);", fromName, from, toName, to, byName, by)
+	g.line("if (%s == 0) %s = 1;", byName, byName)
+	g.line("if (%s > %s) %s = -%s;", fromName, toName, byName, byName)
+	g.line("for (%s = %s; %s > 0 ? %s <= %s : %s >= %s; %s += %s) {",
+		name, fromName, byName, name, toName, name, toName, name, byName)
+	if err = g.loop(block.Inputs["DO"]); err != nil {
+		return err
+	}
+	g.line("}")
+	g.indent--
+	g.line("}")
+	return nil
+}
+
+// number 生成数值输入，未连接积木时为0
+func (g *blockGenerator) number(block *blockNode, input string) (string, error) {
+	child := block.Inputs[input]
+	if child == nil {
+		return "0", nil
+	}
+	return g.expression(child)
+}
+
+func (g *blockGenerator) expression(block *blockNode) (string, error) {
+	if block.Next != nil {
+		return "", fmt.Errorf("积木%s不能连接后续积木", block.Type)
+	}
+	switch block.Type {
+	case "math_number":
+		n, err := strconv.ParseFloat(block.Fields["NUM"], 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			return "", fmt.Errorf("数字%s不合法", block.Fields["NUM"])
+		}
+		return "(" + strconv.FormatFloat(n, 'g', -1, 64) + ")", nil
+	case "variables_get":
+		return g.variable(block)
+	case "logic_boolean":
+		if block.Fields["BOOL"] == "TRUE" {
+			return "1", nil
+		}
+		return "0", nil
+	case "math_arithmetic":
+		a, b, err := g.binary(block, "A", "B")
+		if err != nil {
+			return "", err
+		}
+		switch block.Fields["OP"] {
+		case "ADD":
+			return "(" + a + " + " + b + ")", nil
+		case "MINUS":
+			return "(" + a + " - " + b + ")", nil
+		case "MULTIPLY":
+			return "(" + a + " * " + b + ")", nil
+		case "DIVIDE":
+			return "(" + a + " / " + b + ")", nil
+		case "POWER":
+			return "pow(" + a + ", " + b + ")", nil
+		}
+	case "math_modulo":
+		a, b, err := g.binary(block, "DIVIDEND", "DIVISOR")
+		if err != nil {
+			return "", err
+		}
+		return "fmod(" + a + ", " + b + ")", nil
+	case "math_single", "math_round":
+		value, err := g.number(block, "NUM")
+		if err != nil {
+			return "", err
+		}
+		functions := map[string]string{
+			"ROOT": "sqrt", "ABS": "fabs", "ROUND": "round", "ROUNDUP": "ceil", "ROUNDDOWN": "floor",
+		}
+		if block.Fields["OP"] == "NEG" {
+			return "(-" + value + ")", nil
+		}
+		if function, ok := functions[block.Fields["OP"]]; ok {
+			return function + "(" + value + ")", nil
+		}
+	case "logic_compare":
+		a, b, err := g.binary(block, "A", "B")
+		if err != nil {
+			return "", err
+		}
+		operators := map[string]string{"EQ": "==", "NEQ": "!=", "LT": "<", "LTE": "<=", "GT": ">", "GTE": ">="}
+		if operator, ok := operators[block.Fields["OP"]]; ok {
+			return "(" + a + " " + operator + " " + b + ")", nil
+		}
+	case "logic_operation":
+		a, b, err := g.binary(block, "A", "B")
+		if err != nil {
+			return "", err
+		}
+		switch block.Fields["OP"] {
+		case "AND":
+			return "(" + a + " && " + b + ")", nil
+		case "OR":
+			return "(" + a + " || " + b + ")", nil
+		}
+	case "logic_negate":
+		value, err := g.number(block, "BOOL")
+		if err != nil {
+			return "", err
+		}
+		return "(!" + value + ")", nil
+	case "text_prompt_ext", "text_prompt":
+		// 只支持读取数字，提示文字不输出，避免影响判题
+		if block.Fields["TYPE"] != "NUMBER" {
+			return "", fmt.Errorf("积木%s只支持读取数字", block.Type)
+		}
+		g.useInput = true
+		return "read_number()", nil
+	case "text":
+		return "", fmt.Errorf("文本只能用于输出")
+	default:
+		return "", fmt.Errorf("不支持的积木%s", block.Type)
+	}
+	return "", fmt.Errorf("积木%s的运算符%s不合法", block.Type, block.Fields["OP"])
+}
+
+func (g *blockGenerator) binary(block *blockNode, left string, right string) (string, string, error) {
+	a, err := g.number(block, left)
+	if err != nil {
+		return "", "", err
+	}
+	b, err := g.number(block, right)
+	if err != nil {
+		return "", "", err
+	}
+	return a, b, nil
+}
+
+// quoteBlockText 转换为C字符串字面量，非ASCII字符按UTF-8原样保留
+func quoteBlockText(text string) string {
+	builder := &strings.Builder{}
+	builder.WriteByte('"')
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch c {
+		case '"':
+			builder.WriteString("\\\"")
+		case '\\':
+			builder.WriteString("\\\\")
+		case '\n':
+			builder.WriteString("\\n")
+		case '\r':
+			builder.WriteString("\\r")
+		case '\t':
+			builder.WriteString("\\t")
+		case '?':
+			// 避免三字符组
+			builder.WriteString("\\?")
+		default:
+			if c < 0x20 || c == 0x7f {
+				builder.WriteString(fmt.Sprintf("\\%03o", c))
+			} else {
+				builder.WriteByte(c)
+			}
+		}
+	}
+	builder.WriteByte('"')
+	return builder.String()
+}
+
+func escapeBlockComment(text string) string {
+	text = strings.ReplaceAll(text, "*/", "* /")
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\n", " "), "\r", " ")
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	conf "funoj-backend/config"
+	e "funoj-backend/consts/error"
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+const (
+	// CodeDraftKeyPrefix redis中草稿的key前缀，后面是codeDraftMember
+	CodeDraftKeyPrefix = "codedraft-"
+	// CodeDraftDirtyKey 还未保存到mysql的草稿集合
+	CodeDraftDirtyKey = "codedraft-dirty"
+)
+
+// CodeDraftService 代码草稿，编辑器自动保存的代码先写入redis，由定时任务保存到mysql并生成历史版本
+type CodeDraftService interface {
+	// SaveCodeDraft 自动保存草稿
+	SaveCodeDraft(ctx *gin.Context, problemID uint, language string, code string) *e.Error
+	// GetCodeDraftHistory 获取草稿的历史版本，新版本在前
+	GetCodeDraftHistory(ctx *gin.Context, problemID uint, language string) ([]*dto.CodeDraftSnapshotDto, *e.Error)
+	// RestoreCodeDraft 将草稿恢复到某个历史版本，返回恢复后的代码
+	RestoreCodeDraft(ctx *gin.Context, problemID uint, language string, version int) (string, *e.Error)
+	// FlushCodeDrafts 将redis中修改过的草稿保存到mysql，由定时任务每隔DraftConfig.FlushInterval秒调用
+	FlushCodeDrafts() *e.Error
+}
+
+type CodeDraftServiceImpl struct {
+	config             *conf.AppConfig
+	codeDraftDao       dao.CodeDraftDao
+	problemDao         dao.ProblemDao
+	problemLanguageDao dao.ProblemLanguageDao
+}
+
+func NewCodeDraftService(config *conf.AppConfig, codeDraftDao dao.CodeDraftDao, problemDao dao.ProblemDao,
+	problemLanguageDao dao.ProblemLanguageDao) CodeDraftService {
+	return &CodeDraftServiceImpl{
+		config:             config,
+		codeDraftDao:       codeDraftDao,
+		problemDao:         problemDao,
+		problemLanguageDao: problemLanguageDao,
+	}
+}
+
+func (svc *CodeDraftServiceImpl) SaveCodeDraft(ctx *gin.Context, problemID uint, language string, code string) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if len(code) > svc.config.DraftConfig.MaxCodeSize {
+		return e.ErrCodeDraftTooLarge
+	}
+	if _, err := checkProblemLanguage(svc.problemDao, svc.problemLanguageDao, problemID, language); err != nil {
+		return err
+	}
+	if err := svc.cacheCodeDraft(user.ID, problemID, language, code); err != nil {
+		log.Println(err)
+		return e.ErrServer
+	}
+	return nil
+}
+
+func (svc *CodeDraftServiceImpl) GetCodeDraftHistory(ctx *gin.Context, problemID uint, language string) ([]*dto.CodeDraftSnapshotDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	snapshots, err := svc.codeDraftDao.GetCodeDraftSnapshots(db.Mysql, user.ID, problemID, language)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.CodeDraftSnapshotDto, len(snapshots))
+	for i, snapshot := range snapshots {
+		answer[i] = dto.NewCodeDraftSnapshotDto(snapshot)
+	}
+	return answer, nil
+}
+
+func (svc *CodeDraftServiceImpl) RestoreCodeDraft(ctx *gin.Context, problemID uint, language string, version int) (string, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	snapshot, err := svc.codeDraftDao.GetCodeDraftSnapshot(db.Mysql, user.ID, problemID, language, version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", e.ErrCodeDraftSnapshotNotExist
+	}
+	if err != nil {
+		return "", e.ErrMysql
+	}
+	// 恢复后的代码作为新的草稿，保存到mysql时生成新的版本，原来的版本不会丢失
+	if err = svc.cacheCodeDraft(user.ID, problemID, language, snapshot.Code); err != nil {
+		log.Println(err)
+		return "", e.ErrServer
+	}
+	return snapshot.Code, nil
+}
+
+func (svc *CodeDraftServiceImpl) FlushCodeDrafts() *e.Error {
+	var failed []string
+	for {
+		member, err := db.Redis.SPop(CodeDraftDirtyKey).Result()
+		if errors.Is(err, redis.Nil) {
+			break
+		}
+		if err != nil {
+			log.Println(err)
+			return e.ErrServer
+		}
+		if err = svc.flushCodeDraft(member); err != nil {
+			log.Println(err)
+			failed = append(failed, member)
+		}
+	}
+	// 保存失败的草稿下次重试
+	if len(failed) != 0 {
+		members := make([]interface{}, len(failed))
+		for i, member := range failed {
+			members[i] = member
+		}
+		if err := db.Redis.SAdd(CodeDraftDirtyKey, members...).Err(); err != nil {
+			log.Println(err)
+		}
+		return e.ErrMysql
+	}
+	return nil
+}
+
+// flushCodeDraft 保存一个草稿，代码和最新的历史版本不同时新增版本并删除超出数量的旧版本
+func (svc *CodeDraftServiceImpl) flushCodeDraft(member string) error {
+	var userID, problemID uint
+	var language string
+	if _, err := fmt.Sscanf(member, "%d:%d:%s", &userID, &problemID, &language); err != nil {
+		return err
+	}
+	code, err := db.Redis.Get(CodeDraftKeyPrefix + member).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	historySize := svc.config.DraftConfig.HistorySize
+	return db.Mysql.Transaction(func(tx *gorm.DB) error {
+		draft := &repository.CodeDraft{UserID: userID, ProblemID: problemID, Language: language, Code: code}
+		if err := svc.codeDraftDao.SaveCodeDraft(tx, draft); err != nil {
+			return err
+		}
+		latest, err := svc.codeDraftDao.GetLatestCodeDraftSnapshot(tx, userID, problemID, language)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil && latest.Code == code {
+			return nil
+		}
+		snapshot := &repository.CodeDraftSnapshot{
+			UserID:    userID,
+			ProblemID: problemID,
+			Language:  language,
+			Version:   latest.Version + 1,
+			Code:      code,
+		}
+		if err = svc.codeDraftDao.InsertCodeDraftSnapshot(tx, snapshot); err != nil {
+			return err
+		}
+		return svc.codeDraftDao.DeleteCodeDraftSnapshotsBefore(tx, userID, problemID, language, snapshot.Version-historySize+1)
+	})
+}
+
+// cacheCodeDraft 写入redis并标记为未保存
+func (svc *CodeDraftServiceImpl) cacheCodeDraft(userID uint, problemID uint, language string, code string) error {
+	member := getCodeDraftMember(userID, problemID, language)
+	expire := time.Duration(svc.config.DraftConfig.CacheExpire) * time.Second
+	_, err := db.Redis.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Set(CodeDraftKeyPrefix+member, code, expire)
+		pipe.SAdd(CodeDraftDirtyKey, member)
+		return nil
+	})
+	return err
+}
+
+func getCodeDraftMember(userID uint, problemID uint, language string) string {
+	return fmt.Sprintf("%d:%d:%s", userID, problemID, language)
+}
+
+// getUserCodeDraft 获取用户的草稿，优先读取redis中还未保存的代码，没有草稿时返回false
+func getUserCodeDraft(codeDraftDao dao.CodeDraftDao, userID uint, problemID uint, language string) (string, bool, error) {
+	code, err := db.Redis.Get(CodeDraftKeyPrefix + getCodeDraftMember(userID, problemID, language)).Result()
+	if err == nil {
+		return code, true, nil
+	}
+	if !errors.Is(err, redis.Nil) {
+		return "", false, err
+	}
+	draft, err := codeDraftDao.GetCodeDraft(db.Mysql, userID, problemID, language)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return draft.Code, true, nil
+}
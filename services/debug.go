@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"errors"
+	conf "funoj-backend/config"
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/dto"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DebugConn 调试使用的WebSocket连接
+type DebugConn interface {
+	ReadJSON(v interface{}) error
+	WriteJSON(v interface{}) error
+	SetReadDeadline(t time.Time) error
+	Close() error
+}
+
+// DebugService 在线调试，在沙箱中用gdb或delve运行用户代码，通过WebSocket收发json消息
+type DebugService interface {
+	// CheckDebugLanguage 检测语言是否支持调试，以及是否配置了沙箱
+	CheckDebugLanguage(language string) *e.Error
+	// ServeDebugSession 处理一次调试，直到连接断开、超时或程序结束后客户端发送stop，返回时调试器已被结束
+	ServeDebugSession(conn DebugConn, user *dto.UserInfo)
+}
+
+// debugStop 程序暂停或结束的状态
+type debugStop struct {
+	Exited   bool
+	ExitCode int
+	Location *dto.DebugFrame
+	// 暂停原因，比如收到信号
+	Reason string
+}
+
+// debugger 调试器，所有方法在同一个协程中调用
+type debugger interface {
+	// start 编译并启动程序，停在main函数入口
+	start(ctx context.Context) (*dto.DebugFrame, error)
+	setBreakpoint(line int) error
+	clearBreakpoint(line int) error
+	// resume 执行continue、next、step、stepOut
+	resume(command string) (*debugStop, error)
+	stack() ([]*dto.DebugFrame, error)
+	locals(frame int) ([]*dto.DebugVariable, error)
+	// traceLocals 可视化执行读取栈帧中的变量，同时返回非空指针变量
+	traceLocals(frame int) ([]*dto.TraceValue, []*tracePointer, error)
+	// readPointer 读取指针指向的堆对象，同时返回对象中的非空指针
+	readPointer(frame int, pointer *tracePointer) (*dto.TraceHeapObject, []*tracePointer, error)
+	close()
+}
+
+// tracePointer 非空指针，Type为调试器中指针的类型，用于读取指向的对象
+type tracePointer struct {
+	Address string
+	Type    string
+}
+
+// debugCompileError 编译失败，输出返回给用户
+type debugCompileError struct {
+	output string
+}
+
+func (err *debugCompileError) Error() string {
+	return err.output
+}
+
+// errDebugCommandTimeout 单条命令执行超时，通常是程序中有死循环
+var errDebugCommandTimeout = errors.New("命令执行超时")
+
+type DebugServiceImpl struct {
+	config *conf.AppConfig
+	mutex  sync.Mutex
+	// 用户id -> 正在进行的调试，每个用户同时只能有一个调试
+	sessions map[uint]*debugSlot
+	// 上一个调试名额的编号
+	lastToken uint64
+}
+
+// debugSlot 一个调试名额，token用于区分同一个用户先后进行的调试
+type debugSlot struct {
+	token  uint64
+	cancel context.CancelFunc
+}
+
+func NewDebugService(config *conf.AppConfig) DebugService {
+	return &DebugServiceImpl{
+		config:   config,
+		sessions: make(map[uint]*debugSlot),
+	}
+}
+
+func (svc *DebugServiceImpl) CheckDebugLanguage(language string) *e.Error {
+	if svc.config.DebugConfig.SandboxCommand == "" {
+		return e.ErrDebugNotAvailable
+	}
+	if !consts.IsDebugLanguageSupported(language) {
+		return e.ErrDebugNotSupported
+	}
+	return nil
+}
+
+func (svc *DebugServiceImpl) ServeDebugSession(conn DebugConn, user *dto.UserInfo) {
+	config := svc.config.DebugConfig
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Timeout)*time.Second)
+	defer cancel()
+	session := &debugSession{
+		conn:   conn,
+		config: config,
+	}
+	defer conn.Close()
+	token, err := svc.acquire(user.ID, cancel)
+	if err != nil {
+		session.sendError(0, err.Message)
+		return
+	}
+	defer svc.release(user.ID, token)
+	// 超时或被新的调试替换时关闭连接，使读取消息的循环退出
+	go func() {
+		<-ctx.Done()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			session.send(&dto.DebugResponse{Type: consts.DebugMsgTimeout, Message: "调试时间已用完"})
+		}
+		conn.Close()
+	}()
+	session.serve(ctx, svc)
+}
+
+// acquire 占用一个调试名额，用户已有的调试会被结束，返回名额的编号
+func (svc *DebugServiceImpl) acquire(userID uint, cancel context.CancelFunc) (uint64, *e.Error) {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+	if old, ok := svc.sessions[userID]; ok {
+		old.cancel()
+	} else if len(svc.sessions) >= svc.config.DebugConfig.MaxSessions {
+		return 0, e.ErrDebugSessionLimit
+	}
+	svc.lastToken++
+	svc.sessions[userID] = &debugSlot{token: svc.lastToken, cancel: cancel}
+	return svc.lastToken, nil
+}
+
+// release 释放调试名额，名额已被用户新的调试占用时不做处理
+func (svc *DebugServiceImpl) release(userID uint, token uint64) {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+	if slot, ok := svc.sessions[userID]; ok && slot.token == token {
+		delete(svc.sessions, userID)
+	}
+}
+
+type debugSession struct {
+	conn        DebugConn
+	config      *conf.DebugConfig
+	writeMutex  sync.Mutex
+	debugger    debugger
+	breakpoints map[int]bool
+}
+
+// send 调试器输出和命令结果在不同的协程中发送，需要加锁
+func (s *debugSession) send(response *dto.DebugResponse) {
+	s.writeMutex.Lock()
+	defer s.writeMutex.Unlock()
+	if err := s.conn.WriteJSON(response); err != nil {
+		log.Println(err)
+	}
+}
+
+func (s *debugSession) sendError(seq int, message string) {
+	s.send(&dto.DebugResponse{Type: consts.DebugMsgError, Seq: seq, Message: message})
+}
+
+func (s *debugSession) sendOutput(output string) {
+	s.send(&dto.DebugResponse{Type: consts.DebugMsgOutput, Output: output})
+}
+
+func (s *debugSession) read() (*dto.DebugRequest, error) {
+	if err := s.conn.SetReadDeadline(time.Now().Add(time.Duration(s.config.IdleTimeout) * time.Second)); err != nil {
+		return nil, err
+	}
+	request := &dto.DebugRequest{}
+	err := s.conn.ReadJSON(request)
+	return request, err
+}
+
+func (s *debugSession) serve(ctx context.Context, svc *DebugServiceImpl) {
+	request, err := s.read()
+	if err != nil {
+		return
+	}
+	if request.Type != consts.DebugMsgStart {
+		s.sendError(request.Seq, "第一条消息必须是start")
+		return
+	}
+	if err := svc.CheckDebugLanguage(request.Language); err != nil {
+		s.sendError(request.Seq, err.Message)
+		return
+	}
+	if request.Code == "" || len(request.Code) > consts.DebugMaxCodeSize || len(request.Input) > consts.DebugMaxCodeSize {
+		s.sendError(request.Seq, "代码或输入的长度不合法")
+		return
+	}
+	dir, err := os.MkdirTemp(svc.config.FilePathConfig.TempDir, "debug-")
+	if err != nil {
+		log.Println(err)
+		s.sendError(request.Seq, "创建调试环境失败")
+		return
+	}
+	defer os.RemoveAll(dir)
+	if s.debugger, err = newDebugger(s.config, dir, request.Language, request.Code, request.Input, s.sendOutput); err != nil {
+		log.Println(err)
+		s.sendError(request.Seq, "创建调试环境失败")
+		return
+	}
+	defer s.debugger.close()
+	location, err := s.debugger.start(ctx)
+	if err != nil {
+		var compileErr *debugCompileError
+		if errors.As(err, &compileErr) {
+			s.send(&dto.DebugResponse{Type: consts.DebugMsgError, Seq: request.Seq, Message: "编译失败", Output: compileErr.output})
+		} else {
+			log.Println(err)
+			s.sendError(request.Seq, "启动调试器失败")
+		}
+		return
+	}
+	s.breakpoints = make(map[int]bool)
+	for _, line := range request.Breakpoints {
+		if err = s.debugger.setBreakpoint(line); err == nil {
+			s.breakpoints[line] = true
+		}
+	}
+	s.send(&dto.DebugResponse{Type: consts.DebugMsgReady, Seq: request.Seq, Location: location, Breakpoints: s.getBreakpoints()})
+	for ctx.Err() == nil {
+		request, err = s.read()
+		if err != nil || request.Type == consts.DebugMsgStop {
+			return
+		}
+		if !s.handle(request) {
+			return
+		}
+	}
+}
+
+// handle 执行一条命令，返回false时结束调试
+func (s *debugSession) handle(request *dto.DebugRequest) bool {
+	response := &dto.DebugResponse{Type: request.Type, Seq: request.Seq}
+	var err error
+	switch request.Type {
+	case consts.DebugMsgBreak:
+		if err = s.debugger.setBreakpoint(request.Line); err == nil {
+			s.breakpoints[request.Line] = true
+			response.Breakpoints = s.getBreakpoints()
+		}
+	case consts.DebugMsgClear:
+		if err = s.debugger.clearBreakpoint(request.Line); err == nil {
+			delete(s.breakpoints, request.Line)
+			response.Breakpoints = s.getBreakpoints()
+		}
+	case consts.DebugMsgContinue, consts.DebugMsgNext, consts.DebugMsgStep, consts.DebugMsgStepOut:
+		var stop *debugStop
+		if stop, err = s.debugger.resume(request.Type); err == nil {
+			if stop.Exited {
+				exitCode := stop.ExitCode
+				response.Type = consts.DebugMsgExited
+				response.ExitCode = &exitCode
+			} else {
+				response.Type = consts.DebugMsgStopped
+				response.Location = stop.Location
+				response.Message = stop.Reason
+			}
+		}
+	case consts.DebugMsgStack:
+		response.Frames, err = s.debugger.stack()
+	case consts.DebugMsgLocals:
+		response.Variables, err = s.debugger.locals(request.Frame)
+	default:
+		s.sendError(request.Seq, "不支持的消息类型"+request.Type)
+		return true
+	}
+	if err != nil {
+		s.sendError(request.Seq, err.Error())
+		// 超时后调试器的状态未知，直接结束调试
+		return !errors.Is(err, errDebugCommandTimeout)
+	}
+	s.send(response)
+	return true
+}
+
+func (s *debugSession) getBreakpoints() []int {
+	lines := make([]int, 0, len(s.breakpoints))
+	for line := range s.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// newDebugger 保存代码和输入，根据语言创建调试器，程序的输出通过output返回
+func newDebugger(config *conf.DebugConfig, dir string, language string, code string, input string, output func(string)) (debugger, error) {
+	var fileName string
+	switch language {
+	case consts.ProgramC:
+		fileName = "main.c"
+	case consts.ProgramGo:
+		fileName = "main.go"
+	}
+	if err := os.WriteFile(filepath.Join(dir, fileName), []byte(code), 0644); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, debugInputFile), []byte(input), 0644); err != nil {
+		return nil, err
+	}
+	commandTimeout := time.Duration(config.CommandTimeout) * time.Second
+	if language == consts.ProgramC {
+		return newGdbDebugger(config, dir, fileName, commandTimeout, output), nil
+	}
+	return newDelveDebugger(config, dir, fileName, commandTimeout, output), nil
+}
+
+// debugInputFile 程序的标准输入
+const debugInputFile = "input.txt"
+
+// debugOutputFile gdb调试的程序的标准输出和标准错误
+const debugOutputFile = "output.txt"
+
+// newSandboxCommand 通过沙箱命令前缀启动程序，工作目录为调试目录
+func newSandboxCommand(ctx context.Context, config *conf.DebugConfig, dir string, command string, args ...string) *exec.Cmd {
+	fields := strings.Fields(config.SandboxCommand)
+	fields = append(fields, strings.Fields(command)...)
+	fields = append(fields, args...)
+	cmd := exec.CommandContext(ctx, fields[0], fields[1:]...)
+	cmd.Dir = dir
+	cmd.WaitDelay = time.Second
+	return cmd
+}
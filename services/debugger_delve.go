@@ -0,0 +1,414 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	conf "funoj-backend/config"
+	"funoj-backend/consts"
+	"funoj-backend/model/dto"
+	"io"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os/exec"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// delveListenPrefix delve启动完成后输出的监听地址
+const delveListenPrefix = "API server listening at: "
+
+// delveDebugger 通过delve的JSON-RPC接口调试go程序
+type delveDebugger struct {
+	config   *conf.DebugConfig
+	dir      string
+	fileName string
+	timeout  time.Duration
+	output   func(string)
+	cmd      *exec.Cmd
+	client   *rpc.Client
+	// 行号 -> 断点id
+	breakpoints map[int]int
+	closeOnce   sync.Once
+}
+
+// 以下结构与delve的api/rpc2中的定义对应，只保留使用的字段
+type delveLocation struct {
+	PC       uint64         `json:"pc"`
+	File     string         `json:"file"`
+	Line     int            `json:"line"`
+	Function *delveFunction `json:"function,omitempty"`
+}
+
+type delveFunction struct {
+	Name string `json:"name"`
+}
+
+type delveBreakpoint struct {
+	ID           int    `json:"id"`
+	FunctionName string `json:"functionName,omitempty"`
+	Addr         uint64 `json:"addr,omitempty"`
+}
+
+type delveState struct {
+	Exited        bool           `json:"exited"`
+	ExitStatus    int            `json:"exitStatus"`
+	CurrentThread *delveLocation `json:"currentThread"`
+}
+
+type delveVariable struct {
+	Name string `json:"name"`
+	Addr uint64 `json:"addr"`
+	Type string `json:"type"`
+	// reflect.Kind，指针的子变量为指向的对象
+	Kind     reflect.Kind     `json:"kind"`
+	Value    string           `json:"value"`
+	Children []*delveVariable `json:"children"`
+}
+
+type delveScope struct {
+	GoroutineID int64 `json:"GoroutineID"`
+	Frame       int   `json:"Frame"`
+}
+
+type delveLoadConfig struct {
+	FollowPointers     bool `json:"FollowPointers"`
+	MaxVariableRecurse int  `json:"MaxVariableRecurse"`
+	MaxStringLen       int  `json:"MaxStringLen"`
+	MaxArrayValues     int  `json:"MaxArrayValues"`
+	MaxStructFields    int  `json:"MaxStructFields"`
+}
+
+// delveVariableConfig 读取变量时的限制，避免大数组导致消息过大
+var delveVariableConfig = &delveLoadConfig{
+	FollowPointers:     true,
+	MaxVariableRecurse: 1,
+	MaxStringLen:       256,
+	MaxArrayValues:     64,
+	MaxStructFields:    -1,
+}
+
+func newDelveDebugger(config *conf.DebugConfig, dir string, fileName string, timeout time.Duration, output func(string)) debugger {
+	return &delveDebugger{
+		config:      config,
+		dir:         dir,
+		fileName:    fileName,
+		timeout:     timeout,
+		output:      output,
+		breakpoints: make(map[int]int),
+	}
+}
+
+func (d *delveDebugger) start(ctx context.Context) (*dto.DebugFrame, error) {
+	d.cmd = newSandboxCommand(ctx, d.config, d.dir, d.config.DelveCommand, "debug", d.fileName,
+		"--headless", "--api-version=2", "--listen=127.0.0.1:0", "-r", "stdin:"+debugInputFile)
+	stdout, err := d.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	d.cmd.Stderr = d.cmd.Stdout
+	if err = d.cmd.Start(); err != nil {
+		return nil, err
+	}
+	address, err := d.waitListen(stdout)
+	if err != nil {
+		return nil, err
+	}
+	if d.client, err = jsonrpc.Dial("tcp", address); err != nil {
+		return nil, err
+	}
+	// 程序停在运行时入口，先运行到main函数
+	in := map[string]interface{}{"Breakpoint": &delveBreakpoint{FunctionName: "main.main"}}
+	out := &struct{ Breakpoint *delveBreakpoint }{}
+	if err = d.call("CreateBreakpoint", in, out); err != nil {
+		return nil, err
+	}
+	stop, err := d.resume(consts.DebugMsgContinue)
+	if err != nil {
+		return nil, err
+	}
+	if err = d.call("ClearBreakpoint", map[string]interface{}{"Id": out.Breakpoint.ID}, &struct{}{}); err != nil {
+		return nil, err
+	}
+	if stop.Exited {
+		return nil, errors.New("程序没有main函数")
+	}
+	return stop.Location, nil
+}
+
+// waitListen 读取编译输出，直到delve输出监听地址，之后的输出都是程序的输出
+func (d *delveDebugger) waitListen(stdout io.Reader) (string, error) {
+	type listenResult struct {
+		address string
+		output  string
+	}
+	result := make(chan *listenResult, 1)
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		builder := &strings.Builder{}
+		sent := false
+		for scanner.Scan() {
+			line := scanner.Text()
+			if sent {
+				d.output(line + "\n")
+				continue
+			}
+			if strings.HasPrefix(line, delveListenPrefix) {
+				result <- &listenResult{address: strings.TrimSpace(strings.TrimPrefix(line, delveListenPrefix))}
+				sent = true
+				continue
+			}
+			builder.WriteString(line + "\n")
+		}
+		if !sent {
+			result <- &listenResult{output: builder.String()}
+		}
+	}()
+	// 包含编译时间
+	timer := time.NewTimer(3 * d.timeout)
+	defer timer.Stop()
+	select {
+	case r := <-result:
+		if r.address == "" {
+			return "", &debugCompileError{output: r.output}
+		}
+		return r.address, nil
+	case <-timer.C:
+		return "", errDebugCommandTimeout
+	}
+}
+
+func (d *delveDebugger) setBreakpoint(line int) error {
+	if _, ok := d.breakpoints[line]; ok {
+		return nil
+	}
+	// 通过位置表达式找到地址，不依赖沙箱中的文件路径
+	locations := &struct{ Locations []*delveLocation }{}
+	in := map[string]interface{}{
+		"Scope": &delveScope{GoroutineID: -1},
+		"Loc":   fmt.Sprintf("%s:%d", d.fileName, line),
+	}
+	if err := d.call("FindLocation", in, locations); err != nil {
+		return err
+	}
+	if len(locations.Locations) == 0 {
+		return fmt.Errorf("第%d行不能设置断点", line)
+	}
+	out := &struct{ Breakpoint *delveBreakpoint }{}
+	in = map[string]interface{}{"Breakpoint": &delveBreakpoint{Addr: locations.Locations[0].PC}}
+	if err := d.call("CreateBreakpoint", in, out); err != nil {
+		return err
+	}
+	d.breakpoints[line] = out.Breakpoint.ID
+	return nil
+}
+
+func (d *delveDebugger) clearBreakpoint(line int) error {
+	id, ok := d.breakpoints[line]
+	if !ok {
+		return nil
+	}
+	if err := d.call("ClearBreakpoint", map[string]interface{}{"Id": id}, &struct{}{}); err != nil {
+		return err
+	}
+	delete(d.breakpoints, line)
+	return nil
+}
+
+func (d *delveDebugger) resume(command string) (*debugStop, error) {
+	out := &struct{ State *delveState }{}
+	stop := &debugStop{}
+	if err := d.call("Command", map[string]interface{}{"Name": command}, out); err != nil {
+		// 程序结束后delve返回错误 Process 1 has exited with status 0
+		var pid int
+		if _, scanErr := fmt.Sscanf(err.Error(), "Process %d has exited with status %d", &pid, &stop.ExitCode); scanErr == nil {
+			stop.Exited = true
+			return stop, nil
+		}
+		return nil, err
+	}
+	if out.State == nil || out.State.Exited {
+		stop.Exited = true
+		if out.State != nil {
+			stop.ExitCode = out.State.ExitStatus
+		}
+		return stop, nil
+	}
+	if out.State.CurrentThread != nil {
+		stop.Location = newDelveFrame(0, out.State.CurrentThread)
+	}
+	return stop, nil
+}
+
+func (d *delveDebugger) stack() ([]*dto.DebugFrame, error) {
+	out := &struct{ Locations []*delveLocation }{}
+	if err := d.call("Stacktrace", map[string]interface{}{"Id": -1, "Depth": 50}, out); err != nil {
+		return nil, err
+	}
+	frames := make([]*dto.DebugFrame, 0, len(out.Locations))
+	for i, location := range out.Locations {
+		// 只显示用户代码中的栈帧
+		if !strings.HasSuffix(location.File, "/"+d.fileName) && location.File != d.fileName {
+			continue
+		}
+		frames = append(frames, newDelveFrame(i, location))
+	}
+	return frames, nil
+}
+
+func (d *delveDebugger) locals(frame int) ([]*dto.DebugVariable, error) {
+	all, err := d.loadLocals(frame)
+	if err != nil {
+		return nil, err
+	}
+	variables := make([]*dto.DebugVariable, len(all))
+	for i, variable := range all {
+		variables[i] = &dto.DebugVariable{
+			Name:  variable.Name,
+			Type:  variable.Type,
+			Value: formatDelveValue(variable, 0),
+		}
+	}
+	return variables, nil
+}
+
+// loadLocals 读取栈帧中的参数和局部变量
+func (d *delveDebugger) loadLocals(frame int) ([]*delveVariable, error) {
+	in := map[string]interface{}{
+		"Scope": &delveScope{GoroutineID: -1, Frame: frame},
+		"Cfg":   delveVariableConfig,
+	}
+	out := &struct{ Args []*delveVariable }{}
+	if err := d.call("ListFunctionArgs", in, out); err != nil {
+		return nil, err
+	}
+	locals := &struct{ Variables []*delveVariable }{}
+	if err := d.call("ListLocalVars", in, locals); err != nil {
+		return nil, err
+	}
+	return append(out.Args, locals.Variables...), nil
+}
+
+func (d *delveDebugger) traceLocals(frame int) ([]*dto.TraceValue, []*tracePointer, error) {
+	all, err := d.loadLocals(frame)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make([]*dto.TraceValue, len(all))
+	var pointers []*tracePointer
+	for i, variable := range all {
+		var pointer *tracePointer
+		values[i], pointer = newDelveTraceValue(variable)
+		if pointer != nil {
+			pointers = append(pointers, pointer)
+		}
+	}
+	return values, pointers, nil
+}
+
+// readPointer 按地址对指针指向的对象求值，结构体按字段展开
+func (d *delveDebugger) readPointer(frame int, pointer *tracePointer) (*dto.TraceHeapObject, []*tracePointer, error) {
+	in := map[string]interface{}{
+		"Scope": &delveScope{GoroutineID: -1, Frame: frame},
+		"Expr":  fmt.Sprintf("*(%s)(%s)", pointer.Type, pointer.Address),
+		"Cfg":   delveVariableConfig,
+	}
+	out := &struct{ Variable *delveVariable }{}
+	if err := d.call("Eval", in, out); err != nil {
+		return nil, nil, err
+	}
+	variable := out.Variable
+	if variable == nil {
+		return nil, nil, errors.New("读取变量失败")
+	}
+	object := &dto.TraceHeapObject{Type: variable.Type}
+	var pointers []*tracePointer
+	if variable.Kind != reflect.Struct {
+		value, child := newDelveTraceValue(variable)
+		object.Value = value.Value
+		if child != nil {
+			pointers = append(pointers, child)
+		}
+		return object, pointers, nil
+	}
+	for _, child := range variable.Children {
+		field, fieldPointer := newDelveTraceValue(child)
+		object.Fields = append(object.Fields, field)
+		if fieldPointer != nil {
+			pointers = append(pointers, fieldPointer)
+		}
+	}
+	return object, pointers, nil
+}
+
+// newDelveTraceValue 转换为可视化执行的变量，非空指针同时返回指针
+func newDelveTraceValue(variable *delveVariable) (*dto.TraceValue, *tracePointer) {
+	value := &dto.TraceValue{
+		Name:  variable.Name,
+		Type:  variable.Type,
+		Value: formatDelveValue(variable, 0),
+	}
+	if variable.Kind != reflect.Ptr || len(variable.Children) == 0 || variable.Children[0].Addr == 0 {
+		return value, nil
+	}
+	// 指针显示为地址，指向的对象放在堆中
+	value.Ref = fmt.Sprintf("%#x", variable.Children[0].Addr)
+	value.Value = value.Ref
+	return value, &tracePointer{Address: value.Ref, Type: variable.Type}
+}
+
+func (d *delveDebugger) close() {
+	d.closeOnce.Do(func() {
+		if d.client != nil {
+			_ = d.call("Detach", map[string]interface{}{"Kill": true}, &struct{}{})
+			_ = d.client.Close()
+		}
+		if d.cmd != nil && d.cmd.Process != nil {
+			_ = d.cmd.Process.Kill()
+			_ = d.cmd.Wait()
+		}
+	})
+}
+
+// call 调用delve的接口，超时返回errDebugCommandTimeout
+func (d *delveDebugger) call(method string, in interface{}, out interface{}) error {
+	call := d.client.Go("RPCServer."+method, in, out, make(chan *rpc.Call, 1))
+	timer := time.NewTimer(d.timeout)
+	defer timer.Stop()
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-timer.C:
+		return errDebugCommandTimeout
+	}
+}
+
+func newDelveFrame(level int, location *delveLocation) *dto.DebugFrame {
+	frame := &dto.DebugFrame{Level: level, Line: location.Line}
+	if location.Function != nil {
+		frame.FuncName = location.Function.Name
+	}
+	return frame
+}
+
+// formatDelveValue 复合类型的值为空，由子元素拼接
+func formatDelveValue(variable *delveVariable, depth int) string {
+	if variable.Value != "" || len(variable.Children) == 0 {
+		return variable.Value
+	}
+	if depth >= 2 {
+		return "{...}"
+	}
+	values := make([]string, len(variable.Children))
+	for i, child := range variable.Children {
+		value := formatDelveValue(child, depth+1)
+		if child.Name != "" && !strings.HasPrefix(child.Name, "[") {
+			value = child.Name + ": " + value
+		}
+		values[i] = value
+	}
+	return "{" + strings.Join(values, ", ") + "}"
+}
@@ -0,0 +1,629 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	conf "funoj-backend/config"
+	"funoj-backend/consts"
+	"funoj-backend/model/dto"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gdbDebugger 通过gdb的MI接口调试c程序
+type gdbDebugger struct {
+	config   *conf.DebugConfig
+	dir      string
+	fileName string
+	timeout  time.Duration
+	output   func(string)
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	// 程序的输出重定向到文件，gdb的标准输出只有MI记录
+	programOutput *os.File
+	token         int
+	// 命令结果，按token对应
+	results chan *miRecord
+	// 程序暂停的异步记录
+	stops chan *miRecord
+	// 行号 -> 断点编号
+	breakpoints map[int]string
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// miRecord gdb MI的一条记录，比如 1^done,bkpt={...}
+type miRecord struct {
+	Token int
+	Class string
+	Value map[string]interface{}
+}
+
+func newGdbDebugger(config *conf.DebugConfig, dir string, fileName string, timeout time.Duration, output func(string)) debugger {
+	return &gdbDebugger{
+		config:      config,
+		dir:         dir,
+		fileName:    fileName,
+		timeout:     timeout,
+		output:      output,
+		results:     make(chan *miRecord, 16),
+		stops:       make(chan *miRecord, 16),
+		breakpoints: make(map[int]string),
+		done:        make(chan struct{}),
+	}
+}
+
+func (d *gdbDebugger) start(ctx context.Context) (*dto.DebugFrame, error) {
+	compileCtx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	compile := newSandboxCommand(compileCtx, d.config, d.dir, d.config.GccCommand, "-g", "-O0", "-o", "main", d.fileName, "-lm")
+	if output, err := compile.CombinedOutput(); err != nil {
+		if len(output) == 0 {
+			return nil, err
+		}
+		return nil, &debugCompileError{output: string(output)}
+	}
+	d.cmd = newSandboxCommand(ctx, d.config, d.dir, d.config.GdbCommand, "--interpreter=mi2", "--quiet", "--nx", "./main")
+	var err error
+	if d.stdin, err = d.cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	stdout, err := d.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	d.cmd.Stderr = d.cmd.Stdout
+	if err = d.cmd.Start(); err != nil {
+		return nil, err
+	}
+	go d.readOutput(stdout)
+	// 先创建输出文件再打开，程序启动时shell重定向会清空同一个文件
+	outputPath := filepath.Join(d.dir, debugOutputFile)
+	if err = os.WriteFile(outputPath, nil, 0644); err != nil {
+		return nil, err
+	}
+	if d.programOutput, err = os.Open(outputPath); err != nil {
+		return nil, err
+	}
+	if _, err = d.command(fmt.Sprintf("-exec-arguments < %s > %s 2>&1", debugInputFile, debugOutputFile)); err != nil {
+		return nil, err
+	}
+	// --start 在main函数入口暂停
+	stop, err := d.run("-exec-run --start")
+	if err != nil {
+		return nil, err
+	}
+	if stop.Exited {
+		return nil, errors.New("程序没有main函数")
+	}
+	return stop.Location, nil
+}
+
+func (d *gdbDebugger) setBreakpoint(line int) error {
+	if _, ok := d.breakpoints[line]; ok {
+		return nil
+	}
+	record, err := d.command(fmt.Sprintf("-break-insert %s:%d", d.fileName, line))
+	if err != nil {
+		return err
+	}
+	bkpt, _ := record.Value["bkpt"].(map[string]interface{})
+	number, _ := bkpt["number"].(string)
+	d.breakpoints[line] = number
+	return nil
+}
+
+func (d *gdbDebugger) clearBreakpoint(line int) error {
+	number, ok := d.breakpoints[line]
+	if !ok {
+		return nil
+	}
+	if _, err := d.command("-break-delete " + number); err != nil {
+		return err
+	}
+	delete(d.breakpoints, line)
+	return nil
+}
+
+func (d *gdbDebugger) resume(command string) (*debugStop, error) {
+	commands := map[string]string{
+		consts.DebugMsgContinue: "-exec-continue",
+		consts.DebugMsgNext:     "-exec-next",
+		consts.DebugMsgStep:     "-exec-step",
+		consts.DebugMsgStepOut:  "-exec-finish",
+	}
+	return d.run(commands[command])
+}
+
+func (d *gdbDebugger) stack() ([]*dto.DebugFrame, error) {
+	record, err := d.command("-stack-list-frames")
+	if err != nil {
+		return nil, err
+	}
+	list, _ := record.Value["stack"].([]interface{})
+	frames := make([]*dto.DebugFrame, 0, len(list))
+	for _, item := range list {
+		if frame, ok := item.(map[string]interface{}); ok {
+			frames = append(frames, newGdbFrame(frame))
+		}
+	}
+	return frames, nil
+}
+
+func (d *gdbDebugger) locals(frame int) ([]*dto.DebugVariable, error) {
+	record, err := d.command(fmt.Sprintf("-stack-list-variables --thread 1 --frame %d --simple-values", frame))
+	if err != nil {
+		return nil, err
+	}
+	list, _ := record.Value["variables"].([]interface{})
+	variables := make([]*dto.DebugVariable, 0, len(list))
+	for _, item := range list {
+		variable, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := variable["name"].(string)
+		typeName, _ := variable["type"].(string)
+		value, ok := variable["value"].(string)
+		if !ok {
+			// 数组和结构体需要单独求值
+			value, _ = d.evaluate(frame, name)
+		}
+		variables = append(variables, &dto.DebugVariable{Name: name, Type: typeName, Value: value})
+	}
+	return variables, nil
+}
+
+func (d *gdbDebugger) traceLocals(frame int) ([]*dto.TraceValue, []*tracePointer, error) {
+	variables, err := d.locals(frame)
+	if err != nil {
+		return nil, nil, err
+	}
+	values := make([]*dto.TraceValue, len(variables))
+	var pointers []*tracePointer
+	for i, variable := range variables {
+		values[i] = &dto.TraceValue{Name: variable.Name, Type: variable.Type, Value: variable.Value}
+		if address := getGdbPointerAddress(variable.Type, variable.Value); address != "" {
+			values[i].Ref = address
+			pointers = append(pointers, &tracePointer{Address: address, Type: variable.Type})
+		}
+	}
+	return values, pointers, nil
+}
+
+// readPointer 通过变量对象读取结构体的字段，其它类型直接求值
+func (d *gdbDebugger) readPointer(frame int, pointer *tracePointer) (*dto.TraceHeapObject, []*tracePointer, error) {
+	target := fmt.Sprintf("((%s) %s)", pointer.Type, pointer.Address)
+	record, err := d.command(fmt.Sprintf("-var-create --thread 1 --frame %d - * %s", frame, strconv.Quote("*"+target)))
+	if err != nil {
+		return nil, nil, err
+	}
+	name, _ := record.Value["name"].(string)
+	defer func() {
+		if _, err := d.command("-var-delete " + name); err != nil {
+			log.Println(err)
+		}
+	}()
+	object := &dto.TraceHeapObject{}
+	object.Type, _ = record.Value["type"].(string)
+	// 基本类型和指向指针的指针直接求值，结构体和联合体按字段展开
+	if numChild, _ := record.Value["numchild"].(string); numChild == "0" || strings.HasSuffix(strings.TrimSpace(object.Type), "*") {
+		if object.Value, err = d.evaluate(frame, strconv.Quote("*"+target)); err != nil {
+			return nil, nil, err
+		}
+		var pointers []*tracePointer
+		if address := getGdbPointerAddress(object.Type, object.Value); address != "" {
+			pointers = append(pointers, &tracePointer{Address: address, Type: object.Type})
+		}
+		return object, pointers, nil
+	}
+	record, err = d.command("-var-list-children --simple-values " + name)
+	if err != nil {
+		return nil, nil, err
+	}
+	children, _ := record.Value["children"].([]interface{})
+	var pointers []*tracePointer
+	for _, item := range children {
+		child, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		field := &dto.TraceValue{}
+		field.Name, _ = child["exp"].(string)
+		field.Type, _ = child["type"].(string)
+		value, ok := child["value"].(string)
+		if !ok {
+			// 数组和结构体字段需要单独求值
+			if value, err = d.evaluate(frame, strconv.Quote(target+"->"+field.Name)); err != nil {
+				return nil, nil, err
+			}
+		}
+		field.Value = value
+		if address := getGdbPointerAddress(field.Type, field.Value); address != "" {
+			field.Ref = address
+			pointers = append(pointers, &tracePointer{Address: address, Type: field.Type})
+		}
+		object.Fields = append(object.Fields, field)
+	}
+	return object, pointers, nil
+}
+
+// getGdbPointerAddress 获取非空指针的地址，字符串和void指针不展开，返回空字符串
+func getGdbPointerAddress(typeName string, value string) string {
+	typeName = strings.TrimSpace(typeName)
+	if !strings.HasSuffix(typeName, "*") {
+		return ""
+	}
+	switch strings.TrimSpace(strings.TrimSuffix(typeName, "*")) {
+	case "char", "const char", "unsigned char", "void", "const void":
+		return ""
+	}
+	// 指针的值为地址，后面可能有符号名，比如 0x4005d0 <node>
+	fields := strings.Fields(value)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "0x") {
+		return ""
+	}
+	if address, err := strconv.ParseUint(fields[0][2:], 16, 64); err != nil || address == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+func (d *gdbDebugger) evaluate(frame int, expression string) (string, error) {
+	record, err := d.command(fmt.Sprintf("-data-evaluate-expression --thread 1 --frame %d %s", frame, expression))
+	if err != nil {
+		return "", err
+	}
+	value, _ := record.Value["value"].(string)
+	return value, nil
+}
+
+func (d *gdbDebugger) close() {
+	d.closeOnce.Do(func() {
+		close(d.done)
+		if d.stdin != nil {
+			_, _ = io.WriteString(d.stdin, "-gdb-exit\n")
+			_ = d.stdin.Close()
+		}
+		if d.cmd != nil && d.cmd.Process != nil {
+			_ = d.cmd.Process.Kill()
+			_ = d.cmd.Wait()
+		}
+		if d.programOutput != nil {
+			_ = d.programOutput.Close()
+		}
+	})
+}
+
+// command 发送命令并等待结果，gdb返回^error时将错误信息返回
+func (d *gdbDebugger) command(command string) (*miRecord, error) {
+	d.token++
+	token := d.token
+	if _, err := io.WriteString(d.stdin, strconv.Itoa(token)+command+"\n"); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(d.timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case record, ok := <-d.results:
+			if !ok {
+				return nil, errors.New("调试器已退出")
+			}
+			if record.Token != token {
+				continue
+			}
+			if record.Class == "error" {
+				msg, _ := record.Value["msg"].(string)
+				return nil, errors.New(msg)
+			}
+			return record, nil
+		case <-timer.C:
+			return nil, errDebugCommandTimeout
+		}
+	}
+}
+
+// run 执行让程序运行的命令，等待程序暂停或结束
+func (d *gdbDebugger) run(command string) (*debugStop, error) {
+	// 清除之前未处理的暂停记录
+	for len(d.stops) > 0 {
+		<-d.stops
+	}
+	if _, err := d.command(command); err != nil {
+		return nil, err
+	}
+	timer := time.NewTimer(d.timeout)
+	defer timer.Stop()
+	select {
+	case record, ok := <-d.stops:
+		if !ok {
+			return nil, errors.New("调试器已退出")
+		}
+		// 程序暂停时读取这段时间的输出，输出和暂停的位置对应
+		d.readProgramOutput()
+		return newGdbStop(record), nil
+	case <-timer.C:
+		return nil, errDebugCommandTimeout
+	}
+}
+
+// readProgramOutput 读取输出文件中新增的内容
+func (d *gdbDebugger) readProgramOutput() {
+	buffer := make([]byte, 32*1024)
+	for {
+		n, err := d.programOutput.Read(buffer)
+		if n > 0 {
+			d.output(string(buffer[:n]))
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				log.Println(err)
+			}
+			return
+		}
+	}
+}
+
+// readOutput 读取gdb的输出，MI记录分发给命令，程序的输出在单独的文件中，其它内容忽略
+func (d *gdbDebugger) readOutput(stdout io.Reader) {
+	defer close(d.results)
+	defer close(d.stops)
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		record, ok := parseMiRecord(line)
+		if !ok {
+			continue
+		}
+		var target chan *miRecord
+		switch {
+		case record == nil:
+			continue
+		case record.Class == "stopped":
+			target = d.stops
+		case record.Token > 0:
+			target = d.results
+		default:
+			continue
+		}
+		select {
+		case target <- record:
+		case <-d.done:
+			return
+		}
+	}
+}
+
+func newGdbStop(record *miRecord) *debugStop {
+	stop := &debugStop{}
+	reason, _ := record.Value["reason"].(string)
+	switch reason {
+	case "exited-normally":
+		stop.Exited = true
+	case "exited":
+		stop.Exited = true
+		// 退出码为八进制
+		exitCode, _ := record.Value["exit-code"].(string)
+		code, _ := strconv.ParseInt(exitCode, 8, 32)
+		stop.ExitCode = int(code)
+	case "exited-signalled":
+		stop.Exited = true
+		stop.ExitCode = -1
+		stop.Reason, _ = record.Value["signal-name"].(string)
+	case "signal-received":
+		stop.Reason, _ = record.Value["signal-name"].(string)
+	}
+	if frame, ok := record.Value["frame"].(map[string]interface{}); ok {
+		stop.Location = newGdbFrame(frame)
+	}
+	return stop
+}
+
+func newGdbFrame(frame map[string]interface{}) *dto.DebugFrame {
+	answer := &dto.DebugFrame{}
+	answer.FuncName, _ = frame["func"].(string)
+	if level, ok := frame["level"].(string); ok {
+		answer.Level, _ = strconv.Atoi(level)
+	}
+	if line, ok := frame["line"].(string); ok {
+		answer.Line, _ = strconv.Atoi(line)
+	}
+	return answer
+}
+
+// parseMiRecord 解析一行MI输出，不是MI格式时返回false，不需要处理的记录返回nil
+func parseMiRecord(line string) (*miRecord, bool) {
+	if strings.TrimSpace(line) == "(gdb)" {
+		return nil, true
+	}
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i >= len(line) {
+		return nil, false
+	}
+	token, _ := strconv.Atoi(line[:i])
+	rest := line[i+1:]
+	switch line[i] {
+	case '~', '&', '@':
+		// 控制台输出、日志和程序输出，程序输出已重定向到文件，不需要处理
+		if _, ok := parseMiString(rest); !ok || i > 0 {
+			return nil, false
+		}
+		return nil, true
+	case '^', '*', '=', '+':
+		class := rest
+		values := ""
+		if comma := strings.IndexByte(rest, ','); comma >= 0 {
+			class, values = rest[:comma], rest[comma+1:]
+		}
+		if !isMiClass(class) {
+			return nil, false
+		}
+		parser := &miParser{s: values}
+		value, err := parser.parseResults(0)
+		if err != nil {
+			return nil, false
+		}
+		if line[i] == '=' || line[i] == '+' {
+			return nil, true
+		}
+		return &miRecord{Token: token, Class: class, Value: value}, true
+	}
+	return nil, false
+}
+
+// isMiClass 记录类型由小写字母和-组成
+func isMiClass(class string) bool {
+	if class == "" {
+		return false
+	}
+	for _, c := range class {
+		if (c < 'a' || c > 'z') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+func parseMiString(s string) (string, bool) {
+	parser := &miParser{s: s}
+	value, err := parser.parseString()
+	return value, err == nil && parser.i == len(s)
+}
+
+// miParser 解析MI的值：字符串、{}元组和[]列表
+type miParser struct {
+	s string
+	i int
+}
+
+var errMiSyntax = errors.New("mi syntax error")
+
+// parseResults 解析 name=value,name=value，end为结束字符，0表示到字符串末尾
+func (p *miParser) parseResults(end byte) (map[string]interface{}, error) {
+	results := make(map[string]interface{})
+	for p.i < len(p.s) && p.s[p.i] != end {
+		name, value, err := p.parseResult()
+		if err != nil {
+			return nil, err
+		}
+		results[name] = value
+		if p.i < len(p.s) && p.s[p.i] == ',' {
+			p.i++
+		}
+	}
+	return results, nil
+}
+
+func (p *miParser) parseResult() (string, interface{}, error) {
+	eq := strings.IndexByte(p.s[p.i:], '=')
+	if eq <= 0 {
+		return "", nil, errMiSyntax
+	}
+	name := p.s[p.i : p.i+eq]
+	p.i += eq + 1
+	value, err := p.parseValue()
+	return name, value, err
+}
+
+func (p *miParser) parseValue() (interface{}, error) {
+	if p.i >= len(p.s) {
+		return nil, errMiSyntax
+	}
+	switch p.s[p.i] {
+	case '"':
+		return p.parseString()
+	case '{':
+		p.i++
+		value, err := p.parseResults('}')
+		if err != nil || p.i >= len(p.s) {
+			return nil, errMiSyntax
+		}
+		p.i++
+		return value, nil
+	case '[':
+		p.i++
+		list := []interface{}{}
+		for p.i < len(p.s) && p.s[p.i] != ']' {
+			var item interface{}
+			var err error
+			if c := p.s[p.i]; c == '"' || c == '{' || c == '[' {
+				item, err = p.parseValue()
+			} else {
+				// 列表中的 name=value 只保留值
+				_, item, err = p.parseResult()
+			}
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+			if p.i < len(p.s) && p.s[p.i] == ',' {
+				p.i++
+			}
+		}
+		if p.i >= len(p.s) {
+			return nil, errMiSyntax
+		}
+		p.i++
+		return list, nil
+	}
+	return nil, errMiSyntax
+}
+
+func (p *miParser) parseString() (string, error) {
+	if p.i >= len(p.s) || p.s[p.i] != '"' {
+		return "", errMiSyntax
+	}
+	p.i++
+	builder := &strings.Builder{}
+	for p.i < len(p.s) {
+		c := p.s[p.i]
+		p.i++
+		switch c {
+		case '"':
+			return builder.String(), nil
+		case '\\':
+			if p.i >= len(p.s) {
+				return "", errMiSyntax
+			}
+			escaped := p.s[p.i]
+			p.i++
+			switch escaped {
+			case 'n':
+				builder.WriteByte('\n')
+			case 't':
+				builder.WriteByte('\t')
+			case 'r':
+				builder.WriteByte('\r')
+			case '0', '1', '2', '3':
+				// 八进制转义
+				if p.i+2 <= len(p.s) {
+					if n, err := strconv.ParseUint(p.s[p.i-1:p.i+2], 8, 8); err == nil {
+						builder.WriteByte(byte(n))
+						p.i += 2
+						continue
+					}
+				}
+				builder.WriteByte(escaped)
+			default:
+				builder.WriteByte(escaped)
+			}
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return "", errMiSyntax
+}
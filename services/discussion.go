@@ -0,0 +1,431 @@
+package services
+
+import (
+	"errors"
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/model/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"strings"
+	"time"
+)
+
+// DiscussionService 题目讨论区
+type DiscussionService interface {
+	// GetDiscussionList 分页获取题目的讨论帖，未通过题目的用户看不到包含剧透的帖子
+	GetDiscussionList(ctx *gin.Context, query *request.PageQuery) (*response.PageInfo, *e.Error)
+	// GetDiscussionByID 获取讨论帖详细信息
+	GetDiscussionByID(ctx *gin.Context, id uint) (*dto.DiscussionDto, *e.Error)
+	// InsertDiscussion 发布讨论帖
+	InsertDiscussion(ctx *gin.Context, discussion *repository.Discussion) (uint, *e.Error)
+	// UpdateDiscussion 作者修改讨论帖
+	UpdateDiscussion(ctx *gin.Context, discussion *repository.Discussion) *e.Error
+	// DeleteDiscussion 作者或管理员删除讨论帖
+	DeleteDiscussion(ctx *gin.Context, id uint) *e.Error
+	// PinDiscussion 管理员置顶或取消置顶讨论帖
+	PinDiscussion(ctx *gin.Context, id uint, pinned bool) *e.Error
+	// UpvoteDiscussion 点赞或取消点赞讨论帖，返回操作后是否为点赞状态
+	UpvoteDiscussion(ctx *gin.Context, id uint) (bool, *e.Error)
+	// GetDiscussionCommentList 分页获取讨论帖的一级回复，以及一级回复下的所有回复
+	GetDiscussionCommentList(ctx *gin.Context, query *request.PageQuery) (*response.PageInfo, *e.Error)
+	// InsertDiscussionComment 回复讨论帖或者回复
+	InsertDiscussionComment(ctx *gin.Context, comment *repository.DiscussionComment) (uint, *e.Error)
+	// DeleteDiscussionComment 作者或管理员删除回复
+	DeleteDiscussionComment(ctx *gin.Context, id uint) *e.Error
+	// UpvoteDiscussionComment 点赞或取消点赞回复，返回操作后是否为点赞状态
+	UpvoteDiscussionComment(ctx *gin.Context, id uint) (bool, *e.Error)
+}
+
+type DiscussionServiceImpl struct {
+	discussionDao        dao.DiscussionDao
+	discussionCommentDao dao.DiscussionCommentDao
+	problemAttemptDao    dao.ProblemAttemptDao
+	sysUserDao           dao.SysUserDao
+}
+
+func NewDiscussionService(dd dao.DiscussionDao, dcd dao.DiscussionCommentDao, pad dao.ProblemAttemptDao, sud dao.SysUserDao) DiscussionService {
+	return &DiscussionServiceImpl{
+		discussionDao:        dd,
+		discussionCommentDao: dcd,
+		problemAttemptDao:    pad,
+		sysUserDao:           sud,
+	}
+}
+
+func (svc *DiscussionServiceImpl) GetDiscussionList(ctx *gin.Context, query *request.PageQuery) (*response.PageInfo, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	var discussionQuery *request.DiscussionForList
+	if query.Query != nil {
+		discussionQuery = query.Query.(*request.DiscussionForList)
+	} else {
+		discussionQuery = &request.DiscussionForList{}
+		query.Query = discussionQuery
+	}
+	canSeeSpoiler, err := svc.checkSpoilerVisible(user, discussionQuery.ProblemID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	discussionQuery.WithSpoiler = canSeeSpoiler
+	discussions, err := svc.discussionDao.GetDiscussionList(db.Mysql, query)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	newDiscussions := make([]*dto.DiscussionDtoForList, len(discussions))
+	for i := 0; i < len(discussions); i++ {
+		newDiscussions[i] = dto.NewDiscussionDtoForList(discussions[i])
+		newDiscussions[i].UserName, err = svc.sysUserDao.GetUserNameByID(db.Mysql, discussions[i].UserID)
+		if err != nil {
+			return nil, e.ErrMysql
+		}
+	}
+	var count int64
+	count, err = svc.discussionDao.GetDiscussionCount(db.Mysql, discussionQuery)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return &response.PageInfo{
+		Total: count,
+		Size:  int64(len(newDiscussions)),
+		List:  newDiscussions,
+	}, nil
+}
+
+func (svc *DiscussionServiceImpl) GetDiscussionByID(ctx *gin.Context, id uint) (*dto.DiscussionDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	discussion, svcErr := svc.getVisibleDiscussion(user, id)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+	var err error
+	discussionDto := dto.NewDiscussionDto(discussion)
+	discussionDto.UserName, err = svc.sysUserDao.GetUserNameByID(db.Mysql, discussion.UserID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return discussionDto, nil
+}
+
+func (svc *DiscussionServiceImpl) InsertDiscussion(ctx *gin.Context, discussion *repository.Discussion) (uint, *e.Error) {
+	if strings.TrimSpace(discussion.Title) == "" || strings.TrimSpace(discussion.Content) == "" {
+		return 0, e.ErrDiscussionContentEmpty
+	}
+	discussion.UserID = ctx.Keys["user"].(*dto.UserInfo).ID
+	discussion.Pinned = false
+	discussion.UpvoteCount = 0
+	discussion.CommentCount = 0
+	if err := svc.discussionDao.InsertDiscussion(db.Mysql, discussion); err != nil {
+		log.Println(err)
+		return 0, e.ErrMysql
+	}
+	return discussion.ID, nil
+}
+
+func (svc *DiscussionServiceImpl) UpdateDiscussion(ctx *gin.Context, discussion *repository.Discussion) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if strings.TrimSpace(discussion.Title) == "" || strings.TrimSpace(discussion.Content) == "" {
+		return e.ErrDiscussionContentEmpty
+	}
+	old, err := svc.discussionDao.GetDiscussionByID(db.Mysql, discussion.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.ErrDiscussionNotExist
+	}
+	if err != nil {
+		return e.ErrMysql
+	}
+	if old.UserID != user.ID {
+		return e.ErrPermissionInvalid
+	}
+	discussion.UpdatedAt = time.Now()
+	if err = svc.discussionDao.UpdateDiscussion(db.Mysql, discussion); err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *DiscussionServiceImpl) DeleteDiscussion(ctx *gin.Context, id uint) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	discussion, err := svc.discussionDao.GetDiscussionByID(db.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.ErrDiscussionNotExist
+	}
+	if err != nil {
+		return e.ErrMysql
+	}
+	if discussion.UserID != user.ID && !isAdmin(user) {
+		return e.ErrPermissionInvalid
+	}
+	if err = svc.discussionDao.DeleteDiscussionByID(db.Mysql, id); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *DiscussionServiceImpl) PinDiscussion(ctx *gin.Context, id uint, pinned bool) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if !isAdmin(user) {
+		return e.ErrPermissionInvalid
+	}
+	if _, err := svc.getVisibleDiscussion(user, id); err != nil {
+		return err
+	}
+	if err := svc.discussionDao.SetDiscussionPinned(db.Mysql, id, pinned); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *DiscussionServiceImpl) UpvoteDiscussion(ctx *gin.Context, id uint) (bool, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if _, err := svc.getVisibleDiscussion(user, id); err != nil {
+		return false, err
+	}
+	upvoted, err := svc.toggleUpvote(consts.UpvoteTargetDiscussion, id, user.ID,
+		svc.discussionDao.IncreaseDiscussionUpvoteCount)
+	if err != nil {
+		log.Println(err)
+		return false, e.ErrMysql
+	}
+	return upvoted, nil
+}
+
+func (svc *DiscussionServiceImpl) GetDiscussionCommentList(ctx *gin.Context, query *request.PageQuery) (*response.PageInfo, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	var commentQuery *request.DiscussionCommentForList
+	if query.Query != nil {
+		commentQuery = query.Query.(*request.DiscussionCommentForList)
+	}
+	if commentQuery == nil || commentQuery.DiscussionID == 0 {
+		return nil, e.ErrBadRequest
+	}
+	discussion, svcErr := svc.getVisibleDiscussion(user, commentQuery.DiscussionID)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+	canSeeSpoiler, err := svc.checkSpoilerVisible(user, discussion.ProblemID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	// 读取一级回复以及其下的所有回复
+	roots, err := svc.discussionCommentDao.GetRootDiscussionCommentList(db.Mysql, query)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	rootIDs := make([]uint, len(roots))
+	for i, root := range roots {
+		rootIDs[i] = root.ID
+	}
+	replies, err := svc.discussionCommentDao.GetDiscussionCommentsByRootIDs(db.Mysql, rootIDs)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	userNames := make(map[uint]string)
+	rootMap := make(map[uint]*dto.DiscussionCommentDto, len(roots))
+	newComments := make([]*dto.DiscussionCommentDto, len(roots))
+	for i, root := range roots {
+		newComments[i], err = svc.newDiscussionCommentDto(root, user, canSeeSpoiler, userNames)
+		if err != nil {
+			return nil, e.ErrMysql
+		}
+		newComments[i].Replies = []*dto.DiscussionCommentDto{}
+		rootMap[root.ID] = newComments[i]
+	}
+	for _, reply := range replies {
+		replyDto, err := svc.newDiscussionCommentDto(reply, user, canSeeSpoiler, userNames)
+		if err != nil {
+			return nil, e.ErrMysql
+		}
+		if root, ok := rootMap[reply.RootID]; ok {
+			root.Replies = append(root.Replies, replyDto)
+		}
+	}
+	var count int64
+	count, err = svc.discussionCommentDao.GetRootDiscussionCommentCount(db.Mysql, commentQuery)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return &response.PageInfo{
+		Total: count,
+		Size:  int64(len(newComments)),
+		List:  newComments,
+	}, nil
+}
+
+func (svc *DiscussionServiceImpl) InsertDiscussionComment(ctx *gin.Context, comment *repository.DiscussionComment) (uint, *e.Error) {
+	if strings.TrimSpace(comment.Content) == "" {
+		return 0, e.ErrDiscussionContentEmpty
+	}
+	if _, err := svc.getVisibleDiscussion(ctx.Keys["user"].(*dto.UserInfo), comment.DiscussionID); err != nil {
+		return 0, err
+	}
+	// 回复的是另一条回复，挂到对应的一级回复下
+	comment.RootID = 0
+	if comment.ParentID != 0 {
+		parent, err := svc.discussionCommentDao.GetDiscussionCommentByID(db.Mysql, comment.ParentID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, e.ErrDiscussionCommentNotExist
+		}
+		if err != nil {
+			return 0, e.ErrMysql
+		}
+		if parent.DiscussionID != comment.DiscussionID {
+			return 0, e.ErrBadRequest
+		}
+		comment.RootID = parent.RootID
+		if comment.RootID == 0 {
+			comment.RootID = parent.ID
+		}
+	}
+	comment.UserID = ctx.Keys["user"].(*dto.UserInfo).ID
+	comment.UpvoteCount = 0
+	err := db.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := svc.discussionCommentDao.InsertDiscussionComment(tx, comment); err != nil {
+			return err
+		}
+		return svc.discussionDao.IncreaseDiscussionCommentCount(tx, comment.DiscussionID, 1)
+	})
+	if err != nil {
+		log.Println(err)
+		return 0, e.ErrMysql
+	}
+	return comment.ID, nil
+}
+
+func (svc *DiscussionServiceImpl) DeleteDiscussionComment(ctx *gin.Context, id uint) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	comment, err := svc.discussionCommentDao.GetDiscussionCommentByID(db.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.ErrDiscussionCommentNotExist
+	}
+	if err != nil {
+		return e.ErrMysql
+	}
+	if comment.UserID != user.ID && !isAdmin(user) {
+		return e.ErrPermissionInvalid
+	}
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := svc.discussionCommentDao.DeleteDiscussionCommentByID(tx, id); err != nil {
+			return err
+		}
+		return svc.discussionDao.IncreaseDiscussionCommentCount(tx, comment.DiscussionID, -1)
+	})
+	if err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *DiscussionServiceImpl) UpvoteDiscussionComment(ctx *gin.Context, id uint) (bool, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	comment, err := svc.discussionCommentDao.GetDiscussionCommentByID(db.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, e.ErrDiscussionCommentNotExist
+	}
+	if err != nil {
+		return false, e.ErrMysql
+	}
+	if _, svcErr := svc.getVisibleDiscussion(user, comment.DiscussionID); svcErr != nil {
+		return false, svcErr
+	}
+	upvoted, err := svc.toggleUpvote(consts.UpvoteTargetComment, id, user.ID,
+		svc.discussionCommentDao.IncreaseDiscussionCommentUpvoteCount)
+	if err != nil {
+		log.Println(err)
+		return false, e.ErrMysql
+	}
+	return upvoted, nil
+}
+
+// toggleUpvote 切换点赞状态，已点赞则取消，并通过increase同步点赞数
+func (svc *DiscussionServiceImpl) toggleUpvote(targetType string, targetID uint, userID uint,
+	increase func(db *gorm.DB, id uint, delta int) error) (bool, error) {
+	upvoted := false
+	err := db.Mysql.Transaction(func(tx *gorm.DB) error {
+		deleted, err := svc.discussionDao.DeleteUpvote(tx, targetType, targetID, userID)
+		if err != nil {
+			return err
+		}
+		if deleted != 0 {
+			return increase(tx, targetID, -1)
+		}
+		upvoted = true
+		if err = svc.discussionDao.InsertUpvote(tx, &repository.DiscussionUpvote{
+			TargetType: targetType,
+			TargetID:   targetID,
+			UserID:     userID,
+		}); err != nil {
+			return err
+		}
+		return increase(tx, targetID, 1)
+	})
+	return upvoted, err
+}
+
+// getVisibleDiscussion 获取讨论帖，剧透的帖子只有作者和能看到剧透的用户可以获取，帖子下的回复、点赞和置顶同样受限
+func (svc *DiscussionServiceImpl) getVisibleDiscussion(user *dto.UserInfo, id uint) (*repository.Discussion, *e.Error) {
+	discussion, err := svc.discussionDao.GetDiscussionByID(db.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrDiscussionNotExist
+	}
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	if discussion.Spoiler && discussion.UserID != user.ID {
+		canSeeSpoiler, err := svc.checkSpoilerVisible(user, discussion.ProblemID)
+		if err != nil {
+			return nil, e.ErrMysql
+		}
+		if !canSeeSpoiler {
+			return nil, e.ErrDiscussionSpoiler
+		}
+	}
+	return discussion, nil
+}
+
+// checkSpoilerVisible 检验用户能否看到题目下包含剧透的内容，管理员和已通过题目的用户可以看到
+func (svc *DiscussionServiceImpl) checkSpoilerVisible(user *dto.UserInfo, problemID uint) (bool, error) {
+	if isAdmin(user) {
+		return true, nil
+	}
+	if problemID == 0 {
+		return false, nil
+	}
+	status, err := svc.problemAttemptDao.GetProblemAttemptStatus(db.Mysql, user.ID, problemID)
+	if err != nil {
+		return false, err
+	}
+	return status == consts.AttemptStatusAccepted, nil
+}
+
+// newDiscussionCommentDto 构建回复dto，已删除的回复和剧透的回复不返回内容
+func (svc *DiscussionServiceImpl) newDiscussionCommentDto(comment *repository.DiscussionComment, user *dto.UserInfo,
+	canSeeSpoiler bool, userNames map[uint]string) (*dto.DiscussionCommentDto, error) {
+	commentDto := dto.NewDiscussionCommentDto(comment)
+	if commentDto.Deleted {
+		commentDto.Content = ""
+		return commentDto, nil
+	}
+	if comment.Spoiler && !canSeeSpoiler && comment.UserID != user.ID {
+		commentDto.Hidden = true
+		commentDto.Content = ""
+	}
+	name, ok := userNames[comment.UserID]
+	if !ok {
+		var err error
+		name, err = svc.sysUserDao.GetUserNameByID(db.Mysql, comment.UserID)
+		if err != nil {
+			return nil, err
+		}
+		userNames[comment.UserID] = name
+	}
+	commentDto.UserName = name
+	return commentDto, nil
+}
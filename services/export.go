@@ -0,0 +1,103 @@
+package services
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"funoj-backend/consts"
+	"io"
+)
+
+// exportFlushSize 每写入多少条数据刷新一次输出
+const exportFlushSize = 100
+
+// exportWriter 导出数据时按格式逐条写入，csv使用record，json使用item
+type exportWriter interface {
+	begin() error
+	write(item interface{}, record []string) error
+	end() error
+}
+
+// newExportWriter 根据格式创建，header为csv的表头，format需要先经过consts.IsExportFormatSupported检验
+func newExportWriter(format string, w io.Writer, header []string) exportWriter {
+	if format == consts.ExportFormatCsv {
+		return &csvExportWriter{w: w, csv: csv.NewWriter(w), header: header}
+	}
+	return &jsonExportWriter{w: w}
+}
+
+// flushWriter 输出为http响应时把已写入的数据发送给客户端
+func flushWriter(w io.Writer) {
+	if flusher, ok := w.(interface{ Flush() }); ok {
+		flusher.Flush()
+	}
+}
+
+type csvExportWriter struct {
+	w      io.Writer
+	csv    *csv.Writer
+	header []string
+	count  int
+}
+
+func (c *csvExportWriter) begin() error {
+	return c.csv.Write(c.header)
+}
+
+func (c *csvExportWriter) write(_ interface{}, record []string) error {
+	if err := c.csv.Write(record); err != nil {
+		return err
+	}
+	c.count++
+	if c.count%exportFlushSize == 0 {
+		return c.flush()
+	}
+	return nil
+}
+
+func (c *csvExportWriter) end() error {
+	return c.flush()
+}
+
+func (c *csvExportWriter) flush() error {
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return err
+	}
+	flushWriter(c.w)
+	return nil
+}
+
+// jsonExportWriter 逐条写入json数组
+type jsonExportWriter struct {
+	w     io.Writer
+	count int
+}
+
+func (j *jsonExportWriter) begin() error {
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonExportWriter) write(item interface{}, _ []string) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	if j.count != 0 {
+		data = append([]byte{','}, data...)
+	}
+	if _, err = j.w.Write(data); err != nil {
+		return err
+	}
+	j.count++
+	if j.count%exportFlushSize == 0 {
+		flushWriter(j.w)
+	}
+	return nil
+}
+
+func (j *jsonExportWriter) end() error {
+	_, err := io.WriteString(j.w, "]")
+	flushWriter(j.w)
+	return err
+}
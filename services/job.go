@@ -0,0 +1,51 @@
+package services
+
+import (
+	conf "funoj-backend/config"
+	e "funoj-backend/consts/error"
+	"funoj-backend/utils"
+	"log"
+	"time"
+)
+
+// Jobs 后台定时任务，由StartJobs启动
+type Jobs struct {
+	stops []func()
+}
+
+// StartJobs 按配置的间隔启动所有定时任务，返回的函数停止所有任务
+func StartJobs(config *conf.AppConfig, problemReviewService ProblemReviewService, codeDraftService CodeDraftService,
+	mistakeNoteService MistakeNoteService, submissionService SubmissionService) (*Jobs, func()) {
+	jobs := &Jobs{}
+	jobs.add("publish scheduled problems", time.Duration(config.ReviewConfig.PublishInterval)*time.Second,
+		problemReviewService.PublishScheduledProblems)
+	jobs.add("flush code drafts", time.Duration(config.DraftConfig.FlushInterval)*time.Second,
+		codeDraftService.FlushCodeDrafts)
+	jobs.add("collect mistake notes", time.Duration(config.MistakeConfig.CollectInterval)*time.Second,
+		mistakeNoteService.CollectMistakeNotes)
+	// 每小时检查一次，只在设置的时间发送，每天发送一次
+	jobs.add("send mistake note digests", time.Hour, func() *e.Error {
+		if time.Now().Hour() != config.MistakeConfig.DigestHour {
+			return nil
+		}
+		return mistakeNoteService.SendMistakeNoteDigests()
+	})
+	jobs.add("archive submissions", time.Duration(config.ArchiveConfig.Interval)*time.Second,
+		submissionService.ArchiveSubmissions)
+	return jobs, jobs.stop
+}
+
+// add 每隔interval执行一次job，出错时记录日志，下次继续执行
+func (jobs *Jobs) add(name string, interval time.Duration, job func() *e.Error) {
+	jobs.stops = append(jobs.stops, utils.RunPeriodically(interval, func() {
+		if err := job(); err != nil {
+			log.Printf("%s: %s", name, err.Message)
+		}
+	}))
+}
+
+func (jobs *Jobs) stop() {
+	for _, stop := range jobs.stops {
+		stop()
+	}
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	conf "funoj-backend/config"
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"html"
+	"log"
+	"math"
+	"strings"
+	"time"
+)
+
+// MistakeNoteService 错题本，错误多次的题目自动加入，按SM-2间隔重复算法安排复习
+type MistakeNoteService interface {
+	// GetMistakeNoteList 获取错题本，dueToday为true时只获取今天需要复习的题目，获取前会先收集用户新的错题
+	GetMistakeNoteList(ctx *gin.Context, pageQuery *request.PageQuery, dueToday bool) (*response.PageInfo, *e.Error)
+	// AddMistakeNote 手动将题目加入错题本，被移除过的题目重新开始复习
+	AddMistakeNote(ctx *gin.Context, problemID uint) *e.Error
+	// UpdateMistakeNoteContent 修改题目的笔记
+	UpdateMistakeNoteContent(ctx *gin.Context, problemID uint, note string) *e.Error
+	// ReviewMistakeNote 完成一次复习，quality为0到5的掌握程度，返回下次复习的安排
+	ReviewMistakeNote(ctx *gin.Context, problemID uint, quality int) (*dto.MistakeNoteDto, *e.Error)
+	// DeleteMistakeNote 从错题本中移除，之后不会再自动加入
+	DeleteMistakeNote(ctx *gin.Context, problemID uint) *e.Error
+	// SetMistakeNoteDigest 开启或关闭每天的复习提醒邮件
+	SetMistakeNoteDigest(ctx *gin.Context, enabled bool) *e.Error
+	// CollectMistakeNotes 收集所有用户的错题，由定时任务调用
+	CollectMistakeNotes() *e.Error
+	// SendMistakeNoteDigests 给开启了提醒的用户发送今天需要复习的题目，由定时任务每天调用
+	SendMistakeNoteDigests() *e.Error
+}
+
+type MistakeNoteServiceImpl struct {
+	config         *conf.AppConfig
+	mistakeNoteDao dao.MistakeNoteDao
+	problemDao     dao.ProblemDao
+	sysUserDao     dao.SysUserDao
+}
+
+func NewMistakeNoteService(config *conf.AppConfig, mistakeNoteDao dao.MistakeNoteDao, problemDao dao.ProblemDao,
+	sysUserDao dao.SysUserDao) MistakeNoteService {
+	return &MistakeNoteServiceImpl{
+		config:         config,
+		mistakeNoteDao: mistakeNoteDao,
+		problemDao:     problemDao,
+		sysUserDao:     sysUserDao,
+	}
+}
+
+func (svc *MistakeNoteServiceImpl) GetMistakeNoteList(ctx *gin.Context, pageQuery *request.PageQuery, dueToday bool) (*response.PageInfo, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if _, err := svc.mistakeNoteDao.CollectMistakeNotes(db.Mysql, user.ID, svc.config.MistakeConfig.MinErrCount, getToday()); err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	query := &request.MistakeNoteForList{UserID: user.ID}
+	if dueToday {
+		query.DueBefore = getToday().AddDate(0, 0, 1)
+	}
+	pageQuery.Query = query
+	notes, err := svc.mistakeNoteDao.GetMistakeNoteList(db.Mysql, pageQuery)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	noteList, err := svc.newMistakeNoteDtos(notes)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	count, err := svc.mistakeNoteDao.GetMistakeNoteCount(db.Mysql, query)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	return &response.PageInfo{
+		Total: count,
+		Size:  int64(len(noteList)),
+		List:  noteList,
+	}, nil
+}
+
+func (svc *MistakeNoteServiceImpl) AddMistakeNote(ctx *gin.Context, problemID uint) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if _, err := svc.problemDao.GetProblemByID(db.Mysql, problemID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return e.ErrProblemNotExist
+		}
+		return e.ErrMysql
+	}
+	_, err := svc.mistakeNoteDao.GetMistakeNote(db.Mysql, user.ID, problemID)
+	if err == nil {
+		return e.ErrMistakeNoteExist
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.ErrMysql
+	}
+	note := &repository.MistakeNote{
+		UserID:     user.ID,
+		ProblemID:  problemID,
+		EaseFactor: consts.MistakeDefaultEase,
+		DueAt:      getToday(),
+	}
+	if err = svc.mistakeNoteDao.SaveMistakeNote(db.Mysql, note); err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *MistakeNoteServiceImpl) UpdateMistakeNoteContent(ctx *gin.Context, problemID uint, content string) *e.Error {
+	note, err := svc.getMistakeNote(ctx, problemID)
+	if err != nil {
+		return err
+	}
+	note.Note = content
+	if err := svc.mistakeNoteDao.UpdateMistakeNote(db.Mysql, note); err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *MistakeNoteServiceImpl) ReviewMistakeNote(ctx *gin.Context, problemID uint, quality int) (*dto.MistakeNoteDto, *e.Error) {
+	if quality < 0 || quality > consts.MistakeQualityMax {
+		return nil, e.ErrMistakeQualityInvalid
+	}
+	note, svcErr := svc.getMistakeNote(ctx, problemID)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+	scheduleMistakeReview(note, quality, time.Now())
+	if err := svc.mistakeNoteDao.UpdateMistakeNote(db.Mysql, note); err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	answers, err := svc.newMistakeNoteDtos([]*repository.MistakeNote{note})
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	return answers[0], nil
+}
+
+func (svc *MistakeNoteServiceImpl) DeleteMistakeNote(ctx *gin.Context, problemID uint) *e.Error {
+	if _, err := svc.getMistakeNote(ctx, problemID); err != nil {
+		return err
+	}
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if err := svc.mistakeNoteDao.DeleteMistakeNote(db.Mysql, user.ID, problemID); err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *MistakeNoteServiceImpl) SetMistakeNoteDigest(ctx *gin.Context, enabled bool) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	setting := &repository.MistakeNoteSetting{UserID: user.ID, EmailDigest: enabled}
+	if err := svc.mistakeNoteDao.SaveMistakeNoteSetting(db.Mysql, setting); err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *MistakeNoteServiceImpl) CollectMistakeNotes() *e.Error {
+	count, err := svc.mistakeNoteDao.CollectMistakeNotes(db.Mysql, 0, svc.config.MistakeConfig.MinErrCount, getToday())
+	if err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	log.Printf("collected %d mistake notes", count)
+	return nil
+}
+
+func (svc *MistakeNoteServiceImpl) SendMistakeNoteDigests() *e.Error {
+	if err := svc.CollectMistakeNotes(); err != nil {
+		return err
+	}
+	tomorrow := getToday().AddDate(0, 0, 1)
+	digests, err := svc.mistakeNoteDao.GetMistakeNoteDigests(db.Mysql, tomorrow)
+	if err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	// 一个用户发送失败不影响其他用户
+	for _, digest := range digests {
+		if err = svc.sendMistakeNoteDigest(digest, tomorrow); err != nil {
+			log.Println(err)
+		}
+	}
+	return nil
+}
+
+func (svc *MistakeNoteServiceImpl) sendMistakeNoteDigest(digest *repository.MistakeNoteDigest, before time.Time) error {
+	user, err := svc.sysUserDao.GetUserByID(db.Mysql, digest.UserID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		return nil
+	}
+	notes, err := svc.mistakeNoteDao.GetMistakeNoteList(db.Mysql, &request.PageQuery{
+		Query:    &request.MistakeNoteForList{UserID: digest.UserID, DueBefore: before},
+		Page:     1,
+		PageSize: svc.config.MistakeConfig.DigestMaxProblem,
+	})
+	if err != nil {
+		return err
+	}
+	items, err := svc.newMistakeNoteDtos(notes)
+	if err != nil {
+		return err
+	}
+	body := &strings.Builder{}
+	body.WriteString(fmt.Sprintf("<p>你今天有%d道错题需要复习：</p><ul>", digest.Count))
+	for _, item := range items {
+		body.WriteString(fmt.Sprintf("<li>%s %s</li>", html.EscapeString(item.ProblemNumber), html.EscapeString(item.ProblemName)))
+	}
+	body.WriteString("</ul>")
+	message := utils.SysEmailMessage{
+		To:      []string{user.Email},
+		Subject: "funoj-错题复习提醒",
+		Body:    body.String(),
+	}
+	return utils.SendSysEmail(svc.config.EmailConfig, message)
+}
+
+func (svc *MistakeNoteServiceImpl) getMistakeNote(ctx *gin.Context, problemID uint) (*repository.MistakeNote, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	note, err := svc.mistakeNoteDao.GetMistakeNote(db.Mysql, user.ID, problemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrMistakeNoteNotExist
+	}
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return note, nil
+}
+
+// newMistakeNoteDtos 批量填充题目的编号和名称
+func (svc *MistakeNoteServiceImpl) newMistakeNoteDtos(notes []*repository.MistakeNote) ([]*dto.MistakeNoteDto, error) {
+	problemIDs := make([]uint, len(notes))
+	for i, note := range notes {
+		problemIDs[i] = note.ProblemID
+	}
+	problems, err := svc.problemDao.GetSimpleProblemsByIDs(db.Mysql, problemIDs)
+	if err != nil {
+		return nil, err
+	}
+	problemMap := make(map[uint]*repository.Problem, len(problems))
+	for _, problem := range problems {
+		problemMap[problem.ID] = problem
+	}
+	answer := make([]*dto.MistakeNoteDto, len(notes))
+	for i, note := range notes {
+		answer[i] = dto.NewMistakeNoteDto(note)
+		if problem, ok := problemMap[note.ProblemID]; ok {
+			answer[i].ProblemNumber = problem.Number
+			answer[i].ProblemName = problem.Name
+		}
+	}
+	return answer, nil
+}
+
+// scheduleMistakeReview 按SM-2算法根据本次复习的掌握程度计算下次复习的日期
+func scheduleMistakeReview(note *repository.MistakeNote, quality int, now time.Time) {
+	if quality < consts.MistakeQualityPass {
+		note.Repetitions = 0
+		note.ReviewInterval = 1
+	} else {
+		note.Repetitions++
+		switch note.Repetitions {
+		case 1:
+			note.ReviewInterval = 1
+		case 2:
+			note.ReviewInterval = 6
+		default:
+			note.ReviewInterval = int(math.Round(float64(note.ReviewInterval) * note.EaseFactor))
+		}
+	}
+	q := float64(consts.MistakeQualityMax - quality)
+	note.EaseFactor = math.Max(consts.MistakeMinEase, note.EaseFactor+0.1-q*(0.08+q*0.02))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	note.DueAt = today.AddDate(0, 0, note.ReviewInterval)
+	note.LastReviewedAt = &now
+}
+
+// getToday 服务器时区今天的零点
+func getToday() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -2,10 +2,13 @@ package services
 
 import (
 	"errors"
+	"fmt"
 	conf "funoj-backend/config"
+	"funoj-backend/consts"
 	e "funoj-backend/consts/error"
 	"funoj-backend/dao"
 	"funoj-backend/db"
+	"funoj-backend/file_store"
 	"funoj-backend/model/dto"
 	"funoj-backend/model/form/request"
 	"funoj-backend/model/form/response"
@@ -15,6 +18,8 @@ import (
 	"gorm.io/gorm"
 	"log"
 	"os"
+	"path"
+	"strings"
 	"time"
 )
 
@@ -35,25 +40,48 @@ type ProblemService interface {
 	GetProblemByID(id uint) (*dto.ProblemDtoForGet, *e.Error)
 	// GetProblemByNumber 根据题目编号获取题目信息
 	GetProblemByNumber(number string) (*dto.ProblemDtoForGet, *e.Error)
-	// GetProblemTemplateCode 获取题目的模板代码
-	GetProblemTemplateCode(problemID uint, language string) (string, *e.Error)
-	// UpdateProblemEnable 设置题目可用
+	// GetProblemTemplateCode 获取编辑器的初始代码，用户有草稿时返回最后一次的草稿，否则返回题目的模板代码
+	GetProblemTemplateCode(ctx *gin.Context, problemID uint, language string) (string, *e.Error)
+	// UpdateProblemEnable 设置题目可用，只有已发布的题目可以启用
 	UpdateProblemEnable(id uint, enable int) *e.Error
+	// GetProblemLanguages 获取题目允许的语言及其时间、内存限制倍率
+	GetProblemLanguages(problemID uint) ([]*dto.ProblemLanguageDto, *e.Error)
+	// UpdateProblemLanguages 设置题目允许的语言及其时间、内存限制倍率，倍率不大于0时使用语言默认倍率
+	UpdateProblemLanguages(problemID uint, languages []*repository.ProblemLanguage) *e.Error
+	// CheckProblemLanguage 判题前检测题目是否允许该语言，返回该语言的限制倍率
+	CheckProblemLanguage(problemID uint, language string) (*dto.ProblemLanguageDto, *e.Error)
+	// CloneProblem 复制题目到新的编号下，包括用例、语言设置和题目文件，withMenus表示是否复制所属题单
+	CloneProblem(ctx *gin.Context, problemID uint, number string, withMenus bool) (uint, *e.Error)
 }
 
+const (
+	// ProblemFilePath cos中，题目文件存储位置
+	ProblemFilePath = "/problem"
+)
+
 type ProblemServiceImpl struct {
-	config            *conf.AppConfig
-	problemDao        dao.ProblemDao
-	problemCaseDao    dao.ProblemCaseDao
-	problemAttemptDao dao.ProblemAttemptDao
+	config              *conf.AppConfig
+	problemDao          dao.ProblemDao
+	problemCaseDao      dao.ProblemCaseDao
+	problemLanguageDao  dao.ProblemLanguageDao
+	problemAttemptDao   dao.ProblemAttemptDao
+	problemStatisticDao dao.ProblemStatisticDao
+	problemTemplateDao  dao.ProblemTemplateDao
+	codeDraftDao        dao.CodeDraftDao
 }
 
-func NewProblemService(config *conf.AppConfig, problemDao dao.ProblemDao, problemCaseDao dao.ProblemCaseDao, problemAttempt dao.ProblemAttemptDao) ProblemService {
+func NewProblemService(config *conf.AppConfig, problemDao dao.ProblemDao, problemCaseDao dao.ProblemCaseDao, problemLanguageDao dao.ProblemLanguageDao,
+	problemAttempt dao.ProblemAttemptDao, problemStatisticDao dao.ProblemStatisticDao, problemTemplateDao dao.ProblemTemplateDao,
+	codeDraftDao dao.CodeDraftDao) ProblemService {
 	return &ProblemServiceImpl{
-		config:            config,
-		problemDao:        problemDao,
-		problemCaseDao:    problemCaseDao,
-		problemAttemptDao: problemAttempt,
+		config:              config,
+		problemDao:          problemDao,
+		problemCaseDao:      problemCaseDao,
+		problemLanguageDao:  problemLanguageDao,
+		problemAttemptDao:   problemAttempt,
+		problemStatisticDao: problemStatisticDao,
+		problemTemplateDao:  problemTemplateDao,
+		codeDraftDao:        codeDraftDao,
 	}
 }
 
@@ -99,8 +127,16 @@ func (svc *ProblemServiceImpl) InsertProblem(ctx *gin.Context, problem *reposito
 		problem.Difficulty = 1
 	}
 	problem.Enable = -1
+	problem.Status = consts.ProblemStatusDraft
+	// 根据支持的语言生成语言设置，使用默认倍率
+	settings, err := newProblemLanguages(problem.Languages)
+	if err != nil {
+		return 0, e.ErrLanguageNotSupported
+	}
+	problem.LanguageSettings = settings
+	problem.Languages = joinProblemLanguages(settings)
 	// 添加
-	err := svc.problemDao.InsertProblem(db.Mysql, problem)
+	err = svc.problemDao.InsertProblem(db.Mysql, problem)
 	if err != nil {
 		return 0, e.ErrMysql
 	}
@@ -109,7 +145,33 @@ func (svc *ProblemServiceImpl) InsertProblem(ctx *gin.Context, problem *reposito
 
 func (svc *ProblemServiceImpl) UpdateProblem(problem *repository.Problem) *e.Error {
 	problem.UpdatedAt = time.Now()
-	if err := svc.problemDao.UpdateProblem(db.Mysql, problem); err != nil {
+	settings, err := newProblemLanguages(problem.Languages)
+	if err != nil {
+		return e.ErrLanguageNotSupported
+	}
+	// 保留已有语言的倍率设置
+	oldSettings, err := svc.problemLanguageDao.GetProblemLanguages(db.Mysql, problem.ID)
+	if err != nil {
+		return e.ErrMysql
+	}
+	oldSettingMap := make(map[string]*repository.ProblemLanguage, len(oldSettings))
+	for _, setting := range oldSettings {
+		oldSettingMap[setting.Language] = setting
+	}
+	for _, setting := range settings {
+		if old, ok := oldSettingMap[setting.Language]; ok {
+			setting.TimeMultiplier = old.TimeMultiplier
+			setting.MemoryMultiplier = old.MemoryMultiplier
+		}
+	}
+	problem.Languages = joinProblemLanguages(settings)
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := svc.problemDao.UpdateProblem(tx, problem); err != nil {
+			return err
+		}
+		return svc.problemLanguageDao.ReplaceProblemLanguages(tx, problem.ID, settings)
+	})
+	if err != nil {
 		log.Println(err)
 		return e.ErrProblemUpdateFailed
 	}
@@ -146,9 +208,17 @@ func (svc *ProblemServiceImpl) GetProblemList(query *request.PageQuery) (*respon
 	if err != nil {
 		return nil, e.ErrMysql
 	}
+	statistics, err := svc.getProblemStatisticMap(problems)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
 	newProblems := make([]*dto.ProblemDtoForList, len(problems))
 	for i := 0; i < len(problems); i++ {
 		newProblems[i] = dto.NewProblemDtoForList(problems[i])
+		if statistic, ok := statistics[problems[i].ID]; ok {
+			newProblems[i].AcceptanceRate = dto.GetAcceptanceRate(statistic.AcceptedCount, statistic.SubmissionCount)
+			newProblems[i].SolverCount = statistic.SolverCount
+		}
 	}
 	// 获取所有题目总数目
 	var count int64
@@ -178,9 +248,17 @@ func (svc *ProblemServiceImpl) GetUserProblemList(ctx *gin.Context, query *reque
 	if err != nil {
 		return nil, e.ErrMysql
 	}
+	statistics, err := svc.getProblemStatisticMap(problems)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
 	newProblems := make([]*dto.ProblemDtoForUserList, len(problems))
 	for i := 0; i < len(problems); i++ {
 		newProblems[i] = dto.NewProblemDtoForUserList(problems[i])
+		if statistic, ok := statistics[problems[i].ID]; ok {
+			newProblems[i].AcceptanceRate = dto.GetAcceptanceRate(statistic.AcceptedCount, statistic.SubmissionCount)
+			newProblems[i].SolverCount = statistic.SolverCount
+		}
 		// 读取题目完成情况
 		var status int
 		status, err = svc.problemAttemptDao.GetProblemAttemptStatus(db.Mysql, userId, problems[i].ID)
@@ -211,7 +289,32 @@ func (svc *ProblemServiceImpl) GetProblemByID(id uint) (*dto.ProblemDtoForGet, *
 	if err != nil {
 		return nil, e.ErrMysql
 	}
-	return dto.NewProblemDtoForGet(problem), nil
+	problemDto := dto.NewProblemDtoForGet(problem)
+	problemDto.Statistic, err = svc.getProblemStatistic(problem.ID)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	settings, err := getProblemLanguageSettings(svc.problemLanguageDao, db.Mysql, problem)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	problemDto.LanguageSettings = make([]*dto.ProblemLanguageDto, len(settings))
+	for i, setting := range settings {
+		problemDto.LanguageSettings[i] = dto.NewProblemLanguageDto(setting)
+	}
+	problemDto.Templates, err = svc.getProblemTemplates(problem, settings)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrProblemGetFailed
+	}
+	problemDto.Samples, err = getProblemSamples(svc.problemCaseDao, problem.ID)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	return problemDto, nil
 }
 
 func (svc *ProblemServiceImpl) GetProblemByNumber(number string) (*dto.ProblemDtoForGet, *e.Error) {
@@ -222,22 +325,372 @@ func (svc *ProblemServiceImpl) GetProblemByNumber(number string) (*dto.ProblemDt
 	if err != nil {
 		return nil, e.ErrMysql
 	}
-	return dto.NewProblemDtoForGet(problem), nil
+	problemDto := dto.NewProblemDtoForGet(problem)
+	problemDto.Statistic, err = svc.getProblemStatistic(problem.ID)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	settings, err := getProblemLanguageSettings(svc.problemLanguageDao, db.Mysql, problem)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	problemDto.LanguageSettings = make([]*dto.ProblemLanguageDto, len(settings))
+	for i, setting := range settings {
+		problemDto.LanguageSettings[i] = dto.NewProblemLanguageDto(setting)
+	}
+	problemDto.Templates, err = svc.getProblemTemplates(problem, settings)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrProblemGetFailed
+	}
+	problemDto.Samples, err = getProblemSamples(svc.problemCaseDao, problem.ID)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	return problemDto, nil
 }
 
-func (svc *ProblemServiceImpl) GetProblemTemplateCode(problemID uint, language string) (string, *e.Error) {
-	// 读取acm模板
-	code, err := utils.GetAcmCodeTemplate(language)
+func (svc *ProblemServiceImpl) GetProblemTemplateCode(ctx *gin.Context, problemID uint, language string) (string, *e.Error) {
+	if _, err := checkProblemLanguage(svc.problemDao, svc.problemLanguageDao, problemID, language); err != nil {
+		return "", err
+	}
+	if user, ok := ctx.Keys["user"].(*dto.UserInfo); ok {
+		code, exist, err := getUserCodeDraft(svc.codeDraftDao, user.ID, problemID, language)
+		if err != nil {
+			log.Println(err)
+			return "", e.ErrServer
+		}
+		if exist {
+			return code, nil
+		}
+	}
+	// 读取题目模板，没有时使用全局默认模板
+	template, err := getProblemTemplate(svc.problemTemplateDao, problemID, language)
 	if err != nil {
+		log.Println(err)
 		return "", e.ErrProblemGetFailed
 	}
-	return code, nil
+	return template.Code, nil
 }
 
-// todo: 是否要加事务
+// UpdateProblemEnable 只能临时启用或停用已发布的题目，题目的发布与归档通过发布流程完成
 func (svc *ProblemServiceImpl) UpdateProblemEnable(id uint, enable int) *e.Error {
-	if err := svc.problemDao.SetProblemEnable(db.Mysql, id, enable); err != nil {
+	problem, err := svc.problemDao.GetProblemByID(db.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.ErrProblemNotExist
+	}
+	if err != nil {
+		return e.ErrMysql
+	}
+	if enable == 1 && dto.GetProblemStatus(problem) != consts.ProblemStatusPublished {
+		return e.ErrProblemNotPublished
+	}
+	if err = svc.problemDao.SetProblemEnable(db.Mysql, id, enable); err != nil {
 		return e.ErrMysql
 	}
 	return nil
 }
+
+func (svc *ProblemServiceImpl) GetProblemLanguages(problemID uint) ([]*dto.ProblemLanguageDto, *e.Error) {
+	problem, err := svc.problemDao.GetProblemByID(db.Mysql, problemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrProblemNotExist
+	}
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	settings, err := getProblemLanguageSettings(svc.problemLanguageDao, db.Mysql, problem)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.ProblemLanguageDto, len(settings))
+	for i, setting := range settings {
+		answer[i] = dto.NewProblemLanguageDto(setting)
+	}
+	return answer, nil
+}
+
+func (svc *ProblemServiceImpl) UpdateProblemLanguages(problemID uint, languages []*repository.ProblemLanguage) *e.Error {
+	if len(languages) == 0 {
+		return e.ErrProblemLanguageInvalid
+	}
+	if _, err := svc.problemDao.GetProblemByID(db.Mysql, problemID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return e.ErrProblemNotExist
+		}
+		return e.ErrMysql
+	}
+	exists := make(map[string]bool, len(languages))
+	for _, language := range languages {
+		if !consts.IsProgramLanguageSupported(language.Language) {
+			return e.ErrLanguageNotSupported
+		}
+		if exists[language.Language] {
+			return e.ErrProblemLanguageInvalid
+		}
+		exists[language.Language] = true
+		timeMultiplier, memoryMultiplier := consts.GetDefaultLanguageMultiplier(language.Language)
+		if language.TimeMultiplier <= 0 {
+			language.TimeMultiplier = timeMultiplier
+		}
+		if language.MemoryMultiplier <= 0 {
+			language.MemoryMultiplier = memoryMultiplier
+		}
+	}
+	err := db.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := svc.problemLanguageDao.ReplaceProblemLanguages(tx, problemID, languages); err != nil {
+			return err
+		}
+		// 同步题目中逗号分割的语言字段
+		return svc.problemDao.UpdateProblemField(tx, problemID, "languages", joinProblemLanguages(languages))
+	})
+	if err != nil {
+		log.Println(err)
+		return e.ErrProblemUpdateFailed
+	}
+	return nil
+}
+
+func (svc *ProblemServiceImpl) CheckProblemLanguage(problemID uint, language string) (*dto.ProblemLanguageDto, *e.Error) {
+	setting, err := checkProblemLanguage(svc.problemDao, svc.problemLanguageDao, problemID, language)
+	if err != nil {
+		return nil, err
+	}
+	return dto.NewProblemLanguageDto(setting), nil
+}
+
+func (svc *ProblemServiceImpl) CloneProblem(ctx *gin.Context, problemID uint, number string, withMenus bool) (uint, *e.Error) {
+	source, err := svc.problemDao.GetProblemByID(db.Mysql, problemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, e.ErrProblemNotExist
+	}
+	if err != nil {
+		return 0, e.ErrMysql
+	}
+	if number == "" {
+		number = source.Number + "-" + utils.GetGenerateUniqueCode()
+	}
+	b, err := svc.problemDao.CheckProblemNumberExists(db.Mysql, number)
+	if err != nil {
+		return 0, e.ErrMysql
+	}
+	if b {
+		return 0, e.ErrProblemCodeIsExist
+	}
+	problem := &repository.Problem{
+		CreatorID:   ctx.Keys["user"].(*dto.UserInfo).ID,
+		Number:      number,
+		Name:        source.Name,
+		Description: source.Description,
+		Title:       source.Title,
+		Difficulty:  source.Difficulty,
+		Languages:   source.Languages,
+		// 复制出来的题目默认停用
+		Enable: -1,
+		Status: consts.ProblemStatusDraft,
+	}
+	if withMenus {
+		menus, err := svc.problemDao.GetProblemMenus(db.Mysql, problemID)
+		if err != nil {
+			return 0, e.ErrMysql
+		}
+		// 只加入官方题单，不能把未发布的题目放进用户自己的题单
+		for _, menu := range menus {
+			if menu.Type == consts.ProblemMenuTypeOfficial {
+				problem.Menus = append(problem.Menus, menu)
+			}
+		}
+	}
+	settings, err := getProblemLanguageSettings(svc.problemLanguageDao, db.Mysql, source)
+	if err != nil {
+		return 0, e.ErrMysql
+	}
+	for _, setting := range settings {
+		problem.LanguageSettings = append(problem.LanguageSettings, &repository.ProblemLanguage{
+			Language:         setting.Language,
+			TimeMultiplier:   setting.TimeMultiplier,
+			MemoryMultiplier: setting.MemoryMultiplier,
+		})
+	}
+	cases, err := svc.problemCaseDao.GetAllProblemCaseByID(db.Mysql, problemID)
+	if err != nil {
+		return 0, e.ErrMysql
+	}
+	templates, err := svc.problemTemplateDao.GetLatestProblemTemplates(db.Mysql, problemID)
+	if err != nil {
+		return 0, e.ErrMysql
+	}
+	store := file_store.NewProblemCOS(svc.config.COSConfig)
+	newFilePath := svc.getProblemFilePath(number)
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := svc.problemDao.InsertProblem(tx, problem); err != nil {
+			return err
+		}
+		for _, problemCase := range cases {
+			newCase := &repository.ProblemCase{
+				ProblemID: problem.ID,
+				CaseName:  problemCase.CaseName,
+				Input:     problemCase.Input,
+				Output:    problemCase.Output,
+				Sample:    problemCase.Sample,
+			}
+			if err := svc.problemCaseDao.InsertProblemCase(tx, newCase); err != nil {
+				return err
+			}
+		}
+		for _, template := range templates {
+			newTemplate := &repository.ProblemTemplate{
+				ProblemID: problem.ID,
+				Language:  template.Language,
+				Version:   1,
+				Code:      template.Code,
+				CreatorID: problem.CreatorID,
+			}
+			if err := svc.problemTemplateDao.InsertProblemTemplate(tx, newTemplate); err != nil {
+				return err
+			}
+		}
+		// 最后复制题目文件，失败时回滚数据库
+		return store.CopyFolder(svc.getProblemFilePath(source.Number)+"/", newFilePath+"/")
+	})
+	if err != nil {
+		log.Println(err)
+		_ = store.DeleteFolder(newFilePath + "/")
+		return 0, e.ErrProblemCloneFailed
+	}
+	return problem.ID, nil
+}
+
+// getProblemTemplates 获取题目支持的各个语言的模板代码
+func (svc *ProblemServiceImpl) getProblemTemplates(problem *repository.Problem, settings []*repository.ProblemLanguage) ([]*dto.ProblemTemplateDto, error) {
+	templates := make([]*dto.ProblemTemplateDto, len(settings))
+	for i, setting := range settings {
+		template, err := getProblemTemplate(svc.problemTemplateDao, problem.ID, setting.Language)
+		if err != nil {
+			return nil, err
+		}
+		templates[i] = template
+	}
+	return templates, nil
+}
+
+// getProblemFilePath 获取题目文件在对象存储中的位置
+func (svc *ProblemServiceImpl) getProblemFilePath(number string) string {
+	return path.Join(ProblemFilePath, number)
+}
+
+// getProblemStatistic 读取题目的统计信息，通过提交的耗时、内存分位数由分桶统计得到
+func (svc *ProblemServiceImpl) getProblemStatistic(problemID uint) (*dto.ProblemStatisticDto, error) {
+	statistic, err := svc.problemStatisticDao.GetProblemStatisticByProblemID(db.Mysql, problemID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := svc.problemStatisticDao.GetProblemStatisticItems(db.Mysql, problemID)
+	if err != nil {
+		return nil, err
+	}
+	statisticDto := dto.NewProblemStatisticDto(statistic, items)
+	statisticDto.TimePercentiles = getUsagePercentiles(items, consts.StatisticCategoryTime)
+	statisticDto.MemoryPercentiles = getUsagePercentiles(items, consts.StatisticCategoryMemory)
+	return statisticDto, nil
+}
+
+// getProblemStatisticMap 批量读取题目的统计信息，key为题目id
+func (svc *ProblemServiceImpl) getProblemStatisticMap(problems []*repository.Problem) (map[uint]*repository.ProblemStatistic, error) {
+	problemIDs := make([]uint, len(problems))
+	for i, problem := range problems {
+		problemIDs[i] = problem.ID
+	}
+	statistics, err := svc.problemStatisticDao.GetProblemStatisticsByProblemIDs(db.Mysql, problemIDs)
+	if err != nil {
+		return nil, err
+	}
+	answer := make(map[uint]*repository.ProblemStatistic, len(statistics))
+	for _, statistic := range statistics {
+		answer[statistic.ProblemID] = statistic
+	}
+	return answer, nil
+}
+
+// newProblemLanguages 根据逗号分割的语言生成语言设置，使用语言的默认倍率，为空时支持所有语言
+func newProblemLanguages(languages string) ([]*repository.ProblemLanguage, error) {
+	var names []string
+	exists := make(map[string]bool)
+	for _, language := range strings.Split(languages, ",") {
+		language = strings.TrimSpace(language)
+		if language == "" || exists[language] {
+			continue
+		}
+		if !consts.IsProgramLanguageSupported(language) {
+			return nil, fmt.Errorf("language %s is not supported", language)
+		}
+		exists[language] = true
+		names = append(names, language)
+	}
+	if len(names) == 0 {
+		names = consts.ProgramLanguages
+	}
+	answer := make([]*repository.ProblemLanguage, len(names))
+	for i, language := range names {
+		timeMultiplier, memoryMultiplier := consts.GetDefaultLanguageMultiplier(language)
+		answer[i] = &repository.ProblemLanguage{
+			Language:         language,
+			TimeMultiplier:   timeMultiplier,
+			MemoryMultiplier: memoryMultiplier,
+		}
+	}
+	return answer, nil
+}
+
+// joinProblemLanguages 将语言设置转为逗号分割的语言
+func joinProblemLanguages(settings []*repository.ProblemLanguage) string {
+	names := make([]string, len(settings))
+	for i, setting := range settings {
+		names[i] = setting.Language
+	}
+	return strings.Join(names, ",")
+}
+
+// getProblemLanguageSettings 获取题目的语言设置，旧题目没有语言设置时根据languages字段生成
+func getProblemLanguageSettings(languageDao dao.ProblemLanguageDao, tx *gorm.DB, problem *repository.Problem) ([]*repository.ProblemLanguage, error) {
+	settings, err := languageDao.GetProblemLanguages(tx, problem.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(settings) != 0 {
+		return settings, nil
+	}
+	settings, err = newProblemLanguages(problem.Languages)
+	if err != nil {
+		// 旧数据中不支持的语言直接忽略
+		return newProblemLanguages("")
+	}
+	return settings, nil
+}
+
+// checkProblemLanguage 检测题目是否允许使用该语言
+func checkProblemLanguage(problemDao dao.ProblemDao, languageDao dao.ProblemLanguageDao, problemID uint, language string) (*repository.ProblemLanguage, *e.Error) {
+	if !consts.IsProgramLanguageSupported(language) {
+		return nil, e.ErrLanguageNotSupported
+	}
+	problem, err := problemDao.GetProblemByID(db.Mysql, problemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrProblemNotExist
+	}
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	settings, err := getProblemLanguageSettings(languageDao, db.Mysql, problem)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	for _, setting := range settings {
+		if setting.Language == language {
+			return setting, nil
+		}
+	}
+	return nil, e.ErrLanguageNotSupported
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"errors"
+	conf "funoj-backend/config"
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/repository"
+	"gorm.io/gorm"
+	"log"
+	"sync"
+	"time"
+)
+
+// problemAttemptRepairBatch 重新计算时每批处理的记录数
+const problemAttemptRepairBatch = 500
+
+// ProblemAttemptService 用户做题情况维护
+type ProblemAttemptService interface {
+	// RepairProblemAttempts 根据提交记录重新计算所有用户的做题情况，删除没有提交的记录
+	RepairProblemAttempts() (*dto.ProblemAttemptRepairDto, *e.Error)
+}
+
+type ProblemAttemptServiceImpl struct {
+	config            *conf.AppConfig
+	submissionDao     dao.SubmissionDao
+	problemAttemptDao dao.ProblemAttemptDao
+	// 同时只能有一个修复任务
+	repairMutex sync.Mutex
+}
+
+func NewProblemAttemptService(config *conf.AppConfig, sd dao.SubmissionDao, pad dao.ProblemAttemptDao) ProblemAttemptService {
+	return &ProblemAttemptServiceImpl{
+		config:            config,
+		submissionDao:     sd,
+		problemAttemptDao: pad,
+	}
+}
+
+func (svc *ProblemAttemptServiceImpl) RepairProblemAttempts() (*dto.ProblemAttemptRepairDto, *e.Error) {
+	if !svc.repairMutex.TryLock() {
+		return nil, e.ErrProblemAttemptRepairRunning
+	}
+	defer svc.repairMutex.Unlock()
+	start := time.Now()
+	answer := &dto.ProblemAttemptRepairDto{}
+	// 按(user_id, problem_id)分页，修复过程中有新的提交时不会跳过后面的记录
+	var lastUserID, lastProblemID uint
+	for {
+		attempts, err := svc.submissionDao.GetSubmissionAttemptSummaries(db.Mysql, lastUserID, lastProblemID, problemAttemptRepairBatch)
+		if err != nil {
+			log.Println(err)
+			return nil, e.ErrMysql
+		}
+		if len(attempts) == 0 {
+			break
+		}
+		if err = svc.fillProblemAttempts(attempts); err != nil {
+			log.Println(err)
+			return nil, e.ErrMysql
+		}
+		if err = svc.problemAttemptDao.UpsertProblemAttempts(db.Mysql, attempts); err != nil {
+			log.Println(err)
+			return nil, e.ErrMysql
+		}
+		answer.Updated += len(attempts)
+		last := attempts[len(attempts)-1]
+		lastUserID, lastProblemID = last.UserID, last.ProblemID
+	}
+	deleted, err := svc.problemAttemptDao.DeleteProblemAttemptsWithoutSubmission(db.Mysql)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	answer.Deleted = deleted
+	answer.Duration = time.Since(start).Milliseconds()
+	return answer, nil
+}
+
+// fillProblemAttempts 根据汇总结果填充状态和最后一次提交的代码
+func (svc *ProblemAttemptServiceImpl) fillProblemAttempts(attempts []*repository.ProblemAttempt) error {
+	ids := make([]uint, len(attempts))
+	for i, attempt := range attempts {
+		ids[i] = attempt.LastSubmissionID
+	}
+	submissions, err := svc.submissionDao.GetSubmissionCodes(db.Mysql, ids)
+	if err != nil {
+		return err
+	}
+	if err = loadSubmissionArchive(svc.config, submissions...); err != nil {
+		return err
+	}
+	submissionMap := make(map[uint]*repository.Submission, len(submissions))
+	for _, submission := range submissions {
+		submissionMap[submission.ID] = submission
+	}
+	now := time.Now()
+	for _, attempt := range attempts {
+		attempt.Status = consts.AttemptStatusTrying
+		if attempt.SuccessCount > 0 {
+			attempt.Status = consts.AttemptStatusAccepted
+		}
+		if submission, ok := submissionMap[attempt.LastSubmissionID]; ok {
+			attempt.Code = submission.Code
+			attempt.Language = submission.Language
+		}
+		attempt.CreatedAt = now
+		attempt.UpdatedAt = now
+	}
+	return nil
+}
+
+// updateProblemAttempt 提交得到最终结果后更新用户做题情况，在保存提交的事务中调用，同一次提交通过提交上的标记只计入一次，
+// 返回是否是用户第一次通过题目，做题情况加锁读取，同时通过的提交只有一个返回true
+func updateProblemAttempt(tx *gorm.DB, attemptDao dao.ProblemAttemptDao, submissionDao dao.SubmissionDao,
+	submission *repository.Submission) (bool, error) {
+	if !consts.IsFinalVerdict(submission.Status) {
+		return false, nil
+	}
+	applied, err := submissionDao.MarkSubmissionAttemptApplied(tx, submission.ID)
+	if err != nil || !applied {
+		return false, err
+	}
+	if err = attemptDao.InitProblemAttempt(tx, submission.UserID, submission.ProblemID); err != nil {
+		return false, err
+	}
+	attempt, err := attemptDao.GetProblemAttemptForUpdate(tx, submission.UserID, submission.ProblemID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	newSolver := submission.Status == consts.Accepted && attempt.Status != consts.AttemptStatusAccepted
+	attempt.SubmissionCount++
+	if submission.Status == consts.Accepted {
+		attempt.SuccessCount++
+		attempt.Status = consts.AttemptStatusAccepted
+	} else {
+		attempt.ErrCount++
+		if attempt.Status != consts.AttemptStatusAccepted {
+			attempt.Status = consts.AttemptStatusTrying
+		}
+	}
+	// 提交的事务可能乱序加锁，只有更新的提交才修改最后一次的代码
+	if submission.ID > attempt.LastSubmissionID {
+		attempt.Code = submission.Code
+		attempt.Language = submission.Language
+		attempt.LastSubmissionID = submission.ID
+	}
+	attempt.UpdatedAt = time.Now()
+	return newSolver, attemptDao.UpdateProblemAttempt(tx, attempt)
+}
@@ -32,6 +32,10 @@ type ProblemCaseService interface {
 	CheckProblemCaseName(id uint, name string, problemID uint) (bool, *e.Error)
 	// GenerateNewProblemCaseName 生成一个题目唯一用例名称，递增
 	GenerateNewProblemCaseName(problemID uint) (string, *e.Error)
+	// UpdateProblemCaseSample 设置用例是否为样例
+	UpdateProblemCaseSample(id uint, sample bool) *e.Error
+	// GetProblemSampleCases 获取题目的样例，运行代码时使用样例作为输入
+	GetProblemSampleCases(problemID uint) ([]*repository.ProblemCase, *e.Error)
 }
 
 type ProblemCaseServiceImpl struct {
@@ -183,3 +187,60 @@ func (svc *ProblemCaseServiceImpl) GenerateNewProblemCaseName(problemID uint) (s
 	newName := latestCase.CaseName[:i+1] + strconv.Itoa(num)
 	return newName, nil
 }
+
+func (svc *ProblemCaseServiceImpl) UpdateProblemCaseSample(id uint, sample bool) *e.Error {
+	problemCase, err := svc.problemCaseDao.GetProblemCaseByID(db.Mysql, id)
+	if err != nil {
+		log.Println("Error while getting problem case:", err)
+		return e.ErrMysql
+	}
+	if problemCase.ID == 0 {
+		return e.ErrProblemNotExist
+	}
+	if err = svc.problemCaseDao.UpdateProblemCaseSample(db.Mysql, id, sample); err != nil {
+		log.Println("Error while updating problem case sample:", err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *ProblemCaseServiceImpl) GetProblemSampleCases(problemID uint) ([]*repository.ProblemCase, *e.Error) {
+	cases, err := svc.problemCaseDao.GetProblemSampleCases(db.Mysql, problemID)
+	if err != nil {
+		log.Println("Error while getting problem sample cases:", err)
+		return nil, e.ErrMysql
+	}
+	return cases, nil
+}
+
+// getProblemSamples 获取题目详情中展示的样例
+func getProblemSamples(problemCaseDao dao.ProblemCaseDao, problemID uint) ([]*dto.ProblemSampleDto, error) {
+	cases, err := problemCaseDao.GetProblemSampleCases(db.Mysql, problemID)
+	if err != nil {
+		return nil, err
+	}
+	samples := make([]*dto.ProblemSampleDto, len(cases))
+	for i, problemCase := range cases {
+		samples[i] = dto.NewProblemSampleDto(problemCase)
+	}
+	return samples, nil
+}
+
+// hideSubmissionCase 提交失败的用例不是样例时，非管理员只能看到判题结果，清除用例的输入、期望输出和用户输出
+func hideSubmissionCase(problemCaseDao dao.ProblemCaseDao, user *dto.UserInfo, submission *repository.Submission) error {
+	if isAdmin(user) || submission.CaseName == "" {
+		return nil
+	}
+	problemCase, err := problemCaseDao.GetProblemCaseByName(db.Mysql, submission.ProblemID, submission.CaseName)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	// 用例已被删除时按隐藏用例处理
+	if err == nil && problemCase.Sample {
+		return nil
+	}
+	submission.CaseData = ""
+	submission.ExpectedOutput = ""
+	submission.UserOutput = ""
+	return nil
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	e "funoj-backend/consts/error"
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/model/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// ProblemHintService 题目提示，错误提交达到次数后自动解锁，也可以主动按顺序解锁
+type ProblemHintService interface {
+	// InsertProblemHint 添加提示
+	InsertProblemHint(hint *repository.ProblemHint) (uint, *e.Error)
+	// UpdateProblemHint 更新提示
+	UpdateProblemHint(hint *repository.ProblemHint) *e.Error
+	// DeleteProblemHint 删除提示
+	DeleteProblemHint(id uint) *e.Error
+	// GetProblemHints 管理员获取题目的所有提示
+	GetProblemHints(problemID uint) ([]*repository.ProblemHint, *e.Error)
+	// GetProblemHintUnlockList 获取提示解锁记录，用于老师查看哪些用户使用了提示
+	GetProblemHintUnlockList(query *request.PageQuery) (*response.PageInfo, *e.Error)
+	// GetUserProblemHints 用户获取题目的提示，错误次数达到的提示会自动解锁
+	GetUserProblemHints(ctx *gin.Context, problemID uint) ([]*dto.UserProblemHintDto, *e.Error)
+	// UnlockProblemHint 用户主动解锁下一个未解锁的提示
+	UnlockProblemHint(ctx *gin.Context, problemID uint) (*dto.UserProblemHintDto, *e.Error)
+	// GetUserProblemHintPenalty 获取用户在题目中因主动解锁提示扣除的分数，用于作业计分
+	GetUserProblemHintPenalty(userID uint, problemID uint) (int, *e.Error)
+}
+
+type ProblemHintServiceImpl struct {
+	problemHintDao    dao.ProblemHintDao
+	problemDao        dao.ProblemDao
+	problemAttemptDao dao.ProblemAttemptDao
+	sysUserDao        dao.SysUserDao
+}
+
+func NewProblemHintService(phd dao.ProblemHintDao, pd dao.ProblemDao, pad dao.ProblemAttemptDao, sud dao.SysUserDao) ProblemHintService {
+	return &ProblemHintServiceImpl{
+		problemHintDao:    phd,
+		problemDao:        pd,
+		problemAttemptDao: pad,
+		sysUserDao:        sud,
+	}
+}
+
+func (svc *ProblemHintServiceImpl) InsertProblemHint(hint *repository.ProblemHint) (uint, *e.Error) {
+	if _, err := svc.problemDao.GetProblemByID(db.Mysql, hint.ProblemID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, e.ErrProblemNotExist
+		}
+		return 0, e.ErrMysql
+	}
+	hint.ID = 0
+	if hint.UnlockErrCount < 0 {
+		hint.UnlockErrCount = 0
+	}
+	if hint.Penalty < 0 {
+		hint.Penalty = 0
+	}
+	if err := svc.problemHintDao.InsertProblemHint(db.Mysql, hint); err != nil {
+		return 0, e.ErrMysql
+	}
+	return hint.ID, nil
+}
+
+func (svc *ProblemHintServiceImpl) UpdateProblemHint(hint *repository.ProblemHint) *e.Error {
+	if _, err := svc.problemHintDao.GetProblemHintByID(db.Mysql, hint.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return e.ErrProblemHintNotExist
+		}
+		return e.ErrMysql
+	}
+	if hint.UnlockErrCount < 0 {
+		hint.UnlockErrCount = 0
+	}
+	if hint.Penalty < 0 {
+		hint.Penalty = 0
+	}
+	hint.UpdatedAt = time.Now()
+	if err := svc.problemHintDao.UpdateProblemHint(db.Mysql, hint); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *ProblemHintServiceImpl) DeleteProblemHint(id uint) *e.Error {
+	if err := svc.problemHintDao.DeleteProblemHintByID(db.Mysql, id); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *ProblemHintServiceImpl) GetProblemHints(problemID uint) ([]*repository.ProblemHint, *e.Error) {
+	hints, err := svc.problemHintDao.GetProblemHints(db.Mysql, problemID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return hints, nil
+}
+
+func (svc *ProblemHintServiceImpl) GetProblemHintUnlockList(query *request.PageQuery) (*response.PageInfo, *e.Error) {
+	var unlockQuery *request.ProblemHintUnlockForList
+	if query.Query != nil {
+		unlockQuery = query.Query.(*request.ProblemHintUnlockForList)
+	}
+	unlocks, err := svc.problemHintDao.GetProblemHintUnlockList(db.Mysql, query)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	list := make([]*dto.ProblemHintUnlockDto, len(unlocks))
+	for i, unlock := range unlocks {
+		list[i] = dto.NewProblemHintUnlockDto(unlock)
+		list[i].UserName, err = svc.sysUserDao.GetUserNameByID(db.Mysql, unlock.UserID)
+		if err != nil {
+			return nil, e.ErrMysql
+		}
+	}
+	count, err := svc.problemHintDao.GetProblemHintUnlockCount(db.Mysql, unlockQuery)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return &response.PageInfo{
+		Total: count,
+		Size:  int64(len(list)),
+		List:  list,
+	}, nil
+}
+
+func (svc *ProblemHintServiceImpl) GetUserProblemHints(ctx *gin.Context, problemID uint) ([]*dto.UserProblemHintDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if err := svc.checkProblemVisible(user, problemID); err != nil {
+		return nil, err
+	}
+	hints, _, _, err := svc.getUserProblemHints(user.ID, problemID)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	return hints, nil
+}
+
+func (svc *ProblemHintServiceImpl) UnlockProblemHint(ctx *gin.Context, problemID uint) (*dto.UserProblemHintDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if err := svc.checkProblemVisible(user, problemID); err != nil {
+		return nil, err
+	}
+	hints, hintModels, errCount, err := svc.getUserProblemHints(user.ID, problemID)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	if len(hints) == 0 {
+		return nil, e.ErrProblemHintNotExist
+	}
+	// 按顺序解锁第一个未解锁的提示
+	for i, hint := range hints {
+		if !hint.Locked {
+			continue
+		}
+		if err = svc.problemHintDao.InsertProblemHintUnlock(db.Mysql, &repository.ProblemHintUnlock{
+			HintID:    hint.ID,
+			UserID:    user.ID,
+			ProblemID: problemID,
+			Manual:    true,
+			Penalty:   hint.Penalty,
+			ErrCount:  errCount,
+		}); err != nil {
+			return nil, e.ErrMysql
+		}
+		hint.Content = hintModels[i].Content
+		hint.Locked = false
+		hint.Manual = true
+		hint.RemainingErrCount = 0
+		return hint, nil
+	}
+	return nil, e.ErrProblemHintAllUnlocked
+}
+
+func (svc *ProblemHintServiceImpl) GetUserProblemHintPenalty(userID uint, problemID uint) (int, *e.Error) {
+	penalty, err := svc.problemHintDao.GetUserProblemHintPenalty(db.Mysql, userID, problemID)
+	if err != nil {
+		return 0, e.ErrMysql
+	}
+	return penalty, nil
+}
+
+// checkProblemVisible 普通用户只能查看启用的题目的提示
+func (svc *ProblemHintServiceImpl) checkProblemVisible(user *dto.UserInfo, problemID uint) *e.Error {
+	problem, err := svc.problemDao.GetProblemByID(db.Mysql, problemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.ErrProblemNotExist
+	}
+	if err != nil {
+		return e.ErrMysql
+	}
+	if problem.Enable != 1 && !isAdmin(user) {
+		return e.ErrProblemNotExist
+	}
+	return nil
+}
+
+// getUserProblemHints 读取用户在题目中的提示，根据ProblemAttempt.ErrCount自动解锁达到次数的提示，同时返回提示原始数据和错误次数
+func (svc *ProblemHintServiceImpl) getUserProblemHints(userID uint, problemID uint) ([]*dto.UserProblemHintDto,
+	[]*repository.ProblemHint, int, error) {
+	hints, err := svc.problemHintDao.GetProblemHints(db.Mysql, problemID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	unlocks, err := svc.problemHintDao.GetUserProblemHintUnlocks(db.Mysql, userID, problemID)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	unlockMap := make(map[uint]*repository.ProblemHintUnlock, len(unlocks))
+	for _, unlock := range unlocks {
+		unlockMap[unlock.HintID] = unlock
+	}
+	errCount := 0
+	attempt, err := svc.problemAttemptDao.GetProblemAttemptByID(db.Mysql, userID, problemID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, 0, err
+	}
+	if err == nil {
+		errCount = attempt.ErrCount
+	}
+	answer := make([]*dto.UserProblemHintDto, len(hints))
+	for i, hint := range hints {
+		answer[i] = dto.NewUserProblemHintDto(hint)
+		unlock, ok := unlockMap[hint.ID]
+		if !ok && hint.UnlockErrCount > 0 && errCount >= hint.UnlockErrCount {
+			// 错误次数达到，自动解锁，不扣分
+			unlock = &repository.ProblemHintUnlock{
+				HintID:    hint.ID,
+				UserID:    userID,
+				ProblemID: problemID,
+				ErrCount:  errCount,
+			}
+			if err = svc.problemHintDao.InsertProblemHintUnlock(db.Mysql, unlock); err != nil {
+				return nil, nil, 0, err
+			}
+			ok = true
+		}
+		if ok {
+			answer[i].Content = hint.Content
+			answer[i].Locked = false
+			answer[i].Manual = unlock.Manual
+		} else if hint.UnlockErrCount > 0 {
+			answer[i].RemainingErrCount = hint.UnlockErrCount - errCount
+		}
+	}
+	return answer, hints, errCount, nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	conf "funoj-backend/config"
+	"funoj-backend/consts"
 	e "funoj-backend/consts/error"
 	"funoj-backend/dao"
 	"funoj-backend/db"
@@ -44,21 +45,35 @@ type ProblemMenuService interface {
 	GetSimpleProblemMenuList() ([]*dto.ProblemMenuDtoForSimpleList, *e.Error)
 	// GetProblemMenuByID 获取题单信息
 	GetProblemMenuByID(id uint) (*repository.ProblemMenu, *e.Error)
+	// GetProblemMenuSections 获取题单中按分组和顺序排列的题目
+	GetProblemMenuSections(menuID uint) ([]*dto.ProblemMenuSectionDto, *e.Error)
+	// InsertProblemMenuSection 添加题单分组
+	InsertProblemMenuSection(section *repository.ProblemMenuSection) (uint, *e.Error)
+	// UpdateProblemMenuSection 更新题单分组
+	UpdateProblemMenuSection(section *repository.ProblemMenuSection) *e.Error
+	// DeleteProblemMenuSection 删除题单分组，分组中的题目变为未分组
+	DeleteProblemMenuSection(id uint) *e.Error
+	// ReorderProblemMenu 调整题单中分组的顺序，以及题目所在的分组和顺序
+	ReorderProblemMenu(menuID uint, orders []*request.ProblemMenuSectionOrder) *e.Error
+	// GetUserProblemMenuProgress 获取用户在题单中的做题进度
+	GetUserProblemMenuProgress(ctx *gin.Context, menuID uint) (*dto.ProblemMenuProgressDto, *e.Error)
 }
 
 type ProblemMenuServiceImpl struct {
-	config         *conf.AppConfig
-	problemMenuDao dao.ProblemMenuDao
-	problemDao     dao.ProblemDao
-	sysUserDao     dao.SysUserDao
+	config            *conf.AppConfig
+	problemMenuDao    dao.ProblemMenuDao
+	problemDao        dao.ProblemDao
+	problemAttemptDao dao.ProblemAttemptDao
+	sysUserDao        dao.SysUserDao
 }
 
-func NewProblemMenuService(config *conf.AppConfig, pbm dao.ProblemMenuDao, pb dao.ProblemDao, su dao.SysUserDao) ProblemMenuService {
+func NewProblemMenuService(config *conf.AppConfig, pbm dao.ProblemMenuDao, pb dao.ProblemDao, pa dao.ProblemAttemptDao, su dao.SysUserDao) ProblemMenuService {
 	return &ProblemMenuServiceImpl{
-		config:         config,
-		problemMenuDao: pbm,
-		problemDao:     pb,
-		sysUserDao:     su,
+		config:            config,
+		problemMenuDao:    pbm,
+		problemDao:        pb,
+		problemAttemptDao: pa,
+		sysUserDao:        su,
 	}
 }
 
@@ -119,9 +134,10 @@ func (svc *ProblemMenuServiceImpl) DeleteProblemMenu(id uint, forceDelete bool)
 	// 非强制删除
 	if !forceDelete {
 		var count int64
-		count, err = svc.problemDao.GetProblemCount(db.Mysql, &request.ProblemForList{
-			MenuID: &id,
-		})
+		count, err = svc.problemMenuDao.GetProblemMenuProblemCount(db.Mysql, id)
+		if err != nil {
+			return e.ErrMysql
+		}
 		if count != 0 {
 			return e.NewCustomMsg("题单不为空，请问是否需要强制删除")
 		}
@@ -145,6 +161,15 @@ func (svc *ProblemMenuServiceImpl) GetProblemMenuList(query *request.PageQuery)
 	if query.Query != nil {
 		menuQuery = query.Query.(*request.ProblemMenuForList)
 	}
+	// 用户题单也保存在题单表中，没有指定类型时只查询官方题单
+	if menuQuery == nil {
+		menuQuery = &request.ProblemMenuForList{}
+		query.Query = menuQuery
+	}
+	if menuQuery.Type == nil {
+		menuType := consts.ProblemMenuTypeOfficial
+		menuQuery.Type = &menuType
+	}
 	// 获取题单列表
 	menus, err := svc.problemMenuDao.GetProblemMenuList(db.Mysql, query)
 	if err != nil {
@@ -154,9 +179,7 @@ func (svc *ProblemMenuServiceImpl) GetProblemMenuList(query *request.PageQuery)
 	for i := 0; i < len(menus); i++ {
 		newProblemMenus[i] = dto.NewProblemMenuDtoForList(menus[i])
 		// 读取题单中的题目总数还有作者
-		newProblemMenus[i].ProblemCount, err = svc.problemDao.GetProblemCount(db.Mysql, &request.ProblemForList{
-			MenuID: &newProblemMenus[i].ID,
-		})
+		newProblemMenus[i].ProblemCount, err = svc.problemMenuDao.GetProblemMenuProblemCount(db.Mysql, menus[i].ID)
 		if err != nil {
 			return nil, e.ErrMysql
 		}
@@ -210,3 +233,258 @@ func (svc *ProblemMenuServiceImpl) GetProblemMenuByID(id uint) (*repository.Prob
 	}
 	return menu, nil
 }
+
+func (svc *ProblemMenuServiceImpl) GetProblemMenuSections(menuID uint) ([]*dto.ProblemMenuSectionDto, *e.Error) {
+	if err := svc.checkProblemMenuExist(menuID); err != nil {
+		return nil, err
+	}
+	sections, err := getProblemMenuSections(svc.problemMenuDao, svc.problemDao, menuID, false)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return sections, nil
+}
+
+func (svc *ProblemMenuServiceImpl) InsertProblemMenuSection(section *repository.ProblemMenuSection) (uint, *e.Error) {
+	if err := svc.checkProblemMenuExist(section.MenuID); err != nil {
+		return 0, err
+	}
+	if section.Name == "" {
+		section.Name = "未命名分组"
+	}
+	section.ID = 0
+	if err := svc.problemMenuDao.InsertProblemMenuSection(db.Mysql, section); err != nil {
+		return 0, e.ErrMysql
+	}
+	return section.ID, nil
+}
+
+func (svc *ProblemMenuServiceImpl) UpdateProblemMenuSection(section *repository.ProblemMenuSection) *e.Error {
+	if _, err := svc.problemMenuDao.GetProblemMenuSectionByID(db.Mysql, section.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return e.ErrProblemMenuSectionNotExist
+		}
+		return e.ErrMysql
+	}
+	if err := svc.problemMenuDao.UpdateProblemMenuSection(db.Mysql, section); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *ProblemMenuServiceImpl) DeleteProblemMenuSection(id uint) *e.Error {
+	if _, err := svc.problemMenuDao.GetProblemMenuSectionByID(db.Mysql, id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return e.ErrProblemMenuSectionNotExist
+		}
+		return e.ErrMysql
+	}
+	if err := svc.problemMenuDao.DeleteProblemMenuSection(db.Mysql, id); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *ProblemMenuServiceImpl) ReorderProblemMenu(menuID uint, orders []*request.ProblemMenuSectionOrder) *e.Error {
+	if err := svc.checkProblemMenuExist(menuID); err != nil {
+		return err
+	}
+	sections, err := svc.problemMenuDao.GetProblemMenuSections(db.Mysql, menuID)
+	if err != nil {
+		return e.ErrMysql
+	}
+	sectionMap := make(map[uint]*repository.ProblemMenuSection, len(sections))
+	for _, section := range sections {
+		sectionMap[section.ID] = section
+	}
+	items, err := svc.problemMenuDao.GetProblemMenuItems(db.Mysql, menuID)
+	if err != nil {
+		return e.ErrMysql
+	}
+	inMenu := make(map[uint]bool, len(items))
+	for _, item := range items {
+		inMenu[item.ProblemID] = true
+	}
+	// 检查分组属于该题单，题目属于该题单且不重复
+	ordered := make(map[uint]bool, len(items))
+	for _, order := range orders {
+		if _, ok := sectionMap[order.SectionID]; order.SectionID != 0 && !ok {
+			return e.ErrProblemMenuSectionNotExist
+		}
+		for _, problemID := range order.ProblemIDs {
+			if !inMenu[problemID] || ordered[problemID] {
+				return e.ErrProblemMenuOrderInvalid
+			}
+			ordered[problemID] = true
+		}
+	}
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		for i, order := range orders {
+			if section, ok := sectionMap[order.SectionID]; ok {
+				section.Sort = i
+				if err := svc.problemMenuDao.UpdateProblemMenuSection(tx, section); err != nil {
+					return err
+				}
+			}
+			for j, problemID := range order.ProblemIDs {
+				if err := svc.problemMenuDao.UpdateProblemMenuItem(tx, &repository.ProblemMenuAssociation{
+					ProblemMenuID: menuID,
+					ProblemID:     problemID,
+					SectionID:     order.SectionID,
+					Sort:          j,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *ProblemMenuServiceImpl) GetUserProblemMenuProgress(ctx *gin.Context, menuID uint) (*dto.ProblemMenuProgressDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	menu, err := svc.problemMenuDao.GetProblemMenuByID(db.Mysql, menuID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	if menu.ID == 0 {
+		return nil, e.ErrProblemMenuNotExist
+	}
+	b, err := canViewProblemMenu(svc.problemMenuDao, user, menu)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	if !b {
+		return nil, e.ErrProblemMenuForbidden
+	}
+	progress, err := getProblemMenuProgress(svc.problemMenuDao, svc.problemDao, svc.problemAttemptDao, user.ID, menuID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return progress, nil
+}
+
+// checkProblemMenuExist 检测题单是否存在
+func (svc *ProblemMenuServiceImpl) checkProblemMenuExist(menuID uint) *e.Error {
+	menu, err := svc.problemMenuDao.GetProblemMenuByID(db.Mysql, menuID)
+	if err != nil {
+		return e.ErrMysql
+	}
+	if menu.ID == 0 {
+		return e.ErrProblemMenuNotExist
+	}
+	return nil
+}
+
+// getProblemMenuSections 读取题单中按分组和顺序排列的题目，未分组的题目放在最前面，onlyEnabled表示只返回启用的题目
+func getProblemMenuSections(menuDao dao.ProblemMenuDao, problemDao dao.ProblemDao, menuID uint, onlyEnabled bool) ([]*dto.ProblemMenuSectionDto, error) {
+	sections, err := menuDao.GetProblemMenuSections(db.Mysql, menuID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := menuDao.GetProblemMenuItems(db.Mysql, menuID)
+	if err != nil {
+		return nil, err
+	}
+	problemIDs := make([]uint, len(items))
+	for i, item := range items {
+		problemIDs[i] = item.ProblemID
+	}
+	problems, err := problemDao.GetSimpleProblemsByIDs(db.Mysql, problemIDs)
+	if err != nil {
+		return nil, err
+	}
+	problemMap := make(map[uint]*repository.Problem, len(problems))
+	for _, problem := range problems {
+		problemMap[problem.ID] = problem
+	}
+	answer := make([]*dto.ProblemMenuSectionDto, 0, len(sections)+1)
+	answer = append(answer, &dto.ProblemMenuSectionDto{
+		Name:     "未分组",
+		Problems: []*dto.ProblemMenuItemDto{},
+	})
+	sectionMap := make(map[uint]*dto.ProblemMenuSectionDto, len(sections))
+	for _, section := range sections {
+		sectionDto := &dto.ProblemMenuSectionDto{
+			ID:       section.ID,
+			Name:     section.Name,
+			Sort:     section.Sort,
+			Problems: []*dto.ProblemMenuItemDto{},
+		}
+		sectionMap[section.ID] = sectionDto
+		answer = append(answer, sectionDto)
+	}
+	for _, item := range items {
+		problem, ok := problemMap[item.ProblemID]
+		// 题目已删除或未启用
+		if !ok || (onlyEnabled && problem.Enable != 1) {
+			continue
+		}
+		section, ok := sectionMap[item.SectionID]
+		if !ok {
+			section = answer[0]
+		}
+		section.Problems = append(section.Problems, dto.NewProblemMenuItemDto(item, problem))
+	}
+	// 没有未分组的题目时不返回该分组
+	if len(answer[0].Problems) == 0 {
+		answer = answer[1:]
+	}
+	return answer, nil
+}
+
+// getProblemMenuProgress 计算用户在题单中的做题进度，只统计启用的题目
+func getProblemMenuProgress(menuDao dao.ProblemMenuDao, problemDao dao.ProblemDao, attemptDao dao.ProblemAttemptDao,
+	userID uint, menuID uint) (*dto.ProblemMenuProgressDto, error) {
+	sections, err := getProblemMenuSections(menuDao, problemDao, menuID, true)
+	if err != nil {
+		return nil, err
+	}
+	var problemIDs []uint
+	for _, section := range sections {
+		for _, problem := range section.Problems {
+			problemIDs = append(problemIDs, problem.ProblemID)
+		}
+	}
+	statuses, err := attemptDao.GetProblemAttemptStatuses(db.Mysql, userID, problemIDs)
+	if err != nil {
+		return nil, err
+	}
+	progress := &dto.ProblemMenuProgressDto{
+		MenuID:       menuID,
+		ProblemCount: len(problemIDs),
+		Sections:     sections,
+	}
+	for _, section := range sections {
+		for _, problem := range section.Problems {
+			problem.Status = statuses[problem.ProblemID]
+			switch problem.Status {
+			case consts.AttemptStatusAccepted:
+				section.SolvedCount++
+			case consts.AttemptStatusTrying:
+				section.AttemptedCount++
+			}
+			if problem.Status != consts.AttemptStatusAccepted && progress.NextProblem == nil {
+				progress.NextProblem = problem
+			}
+		}
+		progress.SolvedCount += section.SolvedCount
+		progress.AttemptedCount += section.AttemptedCount
+	}
+	return progress, nil
+}
+
+// canViewProblemMenu 公开的题单所有人可见，私有题单只有创建者和管理员可见，分享的题单关注者也可见
+func canViewProblemMenu(menuDao dao.ProblemMenuDao, user *dto.UserInfo, menu *repository.ProblemMenu) (bool, error) {
+	if menu.Visibility == consts.ProblemMenuVisibilityPublic || menu.CreatorID == user.ID || isAdmin(user) {
+		return true, nil
+	}
+	if menu.Visibility == consts.ProblemMenuVisibilityPrivate {
+		return false, nil
+	}
+	return menuDao.CheckProblemMenuFollowed(db.Mysql, menu.ID, user.ID)
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"strings"
+	"time"
+)
+
+// problemTransitions 题目发布流程中允许的状态变更，以及变更所需的权限
+var problemTransitions = map[int]map[int]string{
+	consts.ProblemStatusDraft: {
+		consts.ProblemStatusInReview: consts.PermissionProblemSubmitReview,
+	},
+	consts.ProblemStatusInReview: {
+		consts.ProblemStatusApproved: consts.PermissionProblemReview,
+		consts.ProblemStatusDraft:    consts.PermissionProblemReview,
+	},
+	consts.ProblemStatusApproved: {
+		consts.ProblemStatusPublished: consts.PermissionProblemPublish,
+		consts.ProblemStatusDraft:     consts.PermissionProblemReview,
+	},
+	consts.ProblemStatusPublished: {
+		consts.ProblemStatusArchived: consts.PermissionProblemArchive,
+	},
+	consts.ProblemStatusArchived: {
+		consts.ProblemStatusDraft: consts.PermissionProblemArchive,
+	},
+}
+
+// ProblemReviewService 题目审核与发布流程
+type ProblemReviewService interface {
+	// GetProblemChecklist 获取题目的检查清单
+	GetProblemChecklist(problemID uint) (*dto.ProblemChecklistDto, *e.Error)
+	// TransitProblemStatus 变更题目状态，publishAt不为空且晚于当前时间时，发布操作改为定时发布
+	TransitProblemStatus(ctx *gin.Context, problemID uint, status int, comment string, publishAt *time.Time) *e.Error
+	// GetProblemReviewList 获取题目的状态变更记录
+	GetProblemReviewList(problemID uint) ([]*dto.ProblemReviewDto, *e.Error)
+	// PublishScheduledProblems 发布所有到达定时发布时间的题目，由定时任务调用
+	PublishScheduledProblems() *e.Error
+}
+
+type ProblemReviewServiceImpl struct {
+	problemDao       dao.ProblemDao
+	problemCaseDao   dao.ProblemCaseDao
+	problemReviewDao dao.ProblemReviewDao
+	submissionDao    dao.SubmissionDao
+	sysUserDao       dao.SysUserDao
+}
+
+func NewProblemReviewService(pd dao.ProblemDao, pcd dao.ProblemCaseDao, prd dao.ProblemReviewDao, sd dao.SubmissionDao,
+	sud dao.SysUserDao) ProblemReviewService {
+	return &ProblemReviewServiceImpl{
+		problemDao:       pd,
+		problemCaseDao:   pcd,
+		problemReviewDao: prd,
+		submissionDao:    sd,
+		sysUserDao:       sud,
+	}
+}
+
+func (svc *ProblemReviewServiceImpl) GetProblemChecklist(problemID uint) (*dto.ProblemChecklistDto, *e.Error) {
+	problem, err := svc.problemDao.GetProblemByID(db.Mysql, problemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrProblemNotExist
+	}
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	checklist, err := svc.checkProblem(problem)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	return checklist, nil
+}
+
+func (svc *ProblemReviewServiceImpl) TransitProblemStatus(ctx *gin.Context, problemID uint, status int, comment string,
+	publishAt *time.Time) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	problem, err := svc.problemDao.GetProblemByID(db.Mysql, problemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.ErrProblemNotExist
+	}
+	if err != nil {
+		return e.ErrMysql
+	}
+	from := dto.GetProblemStatus(problem)
+	permission, ok := problemTransitions[from][status]
+	if !ok {
+		return e.ErrProblemStatusTransitionInvalid
+	}
+	// 题目作者可以提交自己的题目审核
+	isCreatorSubmit := status == consts.ProblemStatusInReview && problem.CreatorID == user.ID
+	if !isAdmin(user) && !hasPermission(user, permission) && !isCreatorSubmit {
+		return e.ErrPermissionInvalid
+	}
+	review := &repository.ProblemReview{
+		ProblemID:  problemID,
+		OperatorID: user.ID,
+		FromStatus: from,
+		ToStatus:   status,
+		Comment:    strings.TrimSpace(comment),
+	}
+	// 进入审核、审核通过、发布时需要通过检查清单
+	if status == consts.ProblemStatusInReview || status == consts.ProblemStatusApproved ||
+		status == consts.ProblemStatusPublished {
+		checklist, err := svc.checkProblem(problem)
+		if err != nil {
+			log.Println(err)
+			return e.ErrMysql
+		}
+		if !checklist.Passed() {
+			return e.ErrProblemChecklistNotPassed
+		}
+		checklistJson, _ := json.Marshal(checklist)
+		review.Checklist = string(checklistJson)
+	}
+	// 定时发布，保持审核通过状态，到时间后由定时任务发布
+	var scheduledAt *time.Time
+	if status == consts.ProblemStatusPublished && publishAt != nil && publishAt.After(time.Now()) {
+		scheduledAt = publishAt
+		review.ToStatus = consts.ProblemStatusApproved
+		review.Comment = strings.TrimSpace(review.Comment + " 定时发布：" + publishAt.Format("2006-01-02 15:04:05"))
+	}
+	// 旧题目的状态为0，按数据表中的原值更新
+	if err = svc.saveTransition(review, problem.Status, scheduledAt); err != nil {
+		if errors.Is(err, errProblemStatusChanged) {
+			return e.ErrProblemStatusTransitionInvalid
+		}
+		log.Println(err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *ProblemReviewServiceImpl) GetProblemReviewList(problemID uint) ([]*dto.ProblemReviewDto, *e.Error) {
+	reviews, err := svc.problemReviewDao.GetProblemReviewsByProblemID(db.Mysql, problemID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.ProblemReviewDto, len(reviews))
+	for i, review := range reviews {
+		answer[i] = dto.NewProblemReviewDto(review)
+		// 定时任务执行的变更没有操作人
+		if review.OperatorID == 0 {
+			answer[i].OperatorName = "系统"
+			continue
+		}
+		answer[i].OperatorName, err = svc.sysUserDao.GetUserNameByID(db.Mysql, review.OperatorID)
+		if err != nil {
+			return nil, e.ErrMysql
+		}
+	}
+	return answer, nil
+}
+
+func (svc *ProblemReviewServiceImpl) PublishScheduledProblems() *e.Error {
+	problems, err := svc.problemDao.GetScheduledProblems(db.Mysql, time.Now())
+	if err != nil {
+		return e.ErrMysql
+	}
+	for _, problem := range problems {
+		review := &repository.ProblemReview{
+			ProblemID:  problem.ID,
+			FromStatus: consts.ProblemStatusApproved,
+			ToStatus:   consts.ProblemStatusPublished,
+			Comment:    "定时发布",
+		}
+		// 已被手动变更状态的题目不再发布
+		if err = svc.saveTransition(review, consts.ProblemStatusApproved, nil); errors.Is(err, errProblemStatusChanged) {
+			continue
+		}
+		if err != nil {
+			log.Println(err)
+			return e.ErrMysql
+		}
+	}
+	return nil
+}
+
+// errProblemStatusChanged 保存状态变更时题目状态已被修改
+var errProblemStatusChanged = errors.New("problem status changed")
+
+// saveTransition 题目状态仍为fromStatus时保存题目状态并记录变更，已发布的题目可用，其余状态不可用，
+// 状态已被修改时返回errProblemStatusChanged
+func (svc *ProblemReviewServiceImpl) saveTransition(review *repository.ProblemReview, fromStatus int, publishAt *time.Time) error {
+	enable := -1
+	if review.ToStatus == consts.ProblemStatusPublished {
+		enable = 1
+	}
+	return db.Mysql.Transaction(func(tx *gorm.DB) error {
+		updated, err := svc.problemDao.UpdateProblemStatus(tx, review.ProblemID, fromStatus, review.ToStatus, enable, publishAt)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			return errProblemStatusChanged
+		}
+		return svc.problemReviewDao.InsertProblemReview(tx, review)
+	})
+}
+
+// checkProblem 检查题目是否有用例、题解中的参考代码是否通过、描述是否不为空
+func (svc *ProblemReviewServiceImpl) checkProblem(problem *repository.Problem) (*dto.ProblemChecklistDto, error) {
+	caseCount, err := svc.problemCaseDao.GetProblemCaseCount(db.Mysql, &request.ProblemCaseForList{
+		ProblemID: problem.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	referencePassed, err := svc.submissionDao.CheckReferenceSolutionAccepted(db.Mysql, problem.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ProblemChecklistDto{
+		HasCases:          caseCount != 0,
+		ReferencePassed:   referencePassed,
+		StatementNotEmpty: strings.TrimSpace(problem.Description) != "" && strings.TrimSpace(problem.Title) != "",
+	}, nil
+}
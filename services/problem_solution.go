@@ -0,0 +1,265 @@
+package services
+
+import (
+	"errors"
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/model/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// ProblemSolutionService 题解管理及阅读
+type ProblemSolutionService interface {
+	// InsertProblemSolution 添加题解
+	InsertProblemSolution(ctx *gin.Context, solutionReq *request.ProblemSolution) (uint, *e.Error)
+	// UpdateProblemSolution 更新题解，参考代码整体替换
+	UpdateProblemSolution(solutionReq *request.ProblemSolution) *e.Error
+	// DeleteProblemSolution 删除题解
+	DeleteProblemSolution(id uint) *e.Error
+	// GetProblemSolutionByID 管理员获取题解详细信息
+	GetProblemSolutionByID(id uint) (*dto.ProblemSolutionDto, *e.Error)
+	// GetProblemSolutionList 管理员获取题解列表
+	GetProblemSolutionList(query *request.PageQuery) (*response.PageInfo, *e.Error)
+	// GetUserProblemSolutions 用户获取一道题目的所有题解，未公开的题解只返回标题
+	GetUserProblemSolutions(ctx *gin.Context, problemID uint) ([]*dto.ProblemSolutionDto, *e.Error)
+	// GetUserProblemSolution 用户阅读一篇题解
+	GetUserProblemSolution(ctx *gin.Context, id uint) (*dto.ProblemSolutionDto, *e.Error)
+}
+
+type ProblemSolutionServiceImpl struct {
+	problemSolutionDao dao.ProblemSolutionDao
+	problemDao         dao.ProblemDao
+	submissionDao      dao.SubmissionDao
+	sysUserDao         dao.SysUserDao
+}
+
+func NewProblemSolutionService(psd dao.ProblemSolutionDao, pd dao.ProblemDao, sd dao.SubmissionDao, sud dao.SysUserDao) ProblemSolutionService {
+	return &ProblemSolutionServiceImpl{
+		problemSolutionDao: psd,
+		problemDao:         pd,
+		submissionDao:      sd,
+		sysUserDao:         sud,
+	}
+}
+
+func (svc *ProblemSolutionServiceImpl) InsertProblemSolution(ctx *gin.Context, solutionReq *request.ProblemSolution) (uint, *e.Error) {
+	solution := newProblemSolution(solutionReq)
+	solution.ID = 0
+	if !svc.checkVisibleType(solution) {
+		return 0, e.ErrProblemSolutionVisibleTypeWrong
+	}
+	// 检验题目是否存在
+	_, err := svc.problemDao.GetProblemByID(db.Mysql, solution.ProblemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, e.ErrProblemNotExist
+	}
+	if err != nil {
+		return 0, e.ErrMysql
+	}
+	if solution.Title == "" {
+		solution.Title = "官方题解"
+	}
+	solution.AuthorID = ctx.Keys["user"].(*dto.UserInfo).ID
+	if err = svc.problemSolutionDao.InsertProblemSolution(db.Mysql, solution); err != nil {
+		log.Println(err)
+		return 0, e.ErrMysql
+	}
+	return solution.ID, nil
+}
+
+func (svc *ProblemSolutionServiceImpl) UpdateProblemSolution(solutionReq *request.ProblemSolution) *e.Error {
+	solution := newProblemSolution(solutionReq)
+	if !svc.checkVisibleType(solution) {
+		return e.ErrProblemSolutionVisibleTypeWrong
+	}
+	if _, err := svc.problemSolutionDao.GetProblemSolutionByID(db.Mysql, solution.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return e.ErrProblemSolutionNotExist
+		}
+		return e.ErrMysql
+	}
+	solution.UpdatedAt = time.Now()
+	if err := svc.problemSolutionDao.UpdateProblemSolution(db.Mysql, solution); err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *ProblemSolutionServiceImpl) DeleteProblemSolution(id uint) *e.Error {
+	if err := svc.problemSolutionDao.DeleteProblemSolutionByID(db.Mysql, id); err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *ProblemSolutionServiceImpl) GetProblemSolutionByID(id uint) (*dto.ProblemSolutionDto, *e.Error) {
+	solution, err := svc.problemSolutionDao.GetProblemSolutionByID(db.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrProblemSolutionNotExist
+	}
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	solutionDto := dto.NewProblemSolutionDto(solution)
+	solutionDto.AuthorName, err = svc.sysUserDao.GetUserNameByID(db.Mysql, solution.AuthorID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return solutionDto, nil
+}
+
+func (svc *ProblemSolutionServiceImpl) GetProblemSolutionList(query *request.PageQuery) (*response.PageInfo, *e.Error) {
+	var solutionQuery *request.ProblemSolutionForList
+	if query.Query != nil {
+		solutionQuery = query.Query.(*request.ProblemSolutionForList)
+	}
+	solutions, err := svc.problemSolutionDao.GetProblemSolutionList(db.Mysql, query)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	newSolutions := make([]*dto.ProblemSolutionDtoForList, len(solutions))
+	for i := 0; i < len(solutions); i++ {
+		newSolutions[i] = dto.NewProblemSolutionDtoForList(solutions[i])
+		newSolutions[i].AuthorName, err = svc.sysUserDao.GetUserNameByID(db.Mysql, solutions[i].AuthorID)
+		if err != nil {
+			return nil, e.ErrMysql
+		}
+	}
+	var count int64
+	count, err = svc.problemSolutionDao.GetProblemSolutionCount(db.Mysql, solutionQuery)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return &response.PageInfo{
+		Total: count,
+		Size:  int64(len(newSolutions)),
+		List:  newSolutions,
+	}, nil
+}
+
+func (svc *ProblemSolutionServiceImpl) GetUserProblemSolutions(ctx *gin.Context, problemID uint) ([]*dto.ProblemSolutionDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if err := svc.checkProblemVisible(user, problemID); err != nil {
+		return nil, err
+	}
+	solutions, err := svc.problemSolutionDao.GetProblemSolutionsByProblemID(db.Mysql, problemID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.ProblemSolutionDto, len(solutions))
+	for i, solution := range solutions {
+		visible, err := svc.checkSolutionVisible(user.ID, solution)
+		if err != nil {
+			return nil, e.ErrMysql
+		}
+		answer[i] = dto.NewProblemSolutionDto(solution)
+		answer[i].AuthorName, err = svc.sysUserDao.GetUserNameByID(db.Mysql, solution.AuthorID)
+		if err != nil {
+			return nil, e.ErrMysql
+		}
+		if !visible {
+			answer[i].Locked = true
+			answer[i].Content = ""
+			answer[i].Codes = []*dto.ProblemSolutionCodeDto{}
+		}
+	}
+	return answer, nil
+}
+
+func (svc *ProblemSolutionServiceImpl) GetUserProblemSolution(ctx *gin.Context, id uint) (*dto.ProblemSolutionDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	solution, err := svc.problemSolutionDao.GetProblemSolutionByID(db.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrProblemSolutionNotExist
+	}
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	if err := svc.checkProblemVisible(user, solution.ProblemID); err != nil {
+		return nil, err
+	}
+	visible, err := svc.checkSolutionVisible(user.ID, solution)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	if !visible {
+		return nil, e.ErrProblemSolutionLocked
+	}
+	solutionDto := dto.NewProblemSolutionDto(solution)
+	solutionDto.AuthorName, err = svc.sysUserDao.GetUserNameByID(db.Mysql, solution.AuthorID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return solutionDto, nil
+}
+
+// newProblemSolution 根据请求构建题解，参考代码只取语言和代码
+func newProblemSolution(solutionReq *request.ProblemSolution) *repository.ProblemSolution {
+	solution := &repository.ProblemSolution{
+		Model:       gorm.Model{ID: solutionReq.ID},
+		ProblemID:   solutionReq.ProblemID,
+		Title:       solutionReq.Title,
+		Content:     solutionReq.Content,
+		VisibleType: solutionReq.VisibleType,
+		VisibleAt:   solutionReq.VisibleAt,
+		Codes:       make([]*repository.ProblemSolutionCode, len(solutionReq.Codes)),
+	}
+	for i, code := range solutionReq.Codes {
+		solution.Codes[i] = &repository.ProblemSolutionCode{
+			Language: code.Language,
+			Code:     code.Code,
+		}
+	}
+	return solution
+}
+
+// checkVisibleType 检验题解的可见规则是否合法
+func (svc *ProblemSolutionServiceImpl) checkVisibleType(solution *repository.ProblemSolution) bool {
+	switch solution.VisibleType {
+	case consts.SolutionVisibleAlways, consts.SolutionVisibleAfterAccepted:
+		return true
+	case consts.SolutionVisibleAfterDate:
+		return !solution.VisibleAt.IsZero()
+	default:
+		return false
+	}
+}
+
+// checkProblemVisible 普通用户只能查看启用的题目的题解
+func (svc *ProblemSolutionServiceImpl) checkProblemVisible(user *dto.UserInfo, problemID uint) *e.Error {
+	problem, err := svc.problemDao.GetProblemByID(db.Mysql, problemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return e.ErrProblemNotExist
+	}
+	if err != nil {
+		return e.ErrMysql
+	}
+	if problem.Enable != 1 && !isAdmin(user) {
+		return e.ErrProblemNotExist
+	}
+	return nil
+}
+
+// checkSolutionVisible 检验题解对用户是否可见
+func (svc *ProblemSolutionServiceImpl) checkSolutionVisible(userID uint, solution *repository.ProblemSolution) (bool, error) {
+	switch solution.VisibleType {
+	case consts.SolutionVisibleAlways:
+		return true, nil
+	case consts.SolutionVisibleAfterAccepted:
+		return svc.submissionDao.CheckUserAcceptedProblem(db.Mysql, userID, solution.ProblemID, 0)
+	case consts.SolutionVisibleAfterDate:
+		return !time.Now().Before(solution.VisibleAt), nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"funoj-backend/model/dto"
+	"funoj-backend/model/repository"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+)
+
+// problemPercentiles 题目统计中需要计算的耗时、内存分位数
+var problemPercentiles = []int{50, 75, 90, 99}
+
+// usageBucketBits 耗时、内存分桶保留的有效二进制位数，分桶下界与实际值的相对误差小于1/2^(usageBucketBits-1)
+const usageBucketBits = 5
+
+// getUsageBucket 获取耗时或内存所在分桶的下界，较小的值单独分桶
+func getUsageBucket(value int64) int64 {
+	if value < 1<<usageBucketBits {
+		if value < 0 {
+			return 0
+		}
+		return value
+	}
+	shift := bits.Len64(uint64(value)) - usageBucketBits
+	return value >> shift << shift
+}
+
+// getUsagePercentiles 根据category的分桶统计计算分位数，值为分位数所在分桶的下界
+func getUsagePercentiles(items []*repository.ProblemStatisticItem, category string) []*dto.PercentileItem {
+	type usageBucket struct {
+		value int64
+		count int64
+	}
+	var buckets []usageBucket
+	var total int64
+	for _, item := range items {
+		if item.Category != category {
+			continue
+		}
+		value, err := strconv.ParseInt(item.Item, 10, 64)
+		if err != nil {
+			continue
+		}
+		buckets = append(buckets, usageBucket{value: value, count: item.Count})
+		total += item.Count
+	}
+	answer := make([]*dto.PercentileItem, 0, len(problemPercentiles))
+	if total == 0 {
+		return answer
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].value < buckets[j].value
+	})
+	for _, percentile := range problemPercentiles {
+		// 第percentile百分位在升序排列中的位置，从1开始
+		rank := int64(math.Ceil(float64(total) * float64(percentile) / 100))
+		var count int64
+		for _, bucket := range buckets {
+			count += bucket.count
+			if count >= rank {
+				answer = append(answer, &dto.PercentileItem{Percentile: percentile, Value: bucket.value})
+				break
+			}
+		}
+	}
+	return answer
+}
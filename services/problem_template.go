@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+)
+
+// ProblemTemplateService 题目模板代码管理，problemID为0时操作全局默认模板
+type ProblemTemplateService interface {
+	// SaveProblemTemplate 保存模板代码，生成一个新版本，返回版本号
+	SaveProblemTemplate(ctx *gin.Context, problemID uint, language string, code string) (int, *e.Error)
+	// RestoreProblemTemplate 将模板代码恢复到指定版本，生成一个新版本，返回版本号
+	RestoreProblemTemplate(ctx *gin.Context, problemID uint, language string, version int) (int, *e.Error)
+	// DeleteProblemTemplate 删除题目某个语言的模板，之后使用全局默认模板
+	DeleteProblemTemplate(problemID uint, language string) *e.Error
+	// GetProblemTemplateHistory 获取模板代码的所有版本
+	GetProblemTemplateHistory(problemID uint, language string) ([]*dto.ProblemTemplateDto, *e.Error)
+	// GetDefaultTemplates 获取所有语言的全局默认模板
+	GetDefaultTemplates() ([]*dto.ProblemTemplateDto, *e.Error)
+}
+
+type ProblemTemplateServiceImpl struct {
+	problemTemplateDao dao.ProblemTemplateDao
+	problemDao         dao.ProblemDao
+}
+
+func NewProblemTemplateService(ptd dao.ProblemTemplateDao, pd dao.ProblemDao) ProblemTemplateService {
+	return &ProblemTemplateServiceImpl{
+		problemTemplateDao: ptd,
+		problemDao:         pd,
+	}
+}
+
+func (svc *ProblemTemplateServiceImpl) SaveProblemTemplate(ctx *gin.Context, problemID uint, language string, code string) (int, *e.Error) {
+	if !consts.IsProgramLanguageSupported(language) {
+		return 0, e.ErrLanguageNotSupported
+	}
+	if problemID != 0 {
+		if _, err := svc.problemDao.GetProblemByID(db.Mysql, problemID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, e.ErrProblemNotExist
+			}
+			return 0, e.ErrMysql
+		}
+	}
+	template := &repository.ProblemTemplate{
+		ProblemID: problemID,
+		Language:  language,
+		Code:      code,
+		CreatorID: ctx.Keys["user"].(*dto.UserInfo).ID,
+	}
+	if err := insertProblemTemplateVersion(svc.problemTemplateDao, db.Mysql, template); err != nil {
+		log.Println(err)
+		return 0, e.ErrMysql
+	}
+	return template.Version, nil
+}
+
+func (svc *ProblemTemplateServiceImpl) RestoreProblemTemplate(ctx *gin.Context, problemID uint, language string, version int) (int, *e.Error) {
+	old, err := svc.problemTemplateDao.GetProblemTemplateByVersion(db.Mysql, problemID, language, version)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, e.ErrProblemTemplateNotExist
+	}
+	if err != nil {
+		return 0, e.ErrMysql
+	}
+	return svc.SaveProblemTemplate(ctx, problemID, language, old.Code)
+}
+
+func (svc *ProblemTemplateServiceImpl) DeleteProblemTemplate(problemID uint, language string) *e.Error {
+	if err := svc.problemTemplateDao.DeleteProblemTemplates(db.Mysql, problemID, language); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *ProblemTemplateServiceImpl) GetProblemTemplateHistory(problemID uint, language string) ([]*dto.ProblemTemplateDto, *e.Error) {
+	templates, err := svc.problemTemplateDao.GetProblemTemplateHistory(db.Mysql, problemID, language)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.ProblemTemplateDto, len(templates))
+	for i, template := range templates {
+		answer[i] = dto.NewProblemTemplateDto(template)
+	}
+	return answer, nil
+}
+
+func (svc *ProblemTemplateServiceImpl) GetDefaultTemplates() ([]*dto.ProblemTemplateDto, *e.Error) {
+	answer := make([]*dto.ProblemTemplateDto, len(consts.ProgramLanguages))
+	for i, language := range consts.ProgramLanguages {
+		template, err := getProblemTemplate(svc.problemTemplateDao, 0, language)
+		if err != nil {
+			log.Println(err)
+			return nil, e.ErrMysql
+		}
+		answer[i] = template
+	}
+	return answer, nil
+}
+
+// problemTemplateInsertRetry 同时保存模板导致版本号冲突时最多尝试的次数
+const problemTemplateInsertRetry = 3
+
+// insertProblemTemplateVersion 在最新版本的基础上添加一个新版本，版本号被同时保存的模板占用时重新读取最新版本
+func insertProblemTemplateVersion(templateDao dao.ProblemTemplateDao, tx *gorm.DB, template *repository.ProblemTemplate) error {
+	var err error
+	for i := 0; i < problemTemplateInsertRetry; i++ {
+		var latest *repository.ProblemTemplate
+		latest, err = templateDao.GetLatestProblemTemplate(tx, template.ProblemID, template.Language)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		template.Version = latest.Version + 1
+		if err = templateDao.InsertProblemTemplate(tx, template); !dao.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// getProblemTemplate 获取题目某个语言的模板代码，
+// 题目没有模板时使用全局默认模板，数据库中没有全局默认模板时读取模板文件
+func getProblemTemplate(templateDao dao.ProblemTemplateDao, problemID uint, language string) (*dto.ProblemTemplateDto, error) {
+	template, err := templateDao.GetLatestProblemTemplate(db.Mysql, problemID, language)
+	if err == nil {
+		return dto.NewProblemTemplateDto(template), nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if problemID != 0 {
+		return getProblemTemplate(templateDao, 0, language)
+	}
+	code, err := utils.GetAcmCodeTemplate(language)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ProblemTemplateDto{
+		Language:  language,
+		Code:      code,
+		IsDefault: true,
+	}, nil
+}
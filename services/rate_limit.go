@@ -0,0 +1,49 @@
+package services
+
+import (
+	"funoj-backend/db"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+// tokenBucketScript 同时检查多个令牌桶，所有桶都有令牌时才各取走一个
+// KEYS为桶的key，ARGV为当前毫秒时间，之后每个桶依次为容量和每毫秒补充的令牌数
+var tokenBucketScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local buckets = {}
+for i, key in ipairs(KEYS) do
+	local capacity = tonumber(ARGV[i * 2])
+	local rate = tonumber(ARGV[i * 2 + 1])
+	local bucket = redis.call('HMGET', key, 'tokens', 'time')
+	local tokens = tonumber(bucket[1]) or capacity
+	local last = tonumber(bucket[2]) or now
+	tokens = math.min(capacity, tokens + math.max(0, now - last) * rate)
+	if tokens < 1 then
+		return 0
+	end
+	buckets[i] = {tokens - 1, math.ceil(capacity / rate)}
+end
+for i, key in ipairs(KEYS) do
+	redis.call('HMSET', key, 'tokens', buckets[i][1], 'time', now)
+	redis.call('PEXPIRE', key, buckets[i][2])
+end
+return 1
+`)
+
+// tokenBucket 每分钟最多perMinute次，允许短时间内连续使用完
+type tokenBucket struct {
+	key       string
+	perMinute int
+}
+
+// takeTokens 从所有桶中各取一个令牌，有一个桶为空时返回false且不消耗令牌
+func takeTokens(buckets ...*tokenBucket) (bool, error) {
+	keys := make([]string, len(buckets))
+	args := []interface{}{time.Now().UnixMilli()}
+	for i, bucket := range buckets {
+		keys[i] = bucket.key
+		args = append(args, bucket.perMinute, float64(bucket.perMinute)/float64(time.Minute.Milliseconds()))
+	}
+	allowed, err := tokenBucketScript.Run(db.Redis, keys, args...).Int()
+	return allowed == 1, err
+}
@@ -1,15 +1,54 @@
 package services
 
-import "github.com/google/wire"
+import (
+	"funoj-backend/consts"
+	"funoj-backend/model/dto"
+	"github.com/google/wire"
+)
 
 var ProviderSet = wire.NewSet(
 	NewAccountService,
 	NewAuthService,
+	NewBlockService,
+	NewCodeDraftService,
+	NewDebugService,
+	NewDiscussionService,
+	NewMistakeNoteService,
+	NewProblemAttemptService,
 	NewProblemMenuService,
 	NewProblemService,
 	NewProblemCaseService,
+	NewProblemHintService,
+	NewProblemReviewService,
+	NewProblemSolutionService,
+	NewProblemTemplateService,
 	NewSubmissionService,
 	NewSysPermissionService,
 	NewSysRoleService,
 	NewSysUserService,
+	NewTraceService,
+	NewUserNoteService,
+	NewUserProblemMenuService,
+	NewVisualizationService,
+	StartJobs,
 )
+
+// isAdmin 检验用户是否拥有超级管理员角色
+func isAdmin(user *dto.UserInfo) bool {
+	for _, roleID := range user.Roles {
+		if roleID == consts.AdminID {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPermission 检验用户是否拥有某个权限
+func hasPermission(user *dto.UserInfo, permission string) bool {
+	for _, code := range user.Permissions {
+		if code == permission {
+			return true
+		}
+	}
+	return false
+}
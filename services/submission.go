@@ -1,86 +1,145 @@
 package services
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	conf "funoj-backend/config"
+	"funoj-backend/consts"
 	e "funoj-backend/consts/error"
 	"funoj-backend/dao"
 	"funoj-backend/db"
 	"funoj-backend/model/dto"
 	"funoj-backend/model/form/request"
 	"funoj-backend/model/form/response"
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"gorm.io/gorm"
+	"io"
 	"log"
 	"strconv"
 	"time"
 )
 
+const (
+	// SubmitRateLimitKeyPrefix 运行和提交的令牌桶
+	SubmitRateLimitKeyPrefix = "ratelimit-"
+	// DuplicateSubmissionKeyPrefix 最近提交的代码，值为提交id
+	DuplicateSubmissionKeyPrefix = "submission-dedup-"
+)
+
 type SubmissionService interface {
-	// GetActivityMap 获取活动图
-	GetActivityMap(ctx *gin.Context, year int) ([]*dto.ActivityItem, *e.Error)
+	// GetActivityMap 获取活动图和连续提交天数，日期按timezone计算，timezone为空时使用服务器时区
+	GetActivityMap(ctx *gin.Context, year int, timezone string) (*dto.ActivityMapDto, *e.Error)
 	// GetActivityYear 获取用户有活动的年份
-	GetActivityYear(ctx *gin.Context) ([]string, *e.Error)
+	GetActivityYear(ctx *gin.Context, timezone string) ([]string, *e.Error)
 	// GetUserSubmissionList 获取用户
 	GetUserSubmissionList(ctx *gin.Context, pageQuery *request.PageQuery) (*response.PageInfo, *e.Error)
+	// GetSubmission 获取提交详情，只有提交者和管理员可以查看，隐藏用例的数据只对管理员返回
+	GetSubmission(ctx *gin.Context, id uint) (*dto.SubmissionDetailDto, *e.Error)
+	// DiffSubmissions 对比用户在同一道题目中的两次提交的代码
+	DiffSubmissions(ctx *gin.Context, oldID uint, newID uint) (*dto.SubmissionDiffDto, *e.Error)
+	// ShareSubmission 分享自己的提交，返回分享码，已分享时返回原来的分享码
+	ShareSubmission(ctx *gin.Context, id uint) (string, *e.Error)
+	// CancelShareSubmission 取消分享，原来的链接失效
+	CancelShareSubmission(ctx *gin.Context, id uint) *e.Error
+	// GetSharedSubmission 通过分享码获取提交，不需要登录，只返回代码和判题结果
+	GetSharedSubmission(shareCode string) (*dto.SharedSubmissionDto, *e.Error)
+	// GetSubmissionListForAdmin 管理员查询所有用户的提交，可以按时间、耗时和内存排序
+	GetSubmissionListForAdmin(pageQuery *request.PageQuery) (*response.PageInfo, *e.Error)
+	// ExportSubmissions 按条件导出提交为csv或json，逐行读取并写入w，不会一次加载到内存中
+	ExportSubmissions(query *request.SubmissionForList, sortProperty string, sortRule string, format string, w io.Writer) *e.Error
+	// CheckSubmitRateLimit 运行或提交前检查用户和用户在题目中的频率限制，action为consts.SubmitActionRun等，超过时返回ErrSubmitTooFrequent
+	CheckSubmitRateLimit(ctx *gin.Context, problemID uint, action string) *e.Error
+	// GetDuplicateSubmission 提交前检查，一段时间内在同一道题目提交过相同的代码时返回上次的结果，没有时返回nil，不需要再判题
+	GetDuplicateSubmission(ctx *gin.Context, problemID uint, language string, code string) (*dto.SubmissionDetailDto, *e.Error)
+	// ArchiveSubmissions 将超过ArchiveConfig.RetentionDays天的提交的代码和用例数据压缩后归档到对象存储，保留提交记录，由定时任务调用
+	ArchiveSubmissions() *e.Error
+	// InsertSubmission 保存一次判题结果，同时增量维护题目统计和用户做题情况，题目不允许的语言返回ErrLanguageNotSupported
+	InsertSubmission(submission *repository.Submission) *e.Error
 }
 
 type SubmissionServiceImpl struct {
-	submissionDao dao.SubmissionDao
-	problemDao    dao.ProblemDao
+	config               *conf.AppConfig
+	submissionDao        dao.SubmissionDao
+	problemDao           dao.ProblemDao
+	problemCaseDao       dao.ProblemCaseDao
+	problemLanguageDao   dao.ProblemLanguageDao
+	problemAttemptDao    dao.ProblemAttemptDao
+	problemStatisticDao  dao.ProblemStatisticDao
+	sysUserDao           dao.SysUserDao
+	userNoteDao          dao.UserNoteDao
+	visualizationService VisualizationService
 }
 
-func NewSubmissionService(submissionDao dao.SubmissionDao, problemDao dao.ProblemDao) SubmissionService {
+func NewSubmissionService(config *conf.AppConfig, submissionDao dao.SubmissionDao, problemDao dao.ProblemDao, problemCaseDao dao.ProblemCaseDao,
+	problemLanguageDao dao.ProblemLanguageDao, problemAttemptDao dao.ProblemAttemptDao, problemStatisticDao dao.ProblemStatisticDao, sysUserDao dao.SysUserDao,
+	userNoteDao dao.UserNoteDao, visualizationService VisualizationService) SubmissionService {
 	return &SubmissionServiceImpl{
-		submissionDao: submissionDao,
-		problemDao:    problemDao,
+		config:               config,
+		submissionDao:        submissionDao,
+		problemDao:           problemDao,
+		problemCaseDao:       problemCaseDao,
+		problemLanguageDao:   problemLanguageDao,
+		problemAttemptDao:    problemAttemptDao,
+		problemStatisticDao:  problemStatisticDao,
+		sysUserDao:           sysUserDao,
+		userNoteDao:          userNoteDao,
+		visualizationService: visualizationService,
 	}
 }
 
-func (svc *SubmissionServiceImpl) GetActivityMap(ctx *gin.Context, year int) ([]*dto.ActivityItem, *e.Error) {
+func (svc *SubmissionServiceImpl) GetActivityMap(ctx *gin.Context, year int, timezone string) (*dto.ActivityMapDto, *e.Error) {
 	user := ctx.Keys["user"].(*dto.UserInfo)
-	var startDate time.Time
-	var endDate time.Time
-	// 如果year == 0，获取以今天截至的一年的数据
-	if year == 0 {
-		endDate = time.Now()
-		startDate = time.Date(endDate.Year()-1, endDate.Month()+1, endDate.Day(),
-			0, 0, 0, 0, time.Local)
-	} else {
-		startDate, endDate = svc.getYearRange(year)
+	loc, err := getActivityLocation(timezone)
+	if err != nil {
+		return nil, e.ErrBadRequest
 	}
-	submissions, err := svc.submissionDao.GetUserSimpleSubmissionsByTime(db.Mysql, user.ID, startDate, endDate)
+	days, err := getUserActivityDays(svc.submissionDao, user.ID, loc)
 	if err != nil {
+		log.Println(err)
 		return nil, e.ErrMysql
 	}
-	// 构建活动数据
-	m := make(map[string]int, 366)
-	for i := 0; i < len(submissions); i++ {
-		date := submissions[i].CreatedAt.Format("2006-01-02")
-		m[date]++
-	}
-	answer := make([]*dto.ActivityItem, len(m))
-	i := 0
-	for k, v := range m {
-		answer[i] = &dto.ActivityItem{
-			Date:  k,
-			Count: v,
+	now := time.Now().In(loc)
+	var startDate string
+	var endDate string
+	// 如果year == 0，获取以今天截至的一年的数据
+	if year == 0 {
+		endDate = now.Format(activityDate)
+		startDate = now.AddDate(-1, 0, 1).Format(activityDate)
+	} else {
+		startDate = time.Date(year, 1, 1, 0, 0, 0, 0, loc).Format(activityDate)
+		endDate = time.Date(year, 12, 31, 0, 0, 0, 0, loc).Format(activityDate)
+	}
+	answer := &dto.ActivityMapDto{Items: []*dto.ActivityItem{}}
+	for _, day := range days {
+		if day.Date >= startDate && day.Date <= endDate {
+			answer.Items = append(answer.Items, day)
 		}
-		i++
 	}
+	answer.CurrentStreak, answer.LongestStreak = getActivityStreaks(days, now)
 	return answer, nil
 }
 
-func (svc *SubmissionServiceImpl) GetActivityYear(ctx *gin.Context) ([]string, *e.Error) {
-	answer := []string{}
+func (svc *SubmissionServiceImpl) GetActivityYear(ctx *gin.Context, timezone string) ([]string, *e.Error) {
 	user := ctx.Keys["user"].(*dto.UserInfo)
-	beginYear := 2022
-	currentYear := time.Now().Year()
-	for i := beginYear; i <= currentYear; i++ {
-		beginDate, endDate := svc.getYearRange(i)
-		b, err := svc.submissionDao.CheckUserIsSubmittedByTime(db.Mysql, user.ID, beginDate, endDate)
-		if err != nil {
-			return nil, e.ErrMysql
-		}
-		if b {
-			answer = append(answer, strconv.Itoa(i))
+	loc, err := getActivityLocation(timezone)
+	if err != nil {
+		return nil, e.ErrBadRequest
+	}
+	days, err := getUserActivityDays(svc.submissionDao, user.ID, loc)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	answer := []string{}
+	for _, day := range days {
+		year := day.Date[:4]
+		if len(answer) == 0 || answer[len(answer)-1] != year {
+			answer = append(answer, year)
 		}
 	}
 	return answer, nil
@@ -118,8 +177,317 @@ func (svc *SubmissionServiceImpl) GetUserSubmissionList(ctx *gin.Context, pageQu
 	}, nil
 }
 
-func (svc *SubmissionServiceImpl) getYearRange(year int) (time.Time, time.Time) {
-	startDate := time.Date(year, 1, 1, 0, 0, 0, 0, time.Local)
-	endDate := time.Date(year, 12, 31, 23, 59, 59, 999999999, time.Local)
-	return startDate, endDate
+func (svc *SubmissionServiceImpl) GetSubmission(ctx *gin.Context, id uint) (*dto.SubmissionDetailDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	submission, svcErr := svc.getUserSubmission(user, id, true)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+	err := loadSubmissionArchive(svc.config, submission)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrServer
+	}
+	err = hideSubmissionCase(svc.problemCaseDao, user, submission)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	answer := dto.NewSubmissionDetailDto(submission)
+	answer.UserOutput, answer.Timeline = svc.visualizationService.ParseVisualOutput(submission.UserOutput)
+	if submission.UserID == user.ID {
+		answer.ShareCode = submission.ShareCode
+		note, err := svc.userNoteDao.GetUserNote(db.Mysql, user.ID, submission.ProblemID, submission.ID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.ErrMysql
+		}
+		answer.Note = note.Content
+	}
+	if answer.ProblemName, err = svc.problemDao.GetProblemNameByID(db.Mysql, submission.ProblemID); err != nil {
+		return nil, e.ErrMysql
+	}
+	return answer, nil
+}
+
+func (svc *SubmissionServiceImpl) DiffSubmissions(ctx *gin.Context, oldID uint, newID uint) (*dto.SubmissionDiffDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	oldSubmission, err := svc.getUserSubmission(user, oldID, true)
+	if err != nil {
+		return nil, err
+	}
+	newSubmission, err := svc.getUserSubmission(user, newID, true)
+	if err != nil {
+		return nil, err
+	}
+	if oldID == newID || oldSubmission.UserID != newSubmission.UserID || oldSubmission.ProblemID != newSubmission.ProblemID {
+		return nil, e.ErrSubmissionDiffInvalid
+	}
+	if err2 := loadSubmissionArchive(svc.config, oldSubmission, newSubmission); err2 != nil {
+		log.Println(err2)
+		return nil, e.ErrServer
+	}
+	answer := &dto.SubmissionDiffDto{
+		Old:   dto.NewSubmissionDto(oldSubmission),
+		New:   dto.NewSubmissionDto(newSubmission),
+		Lines: utils.DiffText(oldSubmission.Code, newSubmission.Code),
+	}
+	for _, line := range answer.Lines {
+		switch line.Type {
+		case consts.DiffInsert:
+			answer.Added++
+		case consts.DiffDelete:
+			answer.Removed++
+		}
+	}
+	return answer, nil
+}
+
+func (svc *SubmissionServiceImpl) ShareSubmission(ctx *gin.Context, id uint) (string, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	submission, err := svc.getUserSubmission(user, id, false)
+	if err != nil {
+		return "", err
+	}
+	if submission.ShareCode != "" {
+		return submission.ShareCode, nil
+	}
+	shareCode := utils.GetRandomToken(16)
+	if err2 := svc.submissionDao.UpdateSubmissionShareCode(db.Mysql, id, shareCode); err2 != nil {
+		log.Println(err2)
+		return "", e.ErrMysql
+	}
+	return shareCode, nil
+}
+
+func (svc *SubmissionServiceImpl) CancelShareSubmission(ctx *gin.Context, id uint) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if _, err := svc.getUserSubmission(user, id, false); err != nil {
+		return err
+	}
+	if err := svc.submissionDao.UpdateSubmissionShareCode(db.Mysql, id, ""); err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *SubmissionServiceImpl) GetSharedSubmission(shareCode string) (*dto.SharedSubmissionDto, *e.Error) {
+	if shareCode == "" {
+		return nil, e.ErrSubmissionNotExist
+	}
+	submission, err := svc.submissionDao.GetSubmissionByShareCode(db.Mysql, shareCode)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrSubmissionNotExist
+	}
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	if err = loadSubmissionArchive(svc.config, submission); err != nil {
+		log.Println(err)
+		return nil, e.ErrServer
+	}
+	answer := dto.NewSharedSubmissionDto(submission)
+	if answer.ProblemName, err = svc.problemDao.GetProblemNameByID(db.Mysql, submission.ProblemID); err != nil {
+		return nil, e.ErrMysql
+	}
+	if answer.UserName, err = svc.sysUserDao.GetUserNameByID(db.Mysql, submission.UserID); err != nil {
+		return nil, e.ErrMysql
+	}
+	return answer, nil
+}
+
+func (svc *SubmissionServiceImpl) GetSubmissionListForAdmin(pageQuery *request.PageQuery) (*response.PageInfo, *e.Error) {
+	sortProperty, sortRule, svcErr := checkSubmissionSort(pageQuery.SortProperty, pageQuery.SortRule)
+	if svcErr != nil {
+		return nil, svcErr
+	}
+	pageQuery.SortProperty, pageQuery.SortRule = sortProperty, sortRule
+	submissionReq, _ := pageQuery.Query.(*request.SubmissionForList)
+	submissions, err := svc.submissionDao.GetSubmissionList(db.Mysql, pageQuery)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	names := newSubmissionNameCache(svc.problemDao, svc.sysUserDao)
+	submissionList := make([]*dto.SubmissionDtoForAdmin, len(submissions))
+	for i, submission := range submissions {
+		if submissionList[i], err = names.newSubmissionDto(submission); err != nil {
+			log.Println(err)
+			return nil, e.ErrMysql
+		}
+	}
+	count, err := svc.submissionDao.GetSubmissionCount(db.Mysql, submissionReq)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	return &response.PageInfo{
+		Total: count,
+		Size:  int64(len(submissionList)),
+		List:  submissionList,
+	}, nil
+}
+
+func (svc *SubmissionServiceImpl) ExportSubmissions(query *request.SubmissionForList, sortProperty string, sortRule string, format string, w io.Writer) *e.Error {
+	if !consts.IsExportFormatSupported(format) {
+		return e.ErrExportFormatInvalid
+	}
+	sortProperty, sortRule, svcErr := checkSubmissionSort(sortProperty, sortRule)
+	if svcErr != nil {
+		return svcErr
+	}
+	writer := newExportWriter(format, w, submissionExportHeader)
+	if err := writer.begin(); err != nil {
+		log.Println(err)
+		return e.ErrServer
+	}
+	names := newSubmissionNameCache(svc.problemDao, svc.sysUserDao)
+	order := sortProperty + " " + sortRule + ", id " + sortRule
+	err := svc.submissionDao.EachSubmission(db.Mysql, query, order, func(submission *repository.Submission) error {
+		item, err := names.newSubmissionDto(submission)
+		if err != nil {
+			return err
+		}
+		return writer.write(item, getSubmissionExportRecord(item))
+	})
+	if err == nil {
+		err = writer.end()
+	}
+	if err != nil {
+		log.Println(err)
+		return e.ErrServer
+	}
+	return nil
+}
+
+func (svc *SubmissionServiceImpl) CheckSubmitRateLimit(ctx *gin.Context, problemID uint, action string) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	config := svc.config.RateLimitConfig
+	userLimit, problemLimit := config.UserRunPerMinute, config.ProblemRunPerMinute
+	if action != consts.SubmitActionRun {
+		action = consts.SubmitActionSubmit
+		userLimit, problemLimit = config.UserSubmitPerMinute, config.ProblemSubmitPerMinute
+	}
+	userKey := fmt.Sprintf("%s%s-%d", SubmitRateLimitKeyPrefix, action, user.ID)
+	allowed, err := takeTokens(
+		&tokenBucket{key: userKey, perMinute: userLimit},
+		&tokenBucket{key: fmt.Sprintf("%s-%d", userKey, problemID), perMinute: problemLimit},
+	)
+	if err != nil {
+		log.Println(err)
+		return e.ErrServer
+	}
+	if !allowed {
+		return e.ErrSubmitTooFrequent
+	}
+	return nil
+}
+
+func (svc *SubmissionServiceImpl) GetDuplicateSubmission(ctx *gin.Context, problemID uint, language string, code string) (*dto.SubmissionDetailDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	id, err := db.Redis.Get(getDuplicateSubmissionKey(user.ID, problemID, language, code)).Uint64()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrServer
+	}
+	answer, svcErr := svc.GetSubmission(ctx, uint(id))
+	// 上次的提交被删除时重新判题
+	if svcErr == e.ErrSubmissionNotExist {
+		return nil, nil
+	}
+	return answer, svcErr
+}
+
+// checkSubmissionSort 检验排序字段，默认按提交时间倒序
+func checkSubmissionSort(sortProperty string, sortRule string) (string, string, *e.Error) {
+	if sortProperty == "" {
+		sortProperty = "created_at"
+	}
+	if sortRule == "" {
+		sortRule = "desc"
+	}
+	if !consts.SubmissionSortProperties[sortProperty] || (sortRule != "asc" && sortRule != "desc") {
+		return "", "", e.ErrBadRequest
+	}
+	return sortProperty, sortRule, nil
+}
+
+// getUserSubmission 获取用户自己的提交，allowAdmin为true时管理员可以获取所有人的提交
+func (svc *SubmissionServiceImpl) getUserSubmission(user *dto.UserInfo, id uint, allowAdmin bool) (*repository.Submission, *e.Error) {
+	submission, err := svc.submissionDao.GetSubmissionByID(db.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrSubmissionNotExist
+	}
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	if submission.UserID != user.ID && !(allowAdmin && isAdmin(user)) {
+		return nil, e.ErrSubmissionNotExist
+	}
+	return submission, nil
+}
+
+func (svc *SubmissionServiceImpl) InsertSubmission(submission *repository.Submission) *e.Error {
+	// 题目不允许的语言不能提交
+	if _, err := checkProblemLanguage(svc.problemDao, svc.problemLanguageDao, submission.ProblemID, submission.Language); err != nil {
+		return err
+	}
+	err := db.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := svc.submissionDao.InsertSubmission(tx, submission); err != nil {
+			return err
+		}
+		// 运行代码的结果不计入题目统计
+		if submission.Status == consts.RunSuccess {
+			return nil
+		}
+		newSolver, err := updateProblemAttempt(tx, svc.problemAttemptDao, svc.submissionDao, submission)
+		if err != nil {
+			return err
+		}
+		accepted := submission.Status == consts.Accepted
+		if err = svc.problemStatisticDao.IncreaseProblemStatistic(tx, submission.ProblemID, accepted, newSolver); err != nil {
+			return err
+		}
+		// 通过的提交按耗时、内存分桶计数，用于计算分位数
+		if accepted {
+			timeBucket := getUsageBucket(submission.TimeUsed.Milliseconds())
+			if err = svc.problemStatisticDao.IncreaseProblemStatisticItem(tx, submission.ProblemID,
+				consts.StatisticCategoryTime, strconv.FormatInt(timeBucket, 10)); err != nil {
+				return err
+			}
+			memoryBucket := getUsageBucket(submission.MemoryUsed)
+			if err = svc.problemStatisticDao.IncreaseProblemStatisticItem(tx, submission.ProblemID,
+				consts.StatisticCategoryMemory, strconv.FormatInt(memoryBucket, 10)); err != nil {
+				return err
+			}
+		}
+		if err := svc.problemStatisticDao.IncreaseProblemStatisticItem(tx, submission.ProblemID,
+			consts.StatisticCategoryStatus, strconv.Itoa(submission.Status)); err != nil {
+			return err
+		}
+		return svc.problemStatisticDao.IncreaseProblemStatisticItem(tx, submission.ProblemID,
+			consts.StatisticCategoryLanguage, submission.Language)
+	})
+	if err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	clearUserActivity(submission.UserID)
+	if consts.IsFinalVerdict(submission.Status) {
+		key := getDuplicateSubmissionKey(submission.UserID, submission.ProblemID, submission.Language, submission.Code)
+		window := time.Duration(svc.config.RateLimitConfig.DuplicateWindow) * time.Second
+		if err := db.Redis.Set(key, submission.ID, window).Err(); err != nil {
+			log.Println(err)
+		}
+	}
+	return nil
+}
+
+// getDuplicateSubmissionKey 相同用户、题目、语言和代码的提交使用同一个key
+func getDuplicateSubmissionKey(userID uint, problemID uint, language string, code string) string {
+	hash := sha256.Sum256([]byte(language + "\n" + code))
+	return fmt.Sprintf("%s%d-%d-%s", DuplicateSubmissionKeyPrefix, userID, problemID, hex.EncodeToString(hash[:]))
 }
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	// activityKeyPrefix 用户每天提交数的缓存，hash的字段为时区
+	activityKeyPrefix = "activity-"
+	activityExpire    = 24 * time.Hour
+	activityDate      = "2006-01-02"
+)
+
+func getActivityKey(userID uint) string {
+	return activityKeyPrefix + strconv.Itoa(int(userID))
+}
+
+// getActivityLocation 解析IANA时区名称，为空时使用服务器时区
+func getActivityLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(timezone)
+}
+
+// getUserActivityDays 获取用户在时区loc中每天的提交数，按日期升序，优先读取缓存
+func getUserActivityDays(submissionDao dao.SubmissionDao, userID uint, loc *time.Location) ([]*dto.ActivityItem, error) {
+	key := getActivityKey(userID)
+	if data, err := db.Redis.HGet(key, loc.String()).Result(); err == nil {
+		var days []*dto.ActivityItem
+		if err = json.Unmarshal([]byte(data), &days); err == nil {
+			return days, nil
+		}
+	}
+	// 服务器时区不需要换算
+	timezone := ""
+	if loc != time.Local {
+		timezone = loc.String()
+	}
+	activities, err := submissionDao.GetUserSubmissionActivities(db.Mysql, userID, timezone)
+	if err != nil {
+		return nil, err
+	}
+	days := make([]*dto.ActivityItem, 0, len(activities))
+	for _, activity := range activities {
+		// convert_tz在数据库没有加载时区数据时返回null
+		if activity.Date == "" {
+			return nil, errors.New("mysql time zone tables are not loaded")
+		}
+		days = append(days, &dto.ActivityItem{Date: activity.Date, Count: activity.Count})
+	}
+	if data, err := json.Marshal(days); err == nil {
+		if err = db.Redis.HSet(key, loc.String(), data).Err(); err != nil {
+			log.Println(err)
+		} else {
+			db.Redis.Expire(key, activityExpire)
+		}
+	}
+	return days, nil
+}
+
+// clearUserActivity 用户提交后删除所有时区的缓存
+func clearUserActivity(userID uint) {
+	if err := db.Redis.Del(getActivityKey(userID)).Err(); err != nil {
+		log.Println(err)
+	}
+}
+
+// getActivityStreaks 计算当前和最长的连续提交天数，days按日期升序
+func getActivityStreaks(days []*dto.ActivityItem, today time.Time) (int, int) {
+	current, longest, run := 0, 0, 0
+	var last time.Time
+	for _, day := range days {
+		date, err := time.Parse(activityDate, day.Date)
+		if err != nil {
+			continue
+		}
+		if run != 0 && date.Sub(last) == 24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		last = date
+		if run > longest {
+			longest = run
+		}
+	}
+	// 最后一次提交在今天或昨天时连续天数还未中断
+	todayDate, _ := time.Parse(activityDate, today.Format(activityDate))
+	if run != 0 && todayDate.Sub(last) <= 24*time.Hour {
+		current = run
+	}
+	return current, longest
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	conf "funoj-backend/config"
+	e "funoj-backend/consts/error"
+	"funoj-backend/db"
+	"funoj-backend/file_store"
+	"funoj-backend/model/repository"
+	"github.com/go-redis/redis"
+	"io"
+	"log"
+	"time"
+)
+
+const (
+	// SubmissionArchivePath 归档的提交在对象存储中的目录，下面按提交月份分目录
+	SubmissionArchivePath = "submission"
+	// SubmissionArchiveKeyPrefix redis中读取过的归档数据，后面是提交id
+	SubmissionArchiveKeyPrefix = "submission-archive-"
+	// SubmissionArchiveCacheExpire 归档数据在redis中的缓存时间
+	SubmissionArchiveCacheExpire = time.Hour
+)
+
+// submissionArchive 归档到对象存储的字段，序列化为json后用gzip压缩
+type submissionArchive struct {
+	Code           string `json:"code"`
+	CaseData       string `json:"caseData"`
+	ExpectedOutput string `json:"expectedOutput"`
+	UserOutput     string `json:"userOutput"`
+}
+
+func (svc *SubmissionServiceImpl) ArchiveSubmissions() *e.Error {
+	config := svc.config.ArchiveConfig
+	store := file_store.NewArchiveCOS(svc.config.COSConfig)
+	before := time.Now().AddDate(0, 0, -config.RetentionDays)
+	count := 0
+	for count < config.MaxPerRun {
+		submissions, err := svc.submissionDao.GetArchivableSubmissions(db.Mysql, before, config.BatchSize)
+		if err != nil {
+			log.Println(err)
+			return e.ErrMysql
+		}
+		for _, submission := range submissions {
+			// 先上传再修改数据表，中途失败时下次重新上传并覆盖
+			archivePath, err := saveSubmissionArchive(store, submission)
+			if err != nil {
+				log.Println(err)
+				return e.ErrServer
+			}
+			if err = svc.submissionDao.ArchiveSubmission(db.Mysql, submission.ID, archivePath); err != nil {
+				log.Println(err)
+				return e.ErrMysql
+			}
+		}
+		count += len(submissions)
+		if len(submissions) < config.BatchSize {
+			break
+		}
+	}
+	log.Printf("archived %d submissions", count)
+	return nil
+}
+
+// saveSubmissionArchive 压缩提交的代码和用例数据并上传，返回在对象存储中的路径
+func saveSubmissionArchive(store file_store.Store, submission *repository.Submission) (string, error) {
+	archive := &submissionArchive{
+		Code:           submission.Code,
+		CaseData:       submission.CaseData,
+		ExpectedOutput: submission.ExpectedOutput,
+		UserOutput:     submission.UserOutput,
+	}
+	buffer := &bytes.Buffer{}
+	writer := gzip.NewWriter(buffer)
+	if err := json.NewEncoder(writer).Encode(archive); err != nil {
+		return "", err
+	}
+	if err := writer.Close(); err != nil {
+		return "", err
+	}
+	archivePath := fmt.Sprintf("%s/%s/%d.json.gz", SubmissionArchivePath, submission.CreatedAt.Format("200601"), submission.ID)
+	if err := store.SaveFile(archivePath, buffer); err != nil {
+		return "", err
+	}
+	return archivePath, nil
+}
+
+// loadSubmissionArchive 从对象存储中读回已归档提交的代码和用例数据，没有归档的提交不做处理，读取过的数据在redis中缓存一段时间
+func loadSubmissionArchive(config *conf.AppConfig, submissions ...*repository.Submission) error {
+	var store file_store.Store
+	for _, submission := range submissions {
+		if submission.ArchivePath == "" {
+			continue
+		}
+		key := fmt.Sprintf("%s%d", SubmissionArchiveKeyPrefix, submission.ID)
+		data, err := db.Redis.Get(key).Bytes()
+		if err != nil {
+			// redis出错时直接从对象存储读取
+			if !errors.Is(err, redis.Nil) {
+				log.Println(err)
+			}
+			if store == nil {
+				store = file_store.NewArchiveCOS(config.COSConfig)
+			}
+			if data, err = readSubmissionArchive(store, submission.ArchivePath); err != nil {
+				return err
+			}
+			if err = db.Redis.Set(key, data, SubmissionArchiveCacheExpire).Err(); err != nil {
+				log.Println(err)
+			}
+		}
+		archive := &submissionArchive{}
+		if err = json.Unmarshal(data, archive); err != nil {
+			return err
+		}
+		submission.Code = archive.Code
+		submission.CaseData = archive.CaseData
+		submission.ExpectedOutput = archive.ExpectedOutput
+		submission.UserOutput = archive.UserOutput
+	}
+	return nil
+}
+
+// readSubmissionArchive 下载并解压归档，返回json
+func readSubmissionArchive(store file_store.Store, archivePath string) ([]byte, error) {
+	compressed, err := store.ReadFile(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/repository"
+	"strconv"
+)
+
+// submissionNameCache 填充提交的用户名和题目信息，同一用户或题目只查询一次
+type submissionNameCache struct {
+	problemDao dao.ProblemDao
+	sysUserDao dao.SysUserDao
+	userNames  map[uint]string
+	problems   map[uint]*repository.Problem
+}
+
+func newSubmissionNameCache(problemDao dao.ProblemDao, sysUserDao dao.SysUserDao) *submissionNameCache {
+	return &submissionNameCache{
+		problemDao: problemDao,
+		sysUserDao: sysUserDao,
+		userNames:  make(map[uint]string),
+		problems:   make(map[uint]*repository.Problem),
+	}
+}
+
+func (c *submissionNameCache) newSubmissionDto(submission *repository.Submission) (*dto.SubmissionDtoForAdmin, error) {
+	answer := dto.NewSubmissionDtoForAdmin(submission)
+	userName, ok := c.userNames[submission.UserID]
+	if !ok {
+		var err error
+		if userName, err = c.sysUserDao.GetUserNameByID(db.Mysql, submission.UserID); err != nil {
+			return nil, err
+		}
+		c.userNames[submission.UserID] = userName
+	}
+	problem, ok := c.problems[submission.ProblemID]
+	if !ok {
+		problems, err := c.problemDao.GetSimpleProblemsByIDs(db.Mysql, []uint{submission.ProblemID})
+		if err != nil {
+			return nil, err
+		}
+		// 题目已被删除时保留空的名称
+		problem = &repository.Problem{}
+		if len(problems) != 0 {
+			problem = problems[0]
+		}
+		c.problems[submission.ProblemID] = problem
+	}
+	answer.UserName = userName
+	answer.ProblemNumber = problem.Number
+	answer.ProblemName = problem.Name
+	return answer, nil
+}
+
+// submissionExportHeader 导出提交时csv的表头，与getSubmissionExportRecord对应
+var submissionExportHeader = []string{"id", "userID", "userName", "problemID", "problemNumber", "problemName",
+	"language", "status", "timeUsed", "memoryUsed", "createdAt"}
+
+func getSubmissionExportRecord(submission *dto.SubmissionDtoForAdmin) []string {
+	return []string{
+		strconv.FormatUint(uint64(submission.ID), 10),
+		strconv.FormatUint(uint64(submission.UserID), 10),
+		submission.UserName,
+		strconv.FormatUint(uint64(submission.ProblemID), 10),
+		submission.ProblemNumber,
+		submission.ProblemName,
+		submission.Language,
+		strconv.Itoa(submission.Status),
+		strconv.FormatInt(submission.TimeUsed, 10),
+		strconv.FormatInt(submission.MemoryUsed, 10),
+		submission.CreatedAt.String(),
+	}
+}
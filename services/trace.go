@@ -0,0 +1,333 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	conf "funoj-backend/config"
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/form/request"
+	"github.com/gin-gonic/gin"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceService 可视化执行，用调试器在沙箱中单步运行用户代码，记录每一步的行号、调用栈、局部变量和指针指向的堆对象，供前端播放
+type TraceService interface {
+	// CheckTraceLanguage 检测语言是否支持可视化执行，以及是否配置了沙箱
+	CheckTraceLanguage(language string) *e.Error
+	// RunExecutionTrace 单步运行代码并记录轨迹，超过步数、大小或时间限制时截断
+	RunExecutionTrace(ctx *gin.Context, traceRun *request.TraceRun) (*dto.ExecutionTrace, *e.Error)
+}
+
+type TraceServiceImpl struct {
+	config *conf.AppConfig
+	mutex  sync.Mutex
+	// 正在记录轨迹的用户，每个用户同时只能进行一次
+	running map[uint]bool
+}
+
+func NewTraceService(config *conf.AppConfig) TraceService {
+	return &TraceServiceImpl{
+		config:  config,
+		running: make(map[uint]bool),
+	}
+}
+
+func (svc *TraceServiceImpl) CheckTraceLanguage(language string) *e.Error {
+	if svc.config.DebugConfig.SandboxCommand == "" {
+		return e.ErrDebugNotAvailable
+	}
+	if !consts.IsTraceLanguageSupported(language) {
+		return e.ErrTraceNotSupported
+	}
+	return nil
+}
+
+func (svc *TraceServiceImpl) RunExecutionTrace(ctx *gin.Context, traceRun *request.TraceRun) (*dto.ExecutionTrace, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if err := svc.CheckTraceLanguage(traceRun.Language); err != nil {
+		return nil, err
+	}
+	if traceRun.Code == "" || len(traceRun.Code) > consts.DebugMaxCodeSize || len(traceRun.Input) > consts.DebugMaxCodeSize {
+		return nil, e.ErrBadRequest
+	}
+	if err := svc.acquire(user.ID); err != nil {
+		return nil, err
+	}
+	defer svc.release(user.ID)
+	dir, err := os.MkdirTemp(svc.config.FilePathConfig.TempDir, "trace-")
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrServer
+	}
+	defer os.RemoveAll(dir)
+	// 请求断开时结束调试器
+	runCtx, cancel := context.WithTimeout(ctx.Request.Context(), time.Duration(svc.config.TraceConfig.Timeout)*time.Second)
+	defer cancel()
+	recorder := &traceRecorder{
+		config: svc.config.TraceConfig,
+		trace: &dto.ExecutionTrace{
+			Language: traceRun.Language,
+			Steps:    []*dto.TraceStep{},
+		},
+	}
+	d, err := newDebugger(svc.config.DebugConfig, dir, traceRun.Language, traceRun.Code, traceRun.Input, recorder.write)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrServer
+	}
+	defer d.close()
+	if _, err = d.start(runCtx); err != nil {
+		var compileErr *debugCompileError
+		if errors.As(err, &compileErr) {
+			recorder.trace.CompileOutput = compileErr.output
+			return recorder.trace, nil
+		}
+		log.Println(err)
+		return nil, e.ErrExecuteFailed
+	}
+	if err = recorder.record(runCtx, d); err != nil {
+		log.Println(err)
+		return nil, e.ErrExecuteFailed
+	}
+	return recorder.trace, nil
+}
+
+// acquire 占用一个记录名额，和调试一样需要在沙箱中运行调试器，因此限制同时进行的数量
+func (svc *TraceServiceImpl) acquire(userID uint) *e.Error {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+	if svc.running[userID] {
+		return e.ErrTraceRunning
+	}
+	if len(svc.running) >= svc.config.TraceConfig.MaxRunning {
+		return e.ErrDebugSessionLimit
+	}
+	svc.running[userID] = true
+	return nil
+}
+
+func (svc *TraceServiceImpl) release(userID uint) {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+	delete(svc.running, userID)
+}
+
+// traceRecorder 单步执行程序并生成轨迹
+type traceRecorder struct {
+	config *conf.TraceConfig
+	trace  *dto.ExecutionTrace
+	size   int
+	// 程序的输出在调试器读取输出的协程中写入
+	outputMutex sync.Mutex
+	output      strings.Builder
+}
+
+// write 记录程序的输出，超过大小限制的部分丢弃
+func (r *traceRecorder) write(output string) {
+	r.outputMutex.Lock()
+	defer r.outputMutex.Unlock()
+	if remain := r.config.MaxBytes - r.output.Len(); len(output) > remain {
+		output = output[:remain]
+	}
+	r.output.WriteString(output)
+}
+
+// takeOutput 取出上一次调用之后的输出
+func (r *traceRecorder) takeOutput() string {
+	r.outputMutex.Lock()
+	defer r.outputMutex.Unlock()
+	output := r.output.String()
+	r.output.Reset()
+	return output
+}
+
+// record 从main函数入口开始单步执行，每次在用户代码中暂停时记录一步，进入用户代码之外的函数时执行到返回
+func (r *traceRecorder) record(ctx context.Context, d debugger) error {
+	prevDepth := 0
+	// 包括在用户代码之外执行的命令，防止一直停在库函数中
+	for commands := 0; ; commands++ {
+		if commands >= 2*r.config.MaxSteps {
+			r.truncate(consts.TraceTruncatedSteps)
+			return nil
+		}
+		frames, err := d.stack()
+		if err != nil {
+			return r.stopOnError(ctx, err)
+		}
+		frames = getTraceUserFrames(frames)
+		command := consts.DebugMsgStep
+		if len(frames) == 0 {
+			// main函数已经返回
+			command = consts.DebugMsgContinue
+		} else if frames[0].Level != 0 {
+			command = consts.DebugMsgStepOut
+		} else {
+			event := consts.TraceEventStepLine
+			if len(frames) > prevDepth {
+				event = consts.TraceEventCall
+			} else if len(frames) < prevDepth {
+				event = consts.TraceEventReturn
+			}
+			step, err := r.newStep(d, frames, event)
+			if err != nil {
+				return r.stopOnError(ctx, err)
+			}
+			if !r.add(step) {
+				return nil
+			}
+			prevDepth = len(frames)
+		}
+		stop, err := d.resume(command)
+		if err != nil {
+			return r.stopOnError(ctx, err)
+		}
+		if stop.Exited {
+			r.trace.Exited = true
+			r.trace.ExitCode = stop.ExitCode
+			r.addFinalStep(stop.Reason)
+			return nil
+		}
+		// 收到信号，比如段错误，在出错的位置记录异常
+		if stop.Reason != "" {
+			if frames, err = d.stack(); err != nil {
+				return r.stopOnError(ctx, err)
+			}
+			if frames = getTraceUserFrames(frames); len(frames) == 0 {
+				r.addFinalStep(stop.Reason)
+				return nil
+			}
+			step, err := r.newStep(d, frames, consts.TraceEventException)
+			if err != nil {
+				return r.stopOnError(ctx, err)
+			}
+			step.ExceptionMsg = stop.Reason
+			r.add(step)
+			return nil
+		}
+	}
+}
+
+// newStep 读取用户代码中每个栈帧的局部变量和指针指向的堆对象，frames为栈顶在前的用户栈帧
+func (r *traceRecorder) newStep(d debugger, frames []*dto.DebugFrame, event string) (*dto.TraceStep, error) {
+	step := &dto.TraceStep{
+		Line:     frames[0].Line,
+		Event:    event,
+		FuncName: frames[0].FuncName,
+		Stack:    make([]*dto.TraceFrame, 0, len(frames)),
+	}
+	for i := len(frames) - 1; i >= 0; i-- {
+		locals, pointers, err := d.traceLocals(frames[i].Level)
+		if err != nil {
+			return nil, err
+		}
+		step.Stack = append(step.Stack, &dto.TraceFrame{
+			FuncName: frames[i].FuncName,
+			Line:     frames[i].Line,
+			Locals:   locals,
+		})
+		if err = r.readHeap(d, frames[i].Level, pointers, step); err != nil {
+			return nil, err
+		}
+	}
+	step.Stdout = r.takeOutput()
+	return step, nil
+}
+
+// readHeap 从指针开始按广度优先读取堆对象，超过数量限制时停止，无法读取的指针（比如未初始化）忽略
+func (r *traceRecorder) readHeap(d debugger, frame int, pointers []*tracePointer, step *dto.TraceStep) error {
+	for len(pointers) > 0 {
+		pointer := pointers[0]
+		pointers = pointers[1:]
+		if _, ok := step.Heap[pointer.Address]; ok {
+			continue
+		}
+		if len(step.Heap) >= r.config.MaxHeapObjects {
+			step.HeapTruncated = true
+			return nil
+		}
+		object, children, err := d.readPointer(frame, pointer)
+		if err != nil {
+			if errors.Is(err, errDebugCommandTimeout) {
+				return err
+			}
+			continue
+		}
+		if step.Heap == nil {
+			step.Heap = make(map[string]*dto.TraceHeapObject)
+		}
+		step.Heap[pointer.Address] = object
+		pointers = append(pointers, children...)
+	}
+	return nil
+}
+
+// add 添加一步，超过步数或大小限制时截断轨迹并返回false
+func (r *traceRecorder) add(step *dto.TraceStep) bool {
+	if len(r.trace.Steps) >= r.config.MaxSteps {
+		r.truncate(consts.TraceTruncatedSteps)
+		return false
+	}
+	stepBytes, err := json.Marshal(step)
+	if err != nil || r.size+len(stepBytes) > r.config.MaxBytes {
+		r.truncate(consts.TraceTruncatedSize)
+		return false
+	}
+	r.size += len(stepBytes)
+	r.trace.Steps = append(r.trace.Steps, step)
+	return true
+}
+
+// addFinalStep 不在用户代码中时程序结束或收到信号，将剩余的输出和异常信息作为最后一步，位置沿用上一步
+func (r *traceRecorder) addFinalStep(reason string) {
+	output := r.takeOutput()
+	if output == "" && reason == "" {
+		return
+	}
+	step := &dto.TraceStep{
+		Event:  consts.TraceEventReturn,
+		Stdout: output,
+		Stack:  []*dto.TraceFrame{},
+	}
+	if reason != "" {
+		step.Event = consts.TraceEventException
+		step.ExceptionMsg = reason
+	}
+	if len(r.trace.Steps) > 0 {
+		last := r.trace.Steps[len(r.trace.Steps)-1]
+		step.Line = last.Line
+		step.FuncName = last.FuncName
+	}
+	r.add(step)
+}
+
+// stopOnError 超时时截断轨迹，其它错误直接返回
+func (r *traceRecorder) stopOnError(ctx context.Context, err error) error {
+	if ctx.Err() != nil || errors.Is(err, errDebugCommandTimeout) {
+		r.truncate(consts.TraceTruncatedTime)
+		return nil
+	}
+	return err
+}
+
+func (r *traceRecorder) truncate(reason string) {
+	r.trace.Truncated = true
+	r.trace.TruncatedReason = reason
+}
+
+// getTraceUserFrames 去掉没有行号的栈帧，即用户代码之外的函数
+func getTraceUserFrames(frames []*dto.DebugFrame) []*dto.DebugFrame {
+	userFrames := make([]*dto.DebugFrame, 0, len(frames))
+	for _, frame := range frames {
+		if frame.Line > 0 {
+			userFrames = append(userFrames, frame)
+		}
+	}
+	return userFrames
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"errors"
+	conf "funoj-backend/config"
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/model/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"io"
+	"log"
+	"strconv"
+)
+
+// UserNoteService 用户对题目和自己的提交记录的私人Markdown笔记
+type UserNoteService interface {
+	// SaveProblemNote 保存对题目的笔记
+	SaveProblemNote(ctx *gin.Context, problemID uint, content string) (*dto.UserNoteDto, *e.Error)
+	// SaveSubmissionNote 保存对自己的某次提交的笔记
+	SaveSubmissionNote(ctx *gin.Context, submissionID uint, content string) (*dto.UserNoteDto, *e.Error)
+	// DeleteUserNote 删除自己的笔记
+	DeleteUserNote(ctx *gin.Context, id uint) *e.Error
+	// SearchUserNotes 搜索自己的笔记，可以按题目过滤和按关键字搜索内容，最近修改的在前
+	SearchUserNotes(ctx *gin.Context, pageQuery *request.PageQuery) (*response.PageInfo, *e.Error)
+	// ExportUserNotes 导出自己的所有笔记为csv或json，提交的笔记附带当时的代码，逐条写入w
+	ExportUserNotes(ctx *gin.Context, format string, w io.Writer) *e.Error
+}
+
+type UserNoteServiceImpl struct {
+	config        *conf.AppConfig
+	userNoteDao   dao.UserNoteDao
+	problemDao    dao.ProblemDao
+	submissionDao dao.SubmissionDao
+}
+
+func NewUserNoteService(config *conf.AppConfig, userNoteDao dao.UserNoteDao, problemDao dao.ProblemDao,
+	submissionDao dao.SubmissionDao) UserNoteService {
+	return &UserNoteServiceImpl{
+		config:        config,
+		userNoteDao:   userNoteDao,
+		problemDao:    problemDao,
+		submissionDao: submissionDao,
+	}
+}
+
+func (svc *UserNoteServiceImpl) SaveProblemNote(ctx *gin.Context, problemID uint, content string) (*dto.UserNoteDto, *e.Error) {
+	if _, err := svc.problemDao.GetProblemByID(db.Mysql, problemID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.ErrProblemNotExist
+		}
+		return nil, e.ErrMysql
+	}
+	return svc.saveUserNote(ctx, problemID, 0, content)
+}
+
+func (svc *UserNoteServiceImpl) SaveSubmissionNote(ctx *gin.Context, submissionID uint, content string) (*dto.UserNoteDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	submission, err := svc.submissionDao.GetSubmissionByID(db.Mysql, submissionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && submission.UserID != user.ID) {
+		return nil, e.ErrSubmissionNotExist
+	}
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return svc.saveUserNote(ctx, submission.ProblemID, submission.ID, content)
+}
+
+func (svc *UserNoteServiceImpl) saveUserNote(ctx *gin.Context, problemID uint, submissionID uint, content string) (*dto.UserNoteDto, *e.Error) {
+	if len(content) > consts.UserNoteMaxSize {
+		return nil, e.ErrUserNoteTooLarge
+	}
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	note := &repository.UserNote{
+		UserID:       user.ID,
+		ProblemID:    problemID,
+		SubmissionID: submissionID,
+		Content:      content,
+	}
+	if err := svc.userNoteDao.SaveUserNote(db.Mysql, note); err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	// 更新已有笔记时需要重新读取id
+	note, err := svc.userNoteDao.GetUserNote(db.Mysql, user.ID, problemID, submissionID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	answers, err := svc.newUserNoteDtos([]*repository.UserNote{note})
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	return answers[0], nil
+}
+
+func (svc *UserNoteServiceImpl) DeleteUserNote(ctx *gin.Context, id uint) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	note, err := svc.userNoteDao.GetUserNoteByID(db.Mysql, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && note.UserID != user.ID) {
+		return e.ErrUserNoteNotExist
+	}
+	if err != nil {
+		return e.ErrMysql
+	}
+	if err = svc.userNoteDao.DeleteUserNote(db.Mysql, id); err != nil {
+		log.Println(err)
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *UserNoteServiceImpl) SearchUserNotes(ctx *gin.Context, pageQuery *request.PageQuery) (*response.PageInfo, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	query, ok := pageQuery.Query.(*request.UserNoteForList)
+	if !ok || query == nil {
+		query = &request.UserNoteForList{}
+	}
+	// 只能搜索自己的笔记
+	query.UserID = user.ID
+	pageQuery.Query = query
+	notes, err := svc.userNoteDao.GetUserNoteList(db.Mysql, pageQuery)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	noteList, err := svc.newUserNoteDtos(notes)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	count, err := svc.userNoteDao.GetUserNoteCount(db.Mysql, query)
+	if err != nil {
+		log.Println(err)
+		return nil, e.ErrMysql
+	}
+	return &response.PageInfo{
+		Total: count,
+		Size:  int64(len(noteList)),
+		List:  noteList,
+	}, nil
+}
+
+func (svc *UserNoteServiceImpl) ExportUserNotes(ctx *gin.Context, format string, w io.Writer) *e.Error {
+	if !consts.IsExportFormatSupported(format) {
+		return e.ErrExportFormatInvalid
+	}
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	writer := newExportWriter(format, w, userNoteExportHeader)
+	if err := writer.begin(); err != nil {
+		log.Println(err)
+		return e.ErrServer
+	}
+	problems := make(map[uint]*repository.Problem)
+	err := svc.userNoteDao.EachUserNote(db.Mysql, user.ID, func(note *repository.UserNote) error {
+		item := &dto.UserNoteExportDto{UserNoteDto: dto.NewUserNoteDto(note)}
+		problem, ok := problems[note.ProblemID]
+		if !ok {
+			simpleProblems, err := svc.problemDao.GetSimpleProblemsByIDs(db.Mysql, []uint{note.ProblemID})
+			if err != nil {
+				return err
+			}
+			problem = &repository.Problem{}
+			if len(simpleProblems) != 0 {
+				problem = simpleProblems[0]
+			}
+			problems[note.ProblemID] = problem
+		}
+		item.ProblemNumber = problem.Number
+		item.ProblemName = problem.Name
+		if note.SubmissionID != 0 {
+			submission, err := svc.submissionDao.GetSubmissionByID(db.Mysql, note.SubmissionID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if err == nil {
+				if err = loadSubmissionArchive(svc.config, submission); err != nil {
+					return err
+				}
+				item.Language = submission.Language
+				item.Status = submission.Status
+				item.Code = submission.Code
+			}
+		}
+		return writer.write(item, getUserNoteExportRecord(item))
+	})
+	if err == nil {
+		err = writer.end()
+	}
+	if err != nil {
+		log.Println(err)
+		return e.ErrServer
+	}
+	return nil
+}
+
+// newUserNoteDtos 批量填充题目的编号和名称
+func (svc *UserNoteServiceImpl) newUserNoteDtos(notes []*repository.UserNote) ([]*dto.UserNoteDto, error) {
+	problemIDs := make([]uint, len(notes))
+	for i, note := range notes {
+		problemIDs[i] = note.ProblemID
+	}
+	problems, err := svc.problemDao.GetSimpleProblemsByIDs(db.Mysql, problemIDs)
+	if err != nil {
+		return nil, err
+	}
+	problemMap := make(map[uint]*repository.Problem, len(problems))
+	for _, problem := range problems {
+		problemMap[problem.ID] = problem
+	}
+	answer := make([]*dto.UserNoteDto, len(notes))
+	for i, note := range notes {
+		answer[i] = dto.NewUserNoteDto(note)
+		if problem, ok := problemMap[note.ProblemID]; ok {
+			answer[i].ProblemNumber = problem.Number
+			answer[i].ProblemName = problem.Name
+		}
+	}
+	return answer, nil
+}
+
+// userNoteExportHeader 导出笔记时csv的表头，与getUserNoteExportRecord对应
+var userNoteExportHeader = []string{"problemID", "problemNumber", "problemName", "submissionID",
+	"language", "status", "code", "content", "updatedAt"}
+
+func getUserNoteExportRecord(note *dto.UserNoteExportDto) []string {
+	submissionID, status := "", ""
+	if note.SubmissionID != 0 {
+		submissionID = strconv.FormatUint(uint64(note.SubmissionID), 10)
+		status = strconv.Itoa(note.Status)
+	}
+	return []string{
+		strconv.FormatUint(uint64(note.ProblemID), 10),
+		note.ProblemNumber,
+		note.ProblemName,
+		submissionID,
+		note.Language,
+		status,
+		note.Code,
+		note.Content,
+		note.UpdatedAt.String(),
+	}
+}
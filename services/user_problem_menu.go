@@ -0,0 +1,498 @@
+package services
+
+import (
+	"errors"
+	"funoj-backend/consts"
+	e "funoj-backend/consts/error"
+	"funoj-backend/dao"
+	"funoj-backend/db"
+	"funoj-backend/model/dto"
+	"funoj-backend/model/form/request"
+	"funoj-backend/model/form/response"
+	"funoj-backend/model/repository"
+	"funoj-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"log"
+	"time"
+)
+
+// UserProblemMenuService 用户自己创建的题单、关注的题单以及收藏的题目
+type UserProblemMenuService interface {
+	// InsertUserProblemMenu 用户创建题单，没有指定可见性时为私有
+	InsertUserProblemMenu(ctx *gin.Context, problemMenu *request.UserProblemMenu) (uint, *e.Error)
+	// UpdateUserProblemMenu 更新自己的题单，设置为分享时生成分享码
+	UpdateUserProblemMenu(ctx *gin.Context, problemMenu *request.UserProblemMenu) *e.Error
+	// DeleteUserProblemMenu 删除自己的题单
+	DeleteUserProblemMenu(ctx *gin.Context, id uint) *e.Error
+	// AddUserProblemMenuProblem 向自己的题单中添加题目
+	AddUserProblemMenuProblem(ctx *gin.Context, menuID uint, problemID uint) *e.Error
+	// RemoveUserProblemMenuProblem 从自己的题单中移除题目
+	RemoveUserProblemMenuProblem(ctx *gin.Context, menuID uint, problemID uint) *e.Error
+	// GetUserProblemMenuList 获取自己创建的题单
+	GetUserProblemMenuList(ctx *gin.Context, query *request.PageQuery) (*response.PageInfo, *e.Error)
+	// GetPublicProblemMenuList 获取公开的题单
+	GetPublicProblemMenuList(query *request.PageQuery) (*response.PageInfo, *e.Error)
+	// GetSharedProblemMenu 通过分享码获取题单和做题进度
+	GetSharedProblemMenu(ctx *gin.Context, shareCode string) (*dto.ProblemMenuDetailDto, *e.Error)
+	// CloneProblemMenu 将公开的题单复制为自己的私有题单
+	CloneProblemMenu(ctx *gin.Context, menuID uint) (uint, *e.Error)
+	// FollowProblemMenu 关注题单，分享的题单需要提供分享码
+	FollowProblemMenu(ctx *gin.Context, menuID uint, shareCode string) *e.Error
+	// UnfollowProblemMenu 取消关注题单
+	UnfollowProblemMenu(ctx *gin.Context, menuID uint) *e.Error
+	// GetFollowedProblemMenus 获取关注的题单以及做题进度
+	GetFollowedProblemMenus(ctx *gin.Context) ([]*dto.ProblemMenuDetailDto, *e.Error)
+	// BookmarkProblem 收藏题目
+	BookmarkProblem(ctx *gin.Context, problemID uint) *e.Error
+	// UnbookmarkProblem 取消收藏题目
+	UnbookmarkProblem(ctx *gin.Context, problemID uint) *e.Error
+	// GetBookmarkedProblems 获取收藏的题目
+	GetBookmarkedProblems(ctx *gin.Context, query *request.PageQuery) (*response.PageInfo, *e.Error)
+}
+
+type UserProblemMenuServiceImpl struct {
+	problemMenuDao     dao.ProblemMenuDao
+	problemDao         dao.ProblemDao
+	problemAttemptDao  dao.ProblemAttemptDao
+	problemBookmarkDao dao.ProblemBookmarkDao
+	sysUserDao         dao.SysUserDao
+}
+
+func NewUserProblemMenuService(pmd dao.ProblemMenuDao, pd dao.ProblemDao, pad dao.ProblemAttemptDao, pbd dao.ProblemBookmarkDao,
+	sud dao.SysUserDao) UserProblemMenuService {
+	return &UserProblemMenuServiceImpl{
+		problemMenuDao:     pmd,
+		problemDao:         pd,
+		problemAttemptDao:  pad,
+		problemBookmarkDao: pbd,
+		sysUserDao:         sud,
+	}
+}
+
+func (svc *UserProblemMenuServiceImpl) InsertUserProblemMenu(ctx *gin.Context, menuReq *request.UserProblemMenu) (uint, *e.Error) {
+	problemMenu := &repository.ProblemMenu{
+		Name:        menuReq.Name,
+		Icon:        menuReq.Icon,
+		Description: menuReq.Description,
+		Type:        consts.ProblemMenuTypeUser,
+		CreatorID:   ctx.Keys["user"].(*dto.UserInfo).ID,
+	}
+	if problemMenu.Name == "" {
+		problemMenu.Name = "未命名题单"
+	}
+	visibility := consts.ProblemMenuVisibilityPrivate
+	if menuReq.Visibility != nil {
+		visibility = *menuReq.Visibility
+	}
+	if err := svc.setProblemMenuVisibility(problemMenu, visibility); err != nil {
+		return 0, err
+	}
+	if err := svc.problemMenuDao.InsertProblemMenu(db.Mysql, problemMenu); err != nil {
+		return 0, e.ErrMysql
+	}
+	return problemMenu.ID, nil
+}
+
+func (svc *UserProblemMenuServiceImpl) UpdateUserProblemMenu(ctx *gin.Context, menuReq *request.UserProblemMenu) *e.Error {
+	old, err := svc.getOwnProblemMenu(ctx, menuReq.ID)
+	if err != nil {
+		return err
+	}
+	// 保留原有的分享码，重新设置为分享时链接不变
+	problemMenu := &repository.ProblemMenu{
+		Name:        menuReq.Name,
+		Icon:        menuReq.Icon,
+		Description: menuReq.Description,
+		ShareCode:   old.ShareCode,
+	}
+	visibility := old.Visibility
+	if menuReq.Visibility != nil {
+		visibility = *menuReq.Visibility
+	}
+	if err = svc.setProblemMenuVisibility(problemMenu, visibility); err != nil {
+		return err
+	}
+	updateErr := db.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := svc.problemMenuDao.UpdateProblemMenu(tx, &repository.ProblemMenu{
+			Model:       gorm.Model{ID: old.ID, UpdatedAt: time.Now()},
+			Name:        problemMenu.Name,
+			Icon:        problemMenu.Icon,
+			Description: problemMenu.Description,
+		}); err != nil {
+			return err
+		}
+		return svc.problemMenuDao.UpdateProblemMenuVisibility(tx, old.ID, problemMenu.Visibility, problemMenu.ShareCode)
+	})
+	if updateErr != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *UserProblemMenuServiceImpl) DeleteUserProblemMenu(ctx *gin.Context, id uint) *e.Error {
+	if _, err := svc.getOwnProblemMenu(ctx, id); err != nil {
+		return err
+	}
+	if err := svc.problemMenuDao.DeleteProblemMenuByID(db.Mysql, id); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *UserProblemMenuServiceImpl) AddUserProblemMenuProblem(ctx *gin.Context, menuID uint, problemID uint) *e.Error {
+	if _, err := svc.getOwnProblemMenu(ctx, menuID); err != nil {
+		return err
+	}
+	// 只能添加启用的题目
+	problem, err := svc.problemDao.GetProblemByID(db.Mysql, problemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && problem.Enable != 1) {
+		return e.ErrProblemNotExist
+	}
+	if err != nil {
+		return e.ErrMysql
+	}
+	// 新添加的题目放在未分组的最后
+	sort, err := svc.problemMenuDao.GetProblemMenuMaxSort(db.Mysql, menuID)
+	if err != nil {
+		return e.ErrMysql
+	}
+	if err = svc.problemMenuDao.InsertProblemMenuItem(db.Mysql, &repository.ProblemMenuAssociation{
+		ProblemMenuID: menuID,
+		ProblemID:     problemID,
+		Sort:          sort + 1,
+	}); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *UserProblemMenuServiceImpl) RemoveUserProblemMenuProblem(ctx *gin.Context, menuID uint, problemID uint) *e.Error {
+	if _, err := svc.getOwnProblemMenu(ctx, menuID); err != nil {
+		return err
+	}
+	if err := svc.problemMenuDao.DeleteProblemMenuItem(db.Mysql, menuID, problemID); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *UserProblemMenuServiceImpl) GetUserProblemMenuList(ctx *gin.Context, query *request.PageQuery) (*response.PageInfo, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	menuType := consts.ProblemMenuTypeUser
+	menuQuery := &request.ProblemMenuForList{}
+	if query.Query != nil {
+		menuQuery = query.Query.(*request.ProblemMenuForList)
+	}
+	menuQuery.CreatorID = user.ID
+	menuQuery.Type = &menuType
+	query.Query = menuQuery
+	// 自己的题单返回分享码
+	return svc.getProblemMenuPage(query, menuQuery, true)
+}
+
+func (svc *UserProblemMenuServiceImpl) GetPublicProblemMenuList(query *request.PageQuery) (*response.PageInfo, *e.Error) {
+	visibility := consts.ProblemMenuVisibilityPublic
+	menuQuery := &request.ProblemMenuForList{}
+	if query.Query != nil {
+		menuQuery = query.Query.(*request.ProblemMenuForList)
+	}
+	menuQuery.Visibility = &visibility
+	query.Query = menuQuery
+	return svc.getProblemMenuPage(query, menuQuery, false)
+}
+
+func (svc *UserProblemMenuServiceImpl) GetSharedProblemMenu(ctx *gin.Context, shareCode string) (*dto.ProblemMenuDetailDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if shareCode == "" {
+		return nil, e.ErrProblemMenuNotExist
+	}
+	menu, err := svc.problemMenuDao.GetProblemMenuByShareCode(db.Mysql, shareCode)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, e.ErrProblemMenuNotExist
+	}
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	// 题单不再分享后，分享链接失效
+	if menu.Visibility != consts.ProblemMenuVisibilityShared {
+		return nil, e.ErrProblemMenuForbidden
+	}
+	detail, err := svc.getProblemMenuDetail(user.ID, menu)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return detail, nil
+}
+
+func (svc *UserProblemMenuServiceImpl) CloneProblemMenu(ctx *gin.Context, menuID uint) (uint, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	source, err := svc.problemMenuDao.GetProblemMenuByID(db.Mysql, menuID)
+	if err != nil {
+		return 0, e.ErrMysql
+	}
+	if source.ID == 0 {
+		return 0, e.ErrProblemMenuNotExist
+	}
+	// 只能复制公开的题单或者自己的题单
+	if source.Visibility != consts.ProblemMenuVisibilityPublic && source.CreatorID != user.ID {
+		return 0, e.ErrProblemMenuForbidden
+	}
+	sections, err := svc.problemMenuDao.GetProblemMenuSections(db.Mysql, menuID)
+	if err != nil {
+		return 0, e.ErrMysql
+	}
+	items, err := svc.problemMenuDao.GetProblemMenuItems(db.Mysql, menuID)
+	if err != nil {
+		return 0, e.ErrMysql
+	}
+	menu := &repository.ProblemMenu{
+		Name:        source.Name,
+		Icon:        source.Icon,
+		Description: source.Description,
+		CreatorID:   user.ID,
+		Type:        consts.ProblemMenuTypeUser,
+		Visibility:  consts.ProblemMenuVisibilityPrivate,
+	}
+	err = db.Mysql.Transaction(func(tx *gorm.DB) error {
+		if err := svc.problemMenuDao.InsertProblemMenu(tx, menu); err != nil {
+			return err
+		}
+		sectionIDs := make(map[uint]uint, len(sections))
+		for _, section := range sections {
+			newSection := &repository.ProblemMenuSection{
+				MenuID: menu.ID,
+				Name:   section.Name,
+				Sort:   section.Sort,
+			}
+			if err := svc.problemMenuDao.InsertProblemMenuSection(tx, newSection); err != nil {
+				return err
+			}
+			sectionIDs[section.ID] = newSection.ID
+		}
+		for _, item := range items {
+			if err := svc.problemMenuDao.InsertProblemMenuItem(tx, &repository.ProblemMenuAssociation{
+				ProblemMenuID: menu.ID,
+				ProblemID:     item.ProblemID,
+				SectionID:     sectionIDs[item.SectionID],
+				Sort:          item.Sort,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Println(err)
+		return 0, e.ErrMysql
+	}
+	return menu.ID, nil
+}
+
+func (svc *UserProblemMenuServiceImpl) FollowProblemMenu(ctx *gin.Context, menuID uint, shareCode string) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	menu, err := svc.problemMenuDao.GetProblemMenuByID(db.Mysql, menuID)
+	if err != nil {
+		return e.ErrMysql
+	}
+	if menu.ID == 0 {
+		return e.ErrProblemMenuNotExist
+	}
+	switch menu.Visibility {
+	case consts.ProblemMenuVisibilityPrivate:
+		if menu.CreatorID != user.ID {
+			return e.ErrProblemMenuForbidden
+		}
+	case consts.ProblemMenuVisibilityShared:
+		if menu.CreatorID != user.ID && menu.ShareCode != shareCode {
+			return e.ErrProblemMenuForbidden
+		}
+	}
+	if err = svc.problemMenuDao.InsertProblemMenuFollow(db.Mysql, menuID, user.ID); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *UserProblemMenuServiceImpl) UnfollowProblemMenu(ctx *gin.Context, menuID uint) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if err := svc.problemMenuDao.DeleteProblemMenuFollow(db.Mysql, menuID, user.ID); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *UserProblemMenuServiceImpl) GetFollowedProblemMenus(ctx *gin.Context) ([]*dto.ProblemMenuDetailDto, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	menus, err := svc.problemMenuDao.GetFollowedProblemMenus(db.Mysql, user.ID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	answer := make([]*dto.ProblemMenuDetailDto, 0, len(menus))
+	for _, menu := range menus {
+		// 关注后被设置为私有的题单不再展示
+		if menu.Visibility == consts.ProblemMenuVisibilityPrivate && menu.CreatorID != user.ID {
+			continue
+		}
+		detail, err := svc.getProblemMenuDetail(user.ID, menu)
+		if err != nil {
+			return nil, e.ErrMysql
+		}
+		answer = append(answer, detail)
+	}
+	return answer, nil
+}
+
+func (svc *UserProblemMenuServiceImpl) BookmarkProblem(ctx *gin.Context, problemID uint) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	problem, err := svc.problemDao.GetProblemByID(db.Mysql, problemID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && problem.Enable != 1) {
+		return e.ErrProblemNotExist
+	}
+	if err != nil {
+		return e.ErrMysql
+	}
+	if err = svc.problemBookmarkDao.InsertProblemBookmark(db.Mysql, user.ID, problemID); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *UserProblemMenuServiceImpl) UnbookmarkProblem(ctx *gin.Context, problemID uint) *e.Error {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	if err := svc.problemBookmarkDao.DeleteProblemBookmark(db.Mysql, user.ID, problemID); err != nil {
+		return e.ErrMysql
+	}
+	return nil
+}
+
+func (svc *UserProblemMenuServiceImpl) GetBookmarkedProblems(ctx *gin.Context, query *request.PageQuery) (*response.PageInfo, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	bookmarks, err := svc.problemBookmarkDao.GetProblemBookmarkList(db.Mysql, user.ID, query)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	problemIDs := make([]uint, len(bookmarks))
+	for i, bookmark := range bookmarks {
+		problemIDs[i] = bookmark.ProblemID
+	}
+	problems, err := svc.problemDao.GetSimpleProblemsByIDs(db.Mysql, problemIDs)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	problemMap := make(map[uint]*repository.Problem, len(problems))
+	for _, problem := range problems {
+		problemMap[problem.ID] = problem
+	}
+	statuses, err := svc.problemAttemptDao.GetProblemAttemptStatuses(db.Mysql, user.ID, problemIDs)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	list := make([]*dto.ProblemBookmarkDto, 0, len(bookmarks))
+	for _, bookmark := range bookmarks {
+		// 题目被删除或停用后不再展示
+		problem, ok := problemMap[bookmark.ProblemID]
+		if !ok || problem.Enable != 1 {
+			continue
+		}
+		bookmarkDto := dto.NewProblemBookmarkDto(bookmark, problem)
+		bookmarkDto.Status = statuses[bookmark.ProblemID]
+		list = append(list, bookmarkDto)
+	}
+	count, err := svc.problemBookmarkDao.GetProblemBookmarkCount(db.Mysql, user.ID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return &response.PageInfo{
+		Total: count,
+		Size:  int64(len(list)),
+		List:  list,
+	}, nil
+}
+
+// getOwnProblemMenu 获取题单并检测当前用户是否为题单的创建者，管理员可以操作所有题单
+func (svc *UserProblemMenuServiceImpl) getOwnProblemMenu(ctx *gin.Context, menuID uint) (*repository.ProblemMenu, *e.Error) {
+	user := ctx.Keys["user"].(*dto.UserInfo)
+	menu, err := svc.problemMenuDao.GetProblemMenuByID(db.Mysql, menuID)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	if menu.ID == 0 {
+		return nil, e.ErrProblemMenuNotExist
+	}
+	if menu.CreatorID != user.ID && !isAdmin(user) {
+		return nil, e.ErrProblemMenuForbidden
+	}
+	return menu, nil
+}
+
+// setProblemMenuVisibility 校验并设置题单可见性，分享的题单没有分享码时生成分享码
+func (svc *UserProblemMenuServiceImpl) setProblemMenuVisibility(menu *repository.ProblemMenu, visibility int) *e.Error {
+	switch visibility {
+	case consts.ProblemMenuVisibilityPublic, consts.ProblemMenuVisibilityPrivate:
+	case consts.ProblemMenuVisibilityShared:
+		if menu.ShareCode == "" {
+			menu.ShareCode = utils.GetRandomToken(16)
+		}
+	default:
+		return e.ErrProblemMenuVisibilityWrong
+	}
+	menu.Visibility = visibility
+	return nil
+}
+
+// getProblemMenuPage 分页读取题单，并读取题目数量和创建者名称，withShareCode表示是否返回分享码
+func (svc *UserProblemMenuServiceImpl) getProblemMenuPage(query *request.PageQuery, menuQuery *request.ProblemMenuForList,
+	withShareCode bool) (*response.PageInfo, *e.Error) {
+	menus, err := svc.problemMenuDao.GetProblemMenuList(db.Mysql, query)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	list := make([]*dto.ProblemMenuDtoForList, len(menus))
+	for i, menu := range menus {
+		list[i] = dto.NewProblemMenuDtoForList(menu)
+		if withShareCode && menu.Visibility == consts.ProblemMenuVisibilityShared {
+			list[i].ShareCode = menu.ShareCode
+		}
+		list[i].ProblemCount, err = svc.problemMenuDao.GetProblemMenuProblemCount(db.Mysql, menu.ID)
+		if err != nil {
+			return nil, e.ErrMysql
+		}
+		list[i].CreatorName, err = svc.sysUserDao.GetUserNameByID(db.Mysql, menu.CreatorID)
+		if err != nil {
+			return nil, e.ErrMysql
+		}
+	}
+	count, err := svc.problemMenuDao.GetProblemMenuCount(db.Mysql, menuQuery)
+	if err != nil {
+		return nil, e.ErrMysql
+	}
+	return &response.PageInfo{
+		Total: count,
+		Size:  int64(len(list)),
+		List:  list,
+	}, nil
+}
+
+// getProblemMenuDetail 读取题单信息、用户的做题进度以及是否关注
+func (svc *UserProblemMenuServiceImpl) getProblemMenuDetail(userID uint, menu *repository.ProblemMenu) (*dto.ProblemMenuDetailDto, error) {
+	progress, err := getProblemMenuProgress(svc.problemMenuDao, svc.problemDao, svc.problemAttemptDao, userID, menu.ID)
+	if err != nil {
+		return nil, err
+	}
+	followed, err := svc.problemMenuDao.CheckProblemMenuFollowed(db.Mysql, menu.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	menuDto := dto.NewProblemMenuDtoForList(menu)
+	menuDto.ProblemCount = int64(progress.ProblemCount)
+	menuDto.CreatorName, err = svc.sysUserDao.GetUserNameByID(db.Mysql, menu.CreatorID)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.ProblemMenuDetailDto{
+		Menu:     menuDto,
+		Progress: progress,
+		Followed: followed,
+	}, nil
+}
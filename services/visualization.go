@@ -0,0 +1,179 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	conf "funoj-backend/config"
+	"funoj-backend/consts"
+	"funoj-backend/model/dto"
+	"strings"
+)
+
+// maxVisualErrors 最多记录的非法指令数
+const maxVisualErrors = 20
+
+// maxVisualTargetLength 指令中目标名称的最大长度
+const maxVisualTargetLength = 64
+
+// VisualizationService 解析程序输出的可视化指令，生成算法动画使用的时间线
+type VisualizationService interface {
+	// ParseVisualOutput 解析标准输出中带标记的可视化指令，返回去掉指令行后的输出和时间线，
+	// 非法指令会被忽略并记录原因，没有指令时时间线为nil
+	ParseVisualOutput(stdout string) (string, *dto.VisualTimeline)
+}
+
+type VisualizationServiceImpl struct {
+	config *conf.AppConfig
+}
+
+func NewVisualizationService(config *conf.AppConfig) VisualizationService {
+	return &VisualizationServiceImpl{
+		config: config,
+	}
+}
+
+func (svc *VisualizationServiceImpl) ParseVisualOutput(stdout string) (string, *dto.VisualTimeline) {
+	parser := &visualParser{
+		config:   svc.config.VisualConfig,
+		timeline: &dto.VisualTimeline{Commands: []*dto.VisualCommand{}},
+		arrays:   make(map[string]int),
+		graphs:   make(map[string]map[string]bool),
+	}
+	lines := strings.Split(stdout, "\n")
+	output := make([]string, 0, len(lines))
+	found := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, consts.VisualStdoutMarker) {
+			found = true
+			parser.parseLine(strings.TrimPrefix(line, consts.VisualStdoutMarker))
+			continue
+		}
+		output = append(output, line)
+	}
+	if !found {
+		return stdout, nil
+	}
+	return strings.Join(output, "\n"), parser.timeline
+}
+
+// visualParser 记录已初始化的数组长度和图的节点，用于校验指令
+type visualParser struct {
+	config   *conf.VisualConfig
+	timeline *dto.VisualTimeline
+	size     int
+	// 已读取的指令数，包括非法指令
+	count int
+	// 数组名称 -> 长度
+	arrays map[string]int
+	// 图名称 -> 节点id
+	graphs map[string]map[string]bool
+}
+
+func (p *visualParser) parseLine(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" || p.timeline.Truncated {
+		return
+	}
+	if len(p.timeline.Commands) >= p.config.MaxCommands {
+		p.truncate(consts.VisualTruncatedCount)
+		return
+	}
+	if p.size+len(line) > p.config.MaxCommandBytes {
+		p.truncate(consts.VisualTruncatedSize)
+		return
+	}
+	p.size += len(line)
+	p.count++
+	command := &dto.VisualCommand{}
+	if err := json.Unmarshal([]byte(line), command); err != nil {
+		p.addError("指令不是合法的json")
+		return
+	}
+	if err := p.validate(command); err != "" {
+		p.addError(err)
+		return
+	}
+	command.Seq = len(p.timeline.Commands) + 1
+	p.timeline.Commands = append(p.timeline.Commands, command)
+}
+
+// validate 校验指令，返回错误原因，合法时返回空字符串
+func (p *visualParser) validate(command *dto.VisualCommand) string {
+	if command.Target == "" || len(command.Target) > maxVisualTargetLength {
+		return "target不合法"
+	}
+	length, isArray := p.arrays[command.Target]
+	switch command.Op {
+	case consts.VisualOpArrayInit:
+		if len(command.Values) > p.config.MaxArrayLength {
+			return "数组长度超过限制"
+		}
+		p.arrays[command.Target] = len(command.Values)
+	case consts.VisualOpArraySet:
+		if !isArray {
+			return "数组未初始化"
+		}
+		if command.Index == nil || !inRange(*command.Index, length) || len(command.Value) == 0 {
+			return "数组下标或值不合法"
+		}
+	case consts.VisualOpArraySwap:
+		if !isArray {
+			return "数组未初始化"
+		}
+		if command.I == nil || command.J == nil || !inRange(*command.I, length) || !inRange(*command.J, length) {
+			return "数组下标不合法"
+		}
+	case consts.VisualOpArrayHighlight:
+		if !isArray {
+			return "数组未初始化"
+		}
+		if len(command.Indices) > length {
+			return "高亮的下标过多"
+		}
+		for _, index := range command.Indices {
+			if !inRange(index, length) {
+				return "数组下标不合法"
+			}
+		}
+	case consts.VisualOpPointerMove:
+		if !isArray {
+			return "数组未初始化"
+		}
+		// 指针可以指向数组两端之外的一个位置，比如循环结束时
+		if command.Name == "" || command.Index == nil || *command.Index < -1 || *command.Index > length {
+			return "指针名称或下标不合法"
+		}
+	case consts.VisualOpGraphNode:
+		if command.ID == "" {
+			return "节点id不能为空"
+		}
+		if p.graphs[command.Target] == nil {
+			p.graphs[command.Target] = make(map[string]bool)
+		}
+		p.graphs[command.Target][command.ID] = true
+	case consts.VisualOpGraphEdge:
+		nodes := p.graphs[command.Target]
+		if !nodes[command.From] || !nodes[command.To] {
+			return "边的端点不存在"
+		}
+	default:
+		return "不支持的指令" + command.Op
+	}
+	return ""
+}
+
+func (p *visualParser) addError(reason string) {
+	if len(p.timeline.Errors) >= maxVisualErrors {
+		return
+	}
+	p.timeline.Errors = append(p.timeline.Errors, fmt.Sprintf("第%d条指令: %s", p.count, reason))
+}
+
+func (p *visualParser) truncate(reason string) {
+	p.timeline.Truncated = true
+	p.timeline.TruncatedReason = reason
+}
+
+func inRange(index int, length int) bool {
+	return index >= 0 && index < length
+}
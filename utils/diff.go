@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"funoj-backend/consts"
+	"strings"
+)
+
+// DiffLine 代码对比的一行，行号从1开始，不存在的一边为0
+type DiffLine struct {
+	Type    string `json:"type"`
+	Text    string `json:"text"`
+	OldLine int    `json:"oldLine"`
+	NewLine int    `json:"newLine"`
+}
+
+// DiffText 按行对比两段文本，使用Myers算法得到最少的修改
+func DiffText(oldText string, newText string) []*DiffLine {
+	return DiffLines(splitLines(oldText), splitLines(newText))
+}
+
+// DiffLines 按行对比，修改超过 consts.DiffMaxEdits 行时整体视为删除后插入
+func DiffLines(a []string, b []string) []*DiffLine {
+	n, m := len(a), len(b)
+	// trace[d] 保存第d步开始前k在[-d-1, d+1]范围内能到达的最远x
+	var trace [][]int
+	v := map[int]int{1: 0}
+	found := false
+	for d := 0; d <= n+m && d <= consts.DiffMaxEdits; d++ {
+		snapshot := make([]int, 2*d+3)
+		for k := -d - 1; k <= d+1; k++ {
+			snapshot[k+d+1] = v[k]
+		}
+		trace = append(trace, snapshot)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1] < v[k+1]) {
+				x = v[k+1]
+			} else {
+				x = v[k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[k] = x
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+		if found {
+			break
+		}
+	}
+	if !found {
+		return replaceLines(a, b)
+	}
+	return backtrackDiff(a, b, trace)
+}
+
+func backtrackDiff(a []string, b []string, trace [][]int) []*DiffLine {
+	x, y := len(a), len(b)
+	var reversed []*DiffLine
+	for d := len(trace) - 1; d >= 0; d-- {
+		get := func(k int) int {
+			return trace[d][k+d+1]
+		}
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(k-1) < get(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := get(prevK)
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			reversed = append(reversed, &DiffLine{Type: consts.DiffEqual, Text: a[x-1], OldLine: x, NewLine: y})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				reversed = append(reversed, &DiffLine{Type: consts.DiffInsert, Text: b[y-1], NewLine: y})
+			} else {
+				reversed = append(reversed, &DiffLine{Type: consts.DiffDelete, Text: a[x-1], OldLine: x})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	lines := make([]*DiffLine, len(reversed))
+	for i, line := range reversed {
+		lines[len(reversed)-1-i] = line
+	}
+	return lines
+}
+
+func replaceLines(a []string, b []string) []*DiffLine {
+	lines := make([]*DiffLine, 0, len(a)+len(b))
+	for i, text := range a {
+		lines = append(lines, &DiffLine{Type: consts.DiffDelete, Text: text, OldLine: i + 1})
+	}
+	for i, text := range b {
+		lines = append(lines, &DiffLine{Type: consts.DiffInsert, Text: text, NewLine: i + 1})
+	}
+	return lines
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"log"
+	"time"
+)
+
+// RunPeriodically 启动一个协程，每隔interval执行一次job，调用返回的函数停止执行
+func RunPeriodically(interval time.Duration, job func()) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				runJob(job)
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
+
+// runJob 执行任务，避免任务panic导致协程退出
+func runJob(job func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Println("periodic job panic:", r)
+		}
+	}()
+	job()
+}
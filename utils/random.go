@@ -1,6 +1,8 @@
 package utils
 
 import (
+	crand "crypto/rand"
+	"encoding/hex"
 	"github.com/google/uuid"
 	"log"
 	"math/rand"
@@ -42,3 +44,12 @@ func GetRandomPassword(length int) string {
 	}
 	return string(bytes)
 }
+
+// GetRandomToken 生成不可猜测的随机token，用于分享链接等，长度为length*2的16进制字符串
+func GetRandomToken(length int) string {
+	bytes := make([]byte, length)
+	if _, err := crand.Read(bytes); err != nil {
+		log.Fatal(err)
+	}
+	return hex.EncodeToString(bytes)
+}
@@ -26,3 +26,8 @@ func (t Time) MarshalJson() ([]byte, error) {
 func (t Time) String() string {
 	return time.Time(t).Format(timeFormat)
 }
+
+// ParseTime 按照Time的格式解析本地时间
+func ParseTime(value string) (time.Time, error) {
+	return time.ParseInLocation(timeFormat, value, time.Local)
+}